@@ -0,0 +1,25 @@
+// Command docker-credential-passmanager lets docker keep registry logins in the vault
+// Enable it with "credsStore": "passmanager" in ~/.docker/config.json
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hambosto/passmanager/config"
+	"github.com/hambosto/passmanager/internal/presentation/cli"
+)
+
+func main() {
+	cfg, err := config.LoadConfig(config.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		cfg = config.DefaultConfig()
+	}
+
+	// docker reads errors of credential helpers from stdout
+	if err := cli.New(cfg).RunDockerCredential(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stdout, err)
+		os.Exit(1)
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hambosto/passmanager/config"
+	"github.com/hambosto/passmanager/internal/presentation/cli"
 	"github.com/hambosto/passmanager/internal/presentation/tui"
 )
 
@@ -33,15 +37,38 @@ func main() {
 		}
 	}
 
+	// Run a CLI command instead of the TUI if one was given
+	if len(os.Args) > 1 {
+		if !cli.IsCommand(os.Args[1]) {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s (see passmanager help)\n", os.Args[1])
+			os.Exit(2)
+		}
+		if err := cli.New(cfg).Run(os.Args[1:]); err != nil {
+			// Commands run by passmanager report their own errors
+			var exitErr *cli.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Code)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Get vault path from config
 	vaultPath := cfg.Storage.VaultPath
 
 	// Initialize TUI application with config
 	app := tui.NewAppWithConfig(vaultPath, cfg)
 
+	// Library log output would draw over the interface
+	log.SetOutput(io.Discard)
+
 	// Run Bubble Tea program
 	p := tea.NewProgram(app, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	app.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -12,6 +12,9 @@ type Config struct {
 	Security            SecurityConfig            `yaml:"security"`
 	PasswordGenerator   PasswordGeneratorConfig   `yaml:"password_generator"`
 	PassphraseGenerator PassphraseGeneratorConfig `yaml:"passphrase_generator"`
+	UsernameGenerator   UsernameGeneratorConfig   `yaml:"username_generator"`
+	AutoType            AutoTypeConfig            `yaml:"auto_type"`
+	SSHAgent            SSHAgentConfig            `yaml:"ssh_agent"`
 	Storage             StorageConfig             `yaml:"storage"`
 	UI                  UIConfig                  `yaml:"ui"`
 }
@@ -24,6 +27,17 @@ type SecurityConfig struct {
 	ClearClipboardOnExit bool `yaml:"clear_clipboard_on_exit"`
 	MaxUnlockAttempts    int  `yaml:"max_unlock_attempts"`
 	UnlockCooldown       int  `yaml:"unlock_cooldown"` // seconds
+
+	// ClipboardBackend selects how secrets are copied: "auto", "system", "osc52",
+	// "tmux", "wayland", "x11" or "memory"; auto detects SSH, tmux and Wayland sessions
+	ClipboardBackend string `yaml:"clipboard_backend"`
+	// RestoreClipboard puts back what was on the clipboard before a secret was copied
+	RestoreClipboard bool `yaml:"restore_clipboard"`
+
+	// BreachCatalogPath points to a local copy of the HIBP breaches.json catalogue
+	BreachCatalogPath string `yaml:"breach_catalog_path"`
+	// TwoFactorDirectoryPath points to a local copy of the 2fa.directory JSON
+	TwoFactorDirectoryPath string `yaml:"two_factor_directory_path"`
 }
 
 // PasswordGeneratorConfig contains default password generator settings
@@ -34,6 +48,27 @@ type PasswordGeneratorConfig struct {
 	IncludeNumbers   bool `yaml:"include_numbers"`
 	IncludeSymbols   bool `yaml:"include_symbols"`
 	ExcludeAmbiguous bool `yaml:"exclude_ambiguous"`
+
+	// Minimum number of characters of each enabled set
+	MinUppercase int `yaml:"min_uppercase"`
+	MinLowercase int `yaml:"min_lowercase"`
+	MinNumbers   int `yaml:"min_numbers"`
+	MinSymbols   int `yaml:"min_symbols"`
+
+	// Symbols replaces the default symbol set when not empty
+	Symbols string `yaml:"symbols"`
+	// AllowedChars are extra characters passwords may contain
+	AllowedChars string `yaml:"allowed_chars"`
+	// ExcludedChars are never generated
+	ExcludedChars string `yaml:"excluded_chars"`
+
+	// HistorySize is the number of copied or used passwords kept in the vault (0 = disabled)
+	HistorySize int `yaml:"history_size"`
+
+	// Mode is the generator's initial mode: "random", "pattern", "pronounceable" or "passphrase"
+	Mode string `yaml:"mode"`
+	// Pattern is the default pattern, e.g. "Cvccvc-9999"
+	Pattern string `yaml:"pattern"`
 }
 
 // PassphraseGeneratorConfig contains default passphrase generator settings
@@ -42,6 +77,51 @@ type PassphraseGeneratorConfig struct {
 	Separator     string `yaml:"separator"`
 	Capitalize    bool   `yaml:"capitalize"`
 	IncludeNumber bool   `yaml:"include_number"`
+
+	// WordList selects the word list: "builtin", a file name in WordListDir or a path
+	WordList string `yaml:"word_list"`
+	// WordListDir holds diceware word lists, one file per list or language
+	WordListDir string `yaml:"word_list_dir"`
+	// InsertDigit and InsertSymbol add a random digit or symbol to each word
+	InsertDigit  bool `yaml:"insert_digit"`
+	InsertSymbol bool `yaml:"insert_symbol"`
+}
+
+// UsernameGeneratorConfig contains default username generator settings
+type UsernameGeneratorConfig struct {
+	Mode          string `yaml:"mode"` // "words", "plus", "catchall" or "uuid"
+	WordCount     int    `yaml:"word_count"`
+	Separator     string `yaml:"separator"`
+	IncludeNumber bool   `yaml:"include_number"`
+
+	// Email is the address tagged by plus-addressing, e.g. "me@example.com"
+	Email string `yaml:"email"`
+	// CatchAllDomain is a domain that delivers all its mail to you
+	CatchAllDomain string `yaml:"catch_all_domain"`
+	// Template builds the plus tag or catch-all local part from {site}, {word} and {random}
+	Template string `yaml:"template"`
+}
+
+// AutoTypeConfig contains auto-type settings
+type AutoTypeConfig struct {
+	// Backend selects the typing tool: "auto", "xdotool", "ydotool" or "wtype"
+	Backend string `yaml:"backend"`
+	// DefaultSequence is typed for entries without their own sequence
+	DefaultSequence string `yaml:"default_sequence"`
+	// StartDelay is the time to switch to the target window before typing starts
+	StartDelay int `yaml:"start_delay"` // seconds
+	// KeyDelay is the pause between keystrokes
+	KeyDelay int `yaml:"key_delay"` // milliseconds
+}
+
+// SSHAgentConfig contains settings of the SSH agent run by the interface
+type SSHAgentConfig struct {
+	// Enabled serves SSH key entries while the vault is unlocked
+	Enabled bool `yaml:"enabled"`
+	// SocketPath is where ssh clients connect, empty for the runtime directory
+	SocketPath string `yaml:"socket_path"`
+	// ConfirmTimeout is how long a signature waits for confirmation before it is refused
+	ConfirmTimeout int `yaml:"confirm_timeout"` // seconds
 }
 
 // StorageConfig contains storage-related settings
@@ -68,12 +148,15 @@ func DefaultConfig() *Config {
 
 	return &Config{
 		Security: SecurityConfig{
-			AutoLockTimeout:      5,
-			ClipboardTimeout:     30,
-			ClearClipboardOnLock: true,
-			ClearClipboardOnExit: true,
-			MaxUnlockAttempts:    5,
-			UnlockCooldown:       300,
+			AutoLockTimeout:        5,
+			ClipboardTimeout:       30,
+			ClearClipboardOnLock:   true,
+			ClearClipboardOnExit:   true,
+			ClipboardBackend:       "auto",
+			MaxUnlockAttempts:      5,
+			UnlockCooldown:         300,
+			BreachCatalogPath:      filepath.Join(configDir, "breaches.json"),
+			TwoFactorDirectoryPath: filepath.Join(configDir, "2fa-directory.json"),
 		},
 		PasswordGenerator: PasswordGeneratorConfig{
 			Length:           16,
@@ -82,12 +165,37 @@ func DefaultConfig() *Config {
 			IncludeNumbers:   true,
 			IncludeSymbols:   true,
 			ExcludeAmbiguous: true,
+			MinUppercase:     1,
+			MinLowercase:     1,
+			MinNumbers:       1,
+			MinSymbols:       1,
+			HistorySize:      20,
+			Mode:             "random",
+			Pattern:          "Cvccvc-9999",
 		},
 		PassphraseGenerator: PassphraseGeneratorConfig{
 			WordCount:     4,
 			Separator:     "-",
 			Capitalize:    true,
 			IncludeNumber: true,
+			WordList:      "builtin",
+			WordListDir:   filepath.Join(configDir, "wordlists"),
+		},
+		UsernameGenerator: UsernameGeneratorConfig{
+			Mode:          "words",
+			WordCount:     2,
+			Separator:     "_",
+			IncludeNumber: true,
+		},
+		AutoType: AutoTypeConfig{
+			Backend:         "auto",
+			DefaultSequence: "{USERNAME}{TAB}{PASSWORD}{ENTER}",
+			StartDelay:      3,
+			KeyDelay:        20,
+		},
+		SSHAgent: SSHAgentConfig{
+			Enabled:        false,
+			ConfirmTimeout: 30,
 		},
 		Storage: StorageConfig{
 			VaultPath:      filepath.Join(configDir, "vault.enc"),
@@ -116,7 +224,8 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	config := &Config{}
+	// Start from defaults so settings missing from older files keep sane values
+	config := DefaultConfig()
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
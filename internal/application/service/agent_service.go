@@ -0,0 +1,311 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/domain/repository"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+	"github.com/hambosto/passmanager/internal/infrastructure/crypto"
+	"github.com/hambosto/passmanager/pkg/dockercredential"
+)
+
+// AgentService keeps the vault key of the agent and answers its clients
+// The vault is read from disk for every request, so changes made elsewhere, e.g. in
+// the interface, are picked up; the key is forgotten after timeout without requests
+type AgentService struct {
+	repository repository.VaultRepository
+	totp       *TOTPService
+	timeout    time.Duration
+	now        func() time.Time
+
+	mu       sync.Mutex
+	key      []byte
+	lastUsed time.Time
+}
+
+// NewAgentService creates a locked agent service; a zero timeout disables auto-lock
+func NewAgentService(repo repository.VaultRepository, timeout time.Duration) *AgentService {
+	return &AgentService{
+		repository: repo,
+		totp:       NewTOTPService(),
+		timeout:    timeout,
+		now:        time.Now,
+	}
+}
+
+// Unlock derives the vault key from the master password and keeps it
+func (s *AgentService) Unlock(password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unlock(password)
+}
+
+// Lock zeroes the vault key
+func (s *AgentService) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lock()
+}
+
+// LockIfIdle locks the agent when it was not used for the timeout and reports
+// whether it did
+func (s *AgentService) LockIfIdle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lockIfIdle()
+}
+
+// Handle answers a client request
+func (s *AgentService) Handle(req agent.Request) agent.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockIfIdle()
+
+	switch req.Op {
+	case agent.OpStatus:
+		return agent.Response{Unlocked: s.key != nil, LockIn: s.lockIn()}
+	case agent.OpUnlock:
+		if err := s.unlock(req.Password); err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+		return agent.Response{Unlocked: true, LockIn: s.lockIn()}
+	case agent.OpLock, agent.OpStop:
+		s.lock()
+		return agent.Response{}
+	case agent.OpList, agent.OpGet, agent.OpAdd, agent.OpTOTP, agent.OpLoad, agent.OpSave,
+		agent.OpResolve, agent.OpGitCredential, agent.OpDockerCredential:
+	default:
+		return agent.Failure(agent.CodeInvalid, fmt.Errorf("unknown request %q", req.Op))
+	}
+
+	if s.key == nil {
+		return agent.Failure(agent.CodeLocked, agent.ErrLocked)
+	}
+	s.lastUsed = s.now()
+
+	vault, err := s.repository.Load(s.key)
+	if err != nil {
+		return agent.Failure(agent.CodeFailed, err)
+	}
+
+	switch req.Op {
+	case agent.OpList:
+		return agent.Response{Unlocked: true, Entries: summarizeEntries(vault)}
+	case agent.OpGet:
+		entry, err := FindEntryByName(vault, req.Name)
+		if err != nil {
+			return agent.Failure(agent.CodeInvalid, err)
+		}
+		return agent.Response{Unlocked: true, Entry: entry}
+	case agent.OpAdd:
+		if err := s.addEntry(vault, req.Entry); err != nil {
+			return agent.Failure(agent.CodeInvalid, err)
+		}
+		if err := s.save(vault); err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+		return agent.Response{Unlocked: true, Entry: req.Entry}
+	case agent.OpLoad:
+		return agent.Response{Unlocked: true, Vault: vault, Path: s.repository.GetPath()}
+	case agent.OpSave:
+		if req.Vault == nil {
+			return agent.Failure(agent.CodeInvalid, errors.New("no vault to save"))
+		}
+		if err := s.save(req.Vault); errors.Is(err, repository.ErrVaultChanged) {
+			return agent.Failure(agent.CodeChanged, err)
+		} else if err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+		return agent.Response{Unlocked: true, Revision: req.Vault.Revision}
+	case agent.OpResolve:
+		value, err := NewSecretResolver(vault).Resolve(req.Name)
+		if err != nil {
+			return agent.Failure(agent.CodeInvalid, err)
+		}
+		return agent.Response{Unlocked: true, Output: value}
+	case agent.OpGitCredential:
+		return s.handleGitCredential(vault, req.Action, req.Input)
+	case agent.OpDockerCredential:
+		return s.handleDockerCredential(vault, req.Action, req.Input)
+	default:
+		return s.handleTOTP(vault, req.Name)
+	}
+}
+
+// handleGitCredential answers a git credential helper, saving stored and erased logins
+func (s *AgentService) handleGitCredential(vault *entity.Vault, action, input string) agent.Response {
+	answer, err := ServeGitCredential(vault, action, input)
+	if err != nil {
+		return agent.Failure(agent.CodeInvalid, err)
+	}
+	if answer.Changed {
+		if err := s.save(vault); err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+	}
+	return agent.Response{Unlocked: true, Output: answer.Output, Messages: answer.Messages}
+}
+
+// handleDockerCredential answers the docker credential helper, saving changed logins
+func (s *AgentService) handleDockerCredential(vault *entity.Vault, action, input string) agent.Response {
+	store := NewDockerCredentialStore(vault)
+	var output strings.Builder
+	if err := dockercredential.Serve(store, action, strings.NewReader(input), &output); err != nil {
+		return agent.Failure(agent.CodeInvalid, err)
+	}
+	if store.Changed() {
+		if err := s.save(vault); err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+	}
+	return agent.Response{Unlocked: true, Output: output.String()}
+}
+
+// handleTOTP generates the one-time password of an entry, saving advanced HOTP counters
+func (s *AgentService) handleTOTP(vault *entity.Vault, name string) agent.Response {
+	entry, err := FindEntryByName(vault, name)
+	if err != nil {
+		return agent.Failure(agent.CodeInvalid, err)
+	}
+	if !entry.HasTOTP() {
+		return agent.Failure(agent.CodeInvalid, fmt.Errorf("entry %q has no one-time password", entry.Name))
+	}
+
+	if entry.TOTP.IsHOTP() {
+		code, err := s.totp.NextHOTPCode(entry.TOTP)
+		if err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+		entry.Update()
+		if err := s.save(vault); err != nil {
+			return agent.Failure(agent.CodeFailed, err)
+		}
+		return agent.Response{Unlocked: true, OTP: code}
+	}
+
+	code, expiresIn, err := s.totp.GenerateCode(entry.TOTP)
+	if err != nil {
+		return agent.Failure(agent.CodeFailed, err)
+	}
+	return agent.Response{Unlocked: true, OTP: code, ExpiresIn: int(expiresIn.Seconds())}
+}
+
+// addEntry checks an entry sent by a client and adds it to the vault
+func (s *AgentService) addEntry(vault *entity.Vault, entry *entity.Entry) error {
+	switch {
+	case entry == nil:
+		return errors.New("no entry to add")
+	case entry.ID == "":
+		return errors.New("entry has no id")
+	case strings.TrimSpace(entry.Name) == "":
+		return errors.New("entry has no name")
+	case vault.FindEntry(entry.ID) != nil:
+		return fmt.Errorf("an entry with id %s already exists", entry.ID)
+	case entry.FolderID != "" && vault.FindFolder(entry.FolderID) == nil:
+		return fmt.Errorf("no folder with id %s", entry.FolderID)
+	}
+	vault.AddEntry(entry)
+	return nil
+}
+
+// unlock derives the key and checks it opens the vault
+func (s *AgentService) unlock(password string) error {
+	params, err := s.repository.LoadParams()
+	if err != nil {
+		return err
+	}
+	key := crypto.DeriveKey(password, params)
+	vault, err := s.repository.Load(key)
+	if err != nil {
+		crypto.ZeroBytes(key)
+		return fmt.Errorf("failed to unlock vault (wrong password?): %w", err)
+	}
+
+	// Upgrade raw TOTP secrets from older vaults
+	if s.totp.MigrateVault(vault) > 0 {
+		if err := s.repository.Save(vault, key, params); err != nil {
+			crypto.ZeroBytes(key)
+			return err
+		}
+	}
+
+	s.lock()
+	s.key = key
+	s.lastUsed = s.now()
+	return nil
+}
+
+func (s *AgentService) lock() {
+	if s.key != nil {
+		crypto.ZeroBytes(s.key)
+		s.key = nil
+	}
+}
+
+func (s *AgentService) lockIfIdle() bool {
+	if s.key == nil || s.timeout <= 0 || s.now().Sub(s.lastUsed) < s.timeout {
+		return false
+	}
+	s.lock()
+	return true
+}
+
+// lockIn returns the seconds until auto-lock, rounded up
+func (s *AgentService) lockIn() int {
+	if s.key == nil || s.timeout <= 0 {
+		return 0
+	}
+	remaining := s.timeout - s.now().Sub(s.lastUsed)
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+func (s *AgentService) save(vault *entity.Vault) error {
+	params, err := s.repository.LoadParams()
+	if err != nil {
+		return err
+	}
+	return s.repository.Save(vault, s.key, params)
+}
+
+// summarizeEntries lists the entries of a vault by name without their secrets
+func summarizeEntries(vault *entity.Vault) []agent.EntrySummary {
+	summaries := make([]agent.EntrySummary, 0, len(vault.Entries))
+	for _, entry := range vault.Entries {
+		summaries = append(summaries, agent.EntrySummary{
+			ID:       entry.ID,
+			Name:     entry.Name,
+			Type:     entry.Type.String(),
+			Username: entry.Username,
+			URI:      entry.URI,
+		})
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].Name) < strings.ToLower(summaries[j].Name)
+	})
+	return summaries
+}
+
+// FindEntryByName returns the single entry whose name matches case-insensitively
+func FindEntryByName(vault *entity.Vault, name string) (*entity.Entry, error) {
+	var matches []*entity.Entry
+	for _, entry := range vault.Entries {
+		if strings.EqualFold(entry.Name, name) {
+			matches = append(matches, entry)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no entry named %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d entries are named %q", len(matches), name)
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/domain/repository"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+	"github.com/hambosto/passmanager/internal/infrastructure/storage"
+)
+
+const agentTestPassword = "correct horse battery staple"
+
+// newTestAgent creates a vault with a login and an HOTP entry and a locked agent service for it
+func newTestAgent(t *testing.T, timeout time.Duration) (*AgentService, *storage.FileRepository) {
+	t.Helper()
+	repo := storage.NewFileRepository(filepath.Join(t.TempDir(), "vault.enc"))
+	vaultService := NewVaultService(repo)
+	vault, err := vaultService.CreateVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("CreateVault() error = %v", err)
+	}
+
+	login := entity.NewEntry(entity.EntryTypeLogin, "GitHub")
+	login.Username = "alice"
+	login.Password = "hunter2"
+	login.URI = "https://github.com"
+	vault.AddEntry(login)
+
+	hotp := entity.NewEntry(entity.EntryTypeLogin, "Bank")
+	hotp.TOTP = &entity.TOTPConfig{Type: "hotp", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6}
+	vault.AddEntry(hotp)
+
+	if err := vaultService.SaveVault(vault); err != nil {
+		t.Fatalf("SaveVault() error = %v", err)
+	}
+	return NewAgentService(repo, timeout), repo
+}
+
+func TestAgentServiceLocked(t *testing.T) {
+	agentService, _ := newTestAgent(t, 0)
+	client := agent.NewLocalClient(agentService)
+
+	if unlocked, _, err := client.Status(); err != nil || unlocked {
+		t.Fatalf("Status() = %v, %v, want locked", unlocked, err)
+	}
+	if _, err := client.List(); !errors.Is(err, agent.ErrLocked) {
+		t.Errorf("List() error = %v, want ErrLocked", err)
+	}
+	if _, err := client.Resolve("pm://GitHub/password"); !errors.Is(err, agent.ErrLocked) {
+		t.Errorf("Resolve() error = %v, want ErrLocked", err)
+	}
+	if err := client.Unlock("wrong"); err == nil {
+		t.Error("Unlock(wrong password) succeeded")
+	}
+}
+
+func TestAgentServiceRequests(t *testing.T) {
+	agentService, repo := newTestAgent(t, 0)
+	client := agent.NewLocalClient(agentService)
+	if err := client.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	entries, err := client.List()
+	if err != nil || len(entries) != 2 || entries[0].Name != "Bank" || entries[1].Username != "alice" {
+		t.Fatalf("List() = %+v, %v", entries, err)
+	}
+
+	entry, err := client.Get("github")
+	if err != nil || entry.Password != "hunter2" {
+		t.Fatalf("Get() = %+v, %v", entry, err)
+	}
+	if _, err := client.Get("Nope"); err == nil {
+		t.Error("Get(missing) succeeded")
+	}
+
+	added := entity.NewEntry(entity.EntryTypeLogin, "Mail")
+	added.Password = "s3cret"
+	if err := client.Add(added); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := client.Add(added); err == nil {
+		t.Error("adding the same entry twice succeeded")
+	}
+	if err := client.Add(&entity.Entry{Name: "No ID"}); err == nil {
+		t.Error("adding an entry without id succeeded")
+	}
+
+	// HOTP codes advance the counter stored in the vault
+	first, _, err := client.TOTP("Bank")
+	if err != nil {
+		t.Fatalf("TOTP() error = %v", err)
+	}
+	second, _, _ := client.TOTP("Bank")
+	if first == second {
+		t.Errorf("HOTP codes %s and %s repeat", first, second)
+	}
+	if _, _, err := client.TOTP("GitHub"); err == nil {
+		t.Error("TOTP() of an entry without one-time password succeeded")
+	}
+
+	vault, err := NewVaultService(repo).UnlockVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+	if len(vault.Entries) != 3 {
+		t.Errorf("vault has %d entries, want the added one saved", len(vault.Entries))
+	}
+	if bank, _ := FindEntryByName(vault, "Bank"); bank.TOTP.Counter != 2 {
+		t.Errorf("HOTP counter = %d, want 2", bank.TOTP.Counter)
+	}
+
+	// The key never leaves the agent
+	if resp := agentService.Handle(agent.Request{Op: "key"}); resp.Code != agent.CodeInvalid {
+		t.Errorf("key request = %+v, want it refused as unknown", resp)
+	}
+
+	if err := client.Lock(); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if _, err := client.Get("GitHub"); !errors.Is(err, agent.ErrLocked) {
+		t.Errorf("Get() after Lock() error = %v, want ErrLocked", err)
+	}
+}
+
+func TestAgentServiceHelpers(t *testing.T) {
+	agentService, repo := newTestAgent(t, 0)
+	client := agent.NewLocalClient(agentService)
+	if err := client.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if value, err := client.Resolve("pm://GitHub/username"); err != nil || value != "alice" {
+		t.Errorf("Resolve() = %q, %v, want alice", value, err)
+	}
+	if _, err := client.Resolve("pm://Nope/password"); err == nil {
+		t.Error("Resolve(missing entry) succeeded")
+	}
+
+	output, _, err := client.GitCredential("get", "protocol=https\nhost=github.com\n")
+	if err != nil || output != "username=alice\npassword=hunter2\n\n" {
+		t.Errorf("GitCredential(get) = %q, %v", output, err)
+	}
+	_, messages, err := client.GitCredential("store", "protocol=https\nhost=gitlab.com\nusername=bob\npassword=token\n")
+	if err != nil || len(messages) != 1 {
+		t.Errorf("GitCredential(store) = %q, %v", messages, err)
+	}
+
+	if _, err := client.DockerCredential("store", `{"ServerURL":"ghcr.io","Username":"carol","Secret":"pat"}`); err != nil {
+		t.Fatalf("DockerCredential(store) error = %v", err)
+	}
+	output, err = client.DockerCredential("get", "ghcr.io")
+	if err != nil || !strings.Contains(output, `"Secret":"pat"`) {
+		t.Errorf("DockerCredential(get) = %q, %v", output, err)
+	}
+
+	// Stored logins are saved by the agent
+	vault, err := NewVaultService(repo).UnlockVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+	if len(vault.Entries) != 4 {
+		t.Errorf("vault has %d entries, want the git and docker logins saved", len(vault.Entries))
+	}
+}
+
+func TestAgentServiceKeepsConcurrentChanges(t *testing.T) {
+	agentService, repo := newTestAgent(t, 0)
+	client := agent.NewLocalClient(agentService)
+	if err := client.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	// The interface opens the vault, then the agent uses up an HOTP code and adds a login
+	vaultService := NewVaultService(repo)
+	stale, err := vaultService.UnlockVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+	if _, _, err := client.TOTP("Bank"); err != nil {
+		t.Fatalf("TOTP() error = %v", err)
+	}
+	if err := client.Add(entity.NewEntry(entity.EntryTypeLogin, "Mail")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Saving the copy opened before must not roll the agent's changes back
+	stale.AddEntry(entity.NewEntry(entity.EntryTypeSecureNote, "Note"))
+	if err := vaultService.SaveVault(stale); !errors.Is(err, repository.ErrVaultChanged) {
+		t.Fatalf("SaveVault() of a stale vault error = %v, want ErrVaultChanged", err)
+	}
+	vault, err := vaultService.UnlockVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+	if bank, _ := FindEntryByName(vault, "Bank"); bank.TOTP.Counter != 1 {
+		t.Errorf("HOTP counter = %d, want the agent's 1", bank.TOTP.Counter)
+	}
+	if _, err := FindEntryByName(vault, "Mail"); err != nil {
+		t.Errorf("login added by the agent is gone: %v", err)
+	}
+
+	// A reloaded vault saves on top of them
+	vault.AddEntry(entity.NewEntry(entity.EntryTypeSecureNote, "Note"))
+	if err := vaultService.SaveVault(vault); err != nil {
+		t.Fatalf("SaveVault() of the reloaded vault error = %v", err)
+	}
+	if entries, err := client.List(); err != nil || len(entries) != 4 {
+		t.Errorf("List() = %d entries, %v, want 4", len(entries), err)
+	}
+}
+
+func TestAgentServiceLoadSave(t *testing.T) {
+	agentService, repo := newTestAgent(t, 0)
+	client := agent.NewLocalClient(agentService)
+	if _, _, err := client.Load(); !errors.Is(err, agent.ErrLocked) {
+		t.Fatalf("Load() of a locked agent error = %v, want ErrLocked", err)
+	}
+	if err := client.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	// The interface works on the vault the agent loads and saves with its key
+	vault, path, err := client.Load()
+	if err != nil || len(vault.Entries) != 2 || path != repo.GetPath() {
+		t.Fatalf("Load() = %d entries at %s, %v", len(vault.Entries), path, err)
+	}
+	vault.AddEntry(entity.NewEntry(entity.EntryTypeSecureNote, "Note"))
+	if err := client.Save(vault); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if entries, _ := client.List(); len(entries) != 3 {
+		t.Errorf("List() = %d entries after Save(), want 3", len(entries))
+	}
+
+	// An HOTP code used up by the agent is not rolled back by the interface's next save
+	if _, _, err := client.TOTP("Bank"); err != nil {
+		t.Fatalf("TOTP() error = %v", err)
+	}
+	vault.AddEntry(entity.NewEntry(entity.EntryTypeSecureNote, "Other"))
+	if err := client.Save(vault); !errors.Is(err, repository.ErrVaultChanged) {
+		t.Fatalf("Save() of a stale vault error = %v, want ErrVaultChanged", err)
+	}
+	reloaded, _, err := client.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if bank, _ := FindEntryByName(reloaded, "Bank"); bank.TOTP.Counter != 1 {
+		t.Errorf("HOTP counter = %d, want the agent's 1", bank.TOTP.Counter)
+	}
+	if err := client.Save(&entity.Vault{}); err == nil {
+		t.Error("Save() of a vault with another revision succeeded")
+	}
+}
+
+func TestAgentServiceAutoLock(t *testing.T) {
+	agentService, _ := newTestAgent(t, 5*time.Minute)
+	now := time.Now()
+	agentService.now = func() time.Time { return now }
+	client := agent.NewLocalClient(agentService)
+	if err := client.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	now = now.Add(4 * time.Minute)
+	if _, err := client.List(); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	// Requests restart the timer, status checks do not
+	now = now.Add(4 * time.Minute)
+	if unlocked, lockIn, _ := client.Status(); !unlocked || lockIn != time.Minute {
+		t.Errorf("Status() = %v, %v, want unlocked for another minute", unlocked, lockIn)
+	}
+	if agentService.LockIfIdle() {
+		t.Error("LockIfIdle() locked before the timeout")
+	}
+
+	now = now.Add(time.Minute)
+	if !agentService.LockIfIdle() {
+		t.Error("LockIfIdle() did not lock after the timeout")
+	}
+	if _, err := client.List(); !errors.Is(err, agent.ErrLocked) {
+		t.Errorf("List() after timeout error = %v, want ErrLocked", err)
+	}
+}
+
+func TestAgentServiceOverSocket(t *testing.T) {
+	agentService, _ := newTestAgent(t, 0)
+	if err := agentService.Unlock(agentTestPassword); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	server, err := agent.Listen(path, agentService)
+	if err != nil {
+		t.Skipf("Listen() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve() }()
+
+	client := agent.NewClient(path)
+	entry, err := client.Get("GitHub")
+	if err != nil || entry.Password != "hunter2" {
+		t.Fatalf("Get() over the socket = %+v, %v", entry, err)
+	}
+	vault, _, err := client.Load()
+	if err != nil {
+		t.Fatalf("Load() over the socket error = %v", err)
+	}
+	if err := client.Save(vault); err != nil {
+		t.Errorf("Save() over the socket error = %v", err)
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if unlocked, _, _ := agent.NewLocalClient(agentService).Status(); unlocked {
+		t.Error("agent still unlocked after Stop()")
+	}
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/autotype"
+)
+
+// AutoTypeAction is what a step of an auto-type sequence does
+type AutoTypeAction int
+
+const (
+	// AutoTypeText types literal text
+	AutoTypeText AutoTypeAction = iota
+	// AutoTypeField types a field of the entry
+	AutoTypeField
+	// AutoTypeKey presses a key Count times
+	AutoTypeKey
+	// AutoTypeDelay pauses before the next step
+	AutoTypeDelay
+	// AutoTypeKeyDelay changes the pause between keystrokes for the following steps
+	AutoTypeKeyDelay
+)
+
+// Entry fields available as placeholders; custom fields use {S:Name}
+const (
+	FieldUsername = "USERNAME"
+	FieldPassword = "PASSWORD"
+	FieldTOTP     = "TOTP"
+	FieldURL      = "URL"
+	FieldTitle    = "TITLE"
+	FieldNotes    = "NOTES"
+)
+
+// autoTypeKeys maps key placeholders to keys
+var autoTypeKeys = map[string]autotype.Key{
+	"TAB":       autotype.KeyTab,
+	"ENTER":     autotype.KeyEnter,
+	"SPACE":     autotype.KeySpace,
+	"BACKSPACE": autotype.KeyBackspace,
+	"BS":        autotype.KeyBackspace,
+	"DELETE":    autotype.KeyDelete,
+	"DEL":       autotype.KeyDelete,
+	"ESC":       autotype.KeyEscape,
+	"UP":        autotype.KeyUp,
+	"DOWN":      autotype.KeyDown,
+	"LEFT":      autotype.KeyLeft,
+	"RIGHT":     autotype.KeyRight,
+	"HOME":      autotype.KeyHome,
+	"END":       autotype.KeyEnd,
+}
+
+// DefaultKeyDelay is the pause between keystrokes unless a sequence sets one
+const DefaultKeyDelay = 20 * time.Millisecond
+
+// maxAutoTypeDelay bounds {DELAY} so a typo cannot stall typing for hours
+const maxAutoTypeDelay = time.Minute
+
+// AutoTypeStep is one step of a parsed auto-type sequence
+type AutoTypeStep struct {
+	Action AutoTypeAction
+	// Text is the literal text, or the field name: USERNAME, PASSWORD, ... or a custom field
+	Text   string
+	Custom bool
+	Key    autotype.Key
+	Count  int
+	Delay  time.Duration
+}
+
+// ParseAutoTypeSequence parses a sequence in KeePass syntax: text is typed as is,
+// {USERNAME}, {PASSWORD}, {TOTP}, {URL}, {TITLE}, {NOTES} and {S:Custom Field} type
+// fields, {TAB}, {ENTER} and other keys are pressed, optionally repeated as {TAB 2},
+// {DELAY 500} pauses and {DELAY=50} sets the pause between keystrokes in milliseconds,
+// and {{} and {}} type literal braces
+func ParseAutoTypeSequence(sequence string) ([]AutoTypeStep, error) {
+	var steps []AutoTypeStep
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			steps = append(steps, AutoTypeStep{Action: AutoTypeText, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(sequence); {
+		switch sequence[i] {
+		case '{':
+			// {{} and {}} are the escaped braces
+			if strings.HasPrefix(sequence[i:], "{{}") || strings.HasPrefix(sequence[i:], "{}}") {
+				text.WriteByte(sequence[i+1])
+				i += 3
+				continue
+			}
+			end := strings.IndexByte(sequence[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed placeholder at position %d", i+1)
+			}
+			step, err := parsePlaceholder(sequence[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			steps = append(steps, step)
+			i += end + 1
+		case '}':
+			return nil, fmt.Errorf("unexpected } at position %d, type it as {}}", i+1)
+		default:
+			text.WriteByte(sequence[i])
+			i++
+		}
+	}
+	flush()
+	return steps, nil
+}
+
+// parsePlaceholder parses the inside of a {...} placeholder
+func parsePlaceholder(placeholder string) (AutoTypeStep, error) {
+	if name, ok := strings.CutPrefix(placeholder, "S:"); ok {
+		if name == "" {
+			return AutoTypeStep{}, fmt.Errorf("{S:} needs a custom field name")
+		}
+		return AutoTypeStep{Action: AutoTypeField, Text: name, Custom: true}, nil
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(placeholder))
+	if ms, ok := strings.CutPrefix(upper, "DELAY="); ok {
+		delay, err := parseDelay(ms)
+		if err != nil {
+			return AutoTypeStep{}, err
+		}
+		return AutoTypeStep{Action: AutoTypeKeyDelay, Delay: delay}, nil
+	}
+
+	name, arg, hasArg := strings.Cut(upper, " ")
+	if name == "DELAY" {
+		if !hasArg {
+			return AutoTypeStep{}, fmt.Errorf("{DELAY} needs milliseconds, e.g. {DELAY 500}")
+		}
+		delay, err := parseDelay(arg)
+		if err != nil {
+			return AutoTypeStep{}, err
+		}
+		return AutoTypeStep{Action: AutoTypeDelay, Delay: delay}, nil
+	}
+
+	if key, ok := autoTypeKeys[name]; ok {
+		count := 1
+		if hasArg {
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil || n < 1 || n > 100 {
+				return AutoTypeStep{}, fmt.Errorf("invalid repeat count in {%s}", placeholder)
+			}
+			count = n
+		}
+		return AutoTypeStep{Action: AutoTypeKey, Key: key, Count: count}, nil
+	}
+
+	switch name {
+	case FieldUsername, FieldPassword, FieldTOTP, FieldURL, FieldTitle, FieldNotes:
+		if hasArg {
+			return AutoTypeStep{}, fmt.Errorf("{%s} takes no argument", name)
+		}
+		return AutoTypeStep{Action: AutoTypeField, Text: name}, nil
+	}
+	return AutoTypeStep{}, fmt.Errorf("unknown placeholder {%s}", placeholder)
+}
+
+// parseDelay parses milliseconds of a delay placeholder
+func parseDelay(ms string) (time.Duration, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(ms))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid delay %q, use milliseconds", ms)
+	}
+	delay := time.Duration(n) * time.Millisecond
+	if delay > maxAutoTypeDelay {
+		return 0, fmt.Errorf("delay %q is longer than a minute", ms)
+	}
+	return delay, nil
+}
+
+// AutoTypeService types entries into the focused window
+type AutoTypeService struct {
+	typer    autotype.Typer
+	totp     *TOTPService
+	keyDelay time.Duration
+	sleep    func(time.Duration)
+}
+
+// NewAutoTypeService creates an auto-type service sending keystrokes with typer
+func NewAutoTypeService(typer autotype.Typer) *AutoTypeService {
+	return &AutoTypeService{
+		typer:    typer,
+		totp:     NewTOTPService(),
+		keyDelay: DefaultKeyDelay,
+		sleep:    time.Sleep,
+	}
+}
+
+// SetKeyDelay sets the pause between keystrokes for sequences that do not set one
+func (s *AutoTypeService) SetKeyDelay(delay time.Duration) {
+	s.keyDelay = delay
+}
+
+// AutoTypeSequence returns the entry's sequence, or defaultSequence when it has none
+// An empty defaultSequence means entity.DefaultAutoTypeSequence
+func AutoTypeSequence(entry *entity.Entry, defaultSequence string) string {
+	if sequence := strings.TrimSpace(entry.AutoTypeSequence); sequence != "" {
+		return sequence
+	}
+	if sequence := strings.TrimSpace(defaultSequence); sequence != "" {
+		return sequence
+	}
+	return entity.DefaultAutoTypeSequence
+}
+
+// AutoTypeJob is a parsed sequence with its fields resolved
+// It holds no reference to the entry, so it can be typed on another goroutine
+type AutoTypeJob struct {
+	steps  []AutoTypeStep
+	values map[string]string
+
+	// CounterAdvanced reports that {TOTP} used up an HOTP code, so the vault must be saved
+	CounterAdvanced bool
+}
+
+// Prepare parses sequence and looks up the entry fields it types
+// A sequence referring to a missing field fails before anything changes; otherwise
+// the entry is marked accessed and {TOTP} advances HOTP counters once per sequence
+func (s *AutoTypeService) Prepare(entry *entity.Entry, sequence string) (*AutoTypeJob, error) {
+	steps, err := ParseAutoTypeSequence(sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	var counter uint64
+	if entry.HasTOTP() {
+		counter = entry.TOTP.Counter
+	}
+
+	// Resolve {TOTP} last so a missing field cannot waste an HOTP code
+	job := &AutoTypeJob{steps: steps, values: make(map[string]string)}
+	for _, totp := range []bool{false, true} {
+		for _, step := range steps {
+			if step.Action != AutoTypeField || (!step.Custom && step.Text == FieldTOTP) != totp {
+				continue
+			}
+			key := fieldKey(step)
+			if _, ok := job.values[key]; ok {
+				continue
+			}
+			value, err := s.fieldValue(entry, step)
+			if err != nil {
+				return nil, err
+			}
+			job.values[key] = value
+		}
+	}
+
+	job.CounterAdvanced = entry.HasTOTP() && entry.TOTP.Counter != counter
+	entry.UpdateAccessTime()
+	return job, nil
+}
+
+// Run sends the keystrokes of a prepared job
+func (s *AutoTypeService) Run(job *AutoTypeJob) error {
+	var err error
+	keyDelay := s.keyDelay
+	for _, step := range job.steps {
+		switch step.Action {
+		case AutoTypeText:
+			err = s.typer.Type(step.Text, keyDelay)
+		case AutoTypeField:
+			err = s.typer.Type(job.values[fieldKey(step)], keyDelay)
+		case AutoTypeKey:
+			for range step.Count {
+				if err = s.typer.Press(step.Key); err != nil {
+					break
+				}
+			}
+		case AutoTypeDelay:
+			s.sleep(step.Delay)
+		case AutoTypeKeyDelay:
+			keyDelay = step.Delay
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type prepares and types the entry following sequence on the calling goroutine
+// {TOTP} advances HOTP counters, so callers save the vault afterwards
+func (s *AutoTypeService) Type(entry *entity.Entry, sequence string) error {
+	job, err := s.Prepare(entry, sequence)
+	if err != nil {
+		return err
+	}
+	return s.Run(job)
+}
+
+// fieldKey identifies a field step, keeping custom fields apart from built-in ones
+func fieldKey(step AutoTypeStep) string {
+	if step.Custom {
+		return "S:" + step.Text
+	}
+	return step.Text
+}
+
+// fieldValue returns the value typed for a field step
+func (s *AutoTypeService) fieldValue(entry *entity.Entry, step AutoTypeStep) (string, error) {
+	if step.Custom {
+		// Custom field names are matched case-insensitively like KeePass does
+		for name, value := range entry.CustomFields {
+			if strings.EqualFold(name, step.Text) {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("entry %q has no custom field %q", entry.Name, step.Text)
+	}
+
+	switch step.Text {
+	case FieldUsername:
+		return entry.Username, nil
+	case FieldPassword:
+		return entry.Password, nil
+	case FieldURL:
+		return entry.URI, nil
+	case FieldTitle:
+		return entry.Name, nil
+	case FieldNotes:
+		return entry.Notes, nil
+	case FieldTOTP:
+		if !entry.HasTOTP() {
+			return "", fmt.Errorf("entry %q has no one-time password", entry.Name)
+		}
+		if entry.TOTP.IsHOTP() {
+			code, err := s.totp.NextHOTPCode(entry.TOTP)
+			if err == nil {
+				entry.Update()
+			}
+			return code, err
+		}
+		code, _, err := s.totp.GenerateCode(entry.TOTP)
+		return code, err
+	}
+	return "", fmt.Errorf("unknown field %s", step.Text)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/autotype"
+)
+
+// newRecordingAutoType returns a service typing into a recorder and the pauses it made
+func newRecordingAutoType() (*AutoTypeService, *autotype.Recorder, *[]time.Duration) {
+	recorder := &autotype.Recorder{}
+	var pauses []time.Duration
+	service := NewAutoTypeService(recorder)
+	service.sleep = func(d time.Duration) { pauses = append(pauses, d) }
+	return service, recorder, &pauses
+}
+
+func newAutoTypeEntry() *entity.Entry {
+	entry := entity.NewEntry(entity.EntryTypeLogin, "Example")
+	entry.Username = "alice"
+	entry.Password = "p{a}ss"
+	entry.URI = "https://example.com"
+	entry.CustomFields["PIN"] = "1234"
+	return entry
+}
+
+func TestAutoTypeDefaultSequence(t *testing.T) {
+	service, recorder, _ := newRecordingAutoType()
+	entry := newAutoTypeEntry()
+
+	if err := service.Type(entry, AutoTypeSequence(entry, "")); err != nil {
+		t.Fatalf("Type() error = %v", err)
+	}
+	if got, want := recorder.String(), "alice{Tab}p{a}ss{Return}"; got != want {
+		t.Errorf("typed %q, want %q", got, want)
+	}
+	for _, k := range recorder.Keystrokes() {
+		if k.Key == "" && k.Delay != DefaultKeyDelay {
+			t.Errorf("typed %q with delay %v, want %v", k.Text, k.Delay, DefaultKeyDelay)
+		}
+	}
+}
+
+func TestAutoTypeSequence(t *testing.T) {
+	service, recorder, pauses := newRecordingAutoType()
+	entry := newAutoTypeEntry()
+	entry.AutoTypeSequence = "{TITLE}:{S:pin}{TAB 2}{DELAY=5}{{}{URL}{}}{DELAY 250}{ENTER}"
+
+	if err := service.Type(entry, AutoTypeSequence(entry, "{PASSWORD}")); err != nil {
+		t.Fatalf("Type() error = %v", err)
+	}
+	if got, want := recorder.String(), "Example:1234{Tab}{Tab}{https://example.com}{Return}"; got != want {
+		t.Errorf("typed %q, want %q", got, want)
+	}
+	if len(*pauses) != 1 || (*pauses)[0] != 250*time.Millisecond {
+		t.Errorf("pauses = %v, want [250ms]", *pauses)
+	}
+
+	// {DELAY=5} applies to everything typed after it
+	keystrokes := recorder.Keystrokes()
+	if first, last := keystrokes[0], keystrokes[len(keystrokes)-2]; first.Delay != DefaultKeyDelay || last.Delay != 5*time.Millisecond {
+		t.Errorf("key delays = %v then %v, want %v then 5ms", first.Delay, last.Delay, DefaultKeyDelay)
+	}
+}
+
+func TestAutoTypeTOTP(t *testing.T) {
+	service, recorder, _ := newRecordingAutoType()
+	entry := newAutoTypeEntry()
+	entry.TOTP = &entity.TOTPConfig{Type: "hotp", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6}
+
+	if err := service.Type(entry, "{TOTP}{ENTER}{TOTP}"); err != nil {
+		t.Fatalf("Type() error = %v", err)
+	}
+	typed := recorder.Keystrokes()
+	if len(typed) != 3 || len(typed[0].Text) != 6 || typed[0].Text != typed[2].Text {
+		t.Errorf("typed %+v, want the same 6-digit code twice", typed)
+	}
+	if entry.TOTP.Counter != 1 {
+		t.Errorf("HOTP counter = %d, want it advanced once", entry.TOTP.Counter)
+	}
+
+	// Preparing advances the counter up front, the job only holds the typed text
+	job, err := service.Prepare(entry, "{TOTP}")
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if !job.CounterAdvanced || entry.TOTP.Counter != 2 {
+		t.Errorf("Prepare() CounterAdvanced = %v with counter %d, want true and 2", job.CounterAdvanced, entry.TOTP.Counter)
+	}
+	entry.TOTP = nil
+	if err := service.Run(job); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if typed := recorder.Keystrokes(); len(typed[len(typed)-1].Text) != 6 {
+		t.Errorf("Run() typed %+v after the entry lost its TOTP, want the prepared code", typed[len(typed)-1])
+	}
+
+	// Time-based codes do not change the vault
+	entry.TOTP = &entity.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Period: 30}
+	if job, err := service.Prepare(entry, "{TOTP}"); err != nil || job.CounterAdvanced {
+		t.Errorf("Prepare(TOTP) = %+v, %v, want no counter change", job, err)
+	}
+}
+
+func TestAutoTypeMissingField(t *testing.T) {
+	service, recorder, _ := newRecordingAutoType()
+	entry := newAutoTypeEntry()
+
+	for _, sequence := range []string{"{USERNAME}{TAB}{S:Missing}", "{USERNAME}{TOTP}"} {
+		if err := service.Type(entry, sequence); err == nil {
+			t.Errorf("Type(%q) succeeded, want error", sequence)
+		}
+	}
+	if got := recorder.String(); got != "" {
+		t.Errorf("typed %q before failing, want nothing", got)
+	}
+
+	// A missing field fails before an HOTP code is used up
+	entry.TOTP = &entity.TOTPConfig{Type: "hotp", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6}
+	if _, err := service.Prepare(entry, "{TOTP}{S:Missing}"); err == nil {
+		t.Error("Prepare() succeeded, want error")
+	}
+	if entry.TOTP.Counter != 0 {
+		t.Errorf("HOTP counter = %d after a failed Prepare(), want 0", entry.TOTP.Counter)
+	}
+}
+
+func TestParseAutoTypeSequenceErrors(t *testing.T) {
+	invalid := []string{
+		"{USERNAME",
+		"user}",
+		"{FOO}",
+		"{S:}",
+		"{TAB x}",
+		"{TAB 0}",
+		"{DELAY}",
+		"{DELAY -1}",
+		"{DELAY 3600000}",
+		"{PASSWORD 2}",
+	}
+	for _, sequence := range invalid {
+		if _, err := ParseAutoTypeSequence(sequence); err == nil {
+			t.Errorf("ParseAutoTypeSequence(%q) succeeded, want error", sequence)
+		}
+	}
+
+	steps, err := ParseAutoTypeSequence("{username}{enter}")
+	if err != nil || len(steps) != 2 || steps[0].Text != FieldUsername || steps[1].Key != autotype.KeyEnter {
+		t.Errorf("placeholders are not case-insensitive: %+v, %v", steps, err)
+	}
+	if !strings.Contains(entity.DefaultAutoTypeSequence, "{PASSWORD}") {
+		t.Errorf("DefaultAutoTypeSequence = %q", entity.DefaultAutoTypeSequence)
+	}
+}
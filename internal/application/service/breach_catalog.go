@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Breach describes a site breach in the Have I Been Pwned breaches.json format
+type Breach struct {
+	Name        string   `json:"Name"`
+	Title       string   `json:"Title"`
+	Domain      string   `json:"Domain"`
+	BreachDate  string   `json:"BreachDate"`
+	AddedDate   string   `json:"AddedDate"`
+	PwnCount    int      `json:"PwnCount"`
+	DataClasses []string `json:"DataClasses"`
+	IsVerified  bool     `json:"IsVerified"`
+}
+
+// Date returns the date the breach occurred
+func (b Breach) Date() (time.Time, error) {
+	return time.Parse("2006-01-02", b.BreachDate)
+}
+
+// BreachCatalog is a local index of breached domains
+type BreachCatalog struct {
+	byDomain map[string][]Breach
+}
+
+// NewBreachCatalog builds a catalog from a list of breaches
+// Breaches without a domain or a parseable breach date are skipped
+func NewBreachCatalog(breaches []Breach) *BreachCatalog {
+	catalog := &BreachCatalog{byDomain: make(map[string][]Breach)}
+
+	for _, breach := range breaches {
+		domain := strings.ToLower(strings.TrimSpace(breach.Domain))
+		if domain == "" {
+			continue
+		}
+		if _, err := breach.Date(); err != nil {
+			continue
+		}
+		catalog.byDomain[domain] = append(catalog.byDomain[domain], breach)
+	}
+
+	return catalog
+}
+
+// LoadBreachCatalog loads a breaches.json file from disk
+// A missing file yields an empty catalog
+func LoadBreachCatalog(path string) (*BreachCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewBreachCatalog(nil), nil
+		}
+		return nil, fmt.Errorf("failed to read breach catalog: %w", err)
+	}
+
+	var breaches []Breach
+	if err := json.Unmarshal(data, &breaches); err != nil {
+		return nil, fmt.Errorf("failed to parse breach catalog: %w", err)
+	}
+
+	return NewBreachCatalog(breaches), nil
+}
+
+// Lookup returns the breaches recorded for a host or any of its parent domains
+func (c *BreachCatalog) Lookup(host string) []Breach {
+	if c == nil || host == "" {
+		return nil
+	}
+
+	var found []Breach
+	for _, domain := range parentDomains(host) {
+		found = append(found, c.byDomain[domain]...)
+	}
+
+	return found
+}
+
+// Len returns the number of breached domains in the catalog
+func (c *BreachCatalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.byDomain)
+}
+
+// parentDomains returns host followed by each of its parent domains
+// The bare TLD is never included, but public suffixes such as "co.uk" are, so a catalog
+// must only list registrable domains
+func parentDomains(host string) []string {
+	labels := strings.Split(strings.ToLower(host), ".")
+
+	var domains []string
+	for i := 0; i < len(labels)-1; i++ {
+		domains = append(domains, strings.Join(labels[i:], "."))
+	}
+	return domains
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+)
+
+func TestBreachCatalogLookup(t *testing.T) {
+	catalog := NewBreachCatalog([]Breach{
+		{Name: "Example", Domain: "Example.com", BreachDate: "2020-01-02"},
+		{Name: "Shop", Domain: "shop.example.com", BreachDate: "2021-03-04"},
+		{Name: "NoDomain", BreachDate: "2021-03-04"},
+		{Name: "BadDate", Domain: "bad.test", BreachDate: "soon"},
+	})
+
+	if catalog.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", catalog.Len())
+	}
+
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"example.com", []string{"Example"}},
+		{"www.example.com", []string{"Example"}},
+		{"SHOP.example.com", []string{"Shop", "Example"}},
+		{"checkout.shop.example.com", []string{"Shop", "Example"}},
+		{"notexample.com", nil},
+		{"com", nil},
+		{"bad.test", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		found := catalog.Lookup(tt.host)
+		var names []string
+		for _, breach := range found {
+			names = append(names, breach.Name)
+		}
+		if len(names) != len(tt.want) {
+			t.Errorf("Lookup(%q) = %v, want %v", tt.host, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.host, names, tt.want)
+				break
+			}
+		}
+	}
+
+	var empty *BreachCatalog
+	if found := empty.Lookup("example.com"); found != nil {
+		t.Errorf("nil catalog Lookup() = %v, want none", found)
+	}
+}
+
+func TestFindBreachedEntries(t *testing.T) {
+	breachDate := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		passwordDate time.Time
+		createdAt    time.Time
+		password     string
+		want         bool
+	}{
+		{"set before the breach", breachDate.AddDate(0, -1, 0), time.Time{}, "secret", true},
+		{"changed after the breach", breachDate.AddDate(0, 1, 0), time.Time{}, "secret", false},
+		{"same day as the breach", breachDate, time.Time{}, "secret", false},
+		{"untracked, created before", time.Time{}, breachDate.AddDate(-1, 0, 0), "secret", true},
+		{"untracked, created after", time.Time{}, breachDate.AddDate(1, 0, 0), "secret", false},
+		{"no password", breachDate.AddDate(0, -1, 0), time.Time{}, "", false},
+	}
+
+	service := NewSecurityService()
+	service.SetBreachCatalog(NewBreachCatalog([]Breach{
+		{Name: "Example", Title: "Example", Domain: "example.com", BreachDate: "2020-06-01"},
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := entity.NewVault()
+			vault.AddEntry(&entity.Entry{
+				Name:              tt.name,
+				URI:               "https://login.example.com",
+				Password:          tt.password,
+				PasswordUpdatedAt: tt.passwordDate,
+				CreatedAt:         tt.createdAt,
+			})
+
+			breached := service.FindBreachedEntries(vault)
+			if got := len(breached) == 1; got != tt.want {
+				t.Errorf("FindBreachedEntries() = %v, want breached %v", breached, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBreachCatalog(t *testing.T) {
+	dir := t.TempDir()
+
+	catalog, err := LoadBreachCatalog(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadBreachCatalog(missing) error = %v", err)
+	}
+	if catalog.Len() != 0 {
+		t.Errorf("LoadBreachCatalog(missing) Len() = %d, want 0", catalog.Len())
+	}
+
+	valid := filepath.Join(dir, "breaches.json")
+	if err := os.WriteFile(valid, []byte(`[{"Name":"Adobe","Domain":"adobe.com","BreachDate":"2013-10-04"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err = LoadBreachCatalog(valid)
+	if err != nil {
+		t.Fatalf("LoadBreachCatalog() error = %v", err)
+	}
+	if found := catalog.Lookup("www.adobe.com"); len(found) != 1 {
+		t.Errorf("Lookup() = %v, want the Adobe breach", found)
+	}
+
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"Name":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBreachCatalog(malformed); err == nil {
+		t.Error("LoadBreachCatalog(malformed) succeeded, want an error")
+	}
+
+	if _, err := LoadBreachCatalog(dir); err == nil {
+		t.Error("LoadBreachCatalog(directory) succeeded, want an error")
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/dockercredential"
+)
+
+// DockerCredentialFolder is the folder holding registry logins
+const DockerCredentialFolder = "Docker"
+
+// DockerCredentialStore keeps docker registry logins as entries of a dedicated folder
+// Entries outside the folder are never read or changed
+type DockerCredentialStore struct {
+	vault   *entity.Vault
+	changed bool
+}
+
+// NewDockerCredentialStore creates a store of the registry logins in vault
+func NewDockerCredentialStore(vault *entity.Vault) *DockerCredentialStore {
+	return &DockerCredentialStore{vault: vault}
+}
+
+// Changed reports whether the vault needs to be saved
+func (s *DockerCredentialStore) Changed() bool {
+	return s.changed
+}
+
+// Add saves the login of a registry, replacing its previous one
+func (s *DockerCredentialStore) Add(creds *dockercredential.Credentials) error {
+	if entry := s.find(creds.ServerURL); entry != nil {
+		if entry.Username == creds.Username && entry.Password == creds.Secret {
+			return nil
+		}
+		entry.Username = creds.Username
+		entry.SetPassword(creds.Secret)
+		entry.Update()
+		s.changed = true
+		return nil
+	}
+
+	folder := s.folder()
+	if folder == nil {
+		folder = entity.NewFolder(DockerCredentialFolder, "")
+		s.vault.AddFolder(folder)
+	}
+
+	entry := entity.NewEntry(entity.EntryTypeLogin, registryName(creds.ServerURL))
+	entry.URI = creds.ServerURL
+	entry.Username = creds.Username
+	entry.SetPassword(creds.Secret)
+	entry.FolderID = folder.ID
+	s.vault.AddEntry(entry)
+	s.changed = true
+	return nil
+}
+
+// Delete removes the login of a registry
+func (s *DockerCredentialStore) Delete(serverURL string) error {
+	entry := s.find(serverURL)
+	if entry == nil {
+		return dockercredential.ErrNotFound
+	}
+	s.vault.RemoveEntry(entry.ID)
+	s.changed = true
+	return nil
+}
+
+// Get returns the login of a registry
+func (s *DockerCredentialStore) Get(serverURL string) (string, string, error) {
+	entry := s.find(serverURL)
+	if entry == nil {
+		return "", "", dockercredential.ErrNotFound
+	}
+	return entry.Username, entry.Password, nil
+}
+
+// List returns the usernames of all registries by server URL
+func (s *DockerCredentialStore) List() (map[string]string, error) {
+	servers := make(map[string]string)
+	for _, entry := range s.entries() {
+		servers[entry.URI] = entry.Username
+	}
+	return servers, nil
+}
+
+// folder returns the folder of registry logins, or nil before the first login is stored
+func (s *DockerCredentialStore) folder() *entity.Folder {
+	for _, folder := range s.vault.Folders {
+		if folder.IsRoot() && folder.Name == DockerCredentialFolder {
+			return folder
+		}
+	}
+	return nil
+}
+
+// entries returns the registry logins
+func (s *DockerCredentialStore) entries() []*entity.Entry {
+	folder := s.folder()
+	if folder == nil {
+		return nil
+	}
+	var entries []*entity.Entry
+	for _, entry := range s.vault.Entries {
+		if entry.FolderID == folder.ID && entry.Type == entity.EntryTypeLogin {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// find returns the login of a registry, ghcr.io and https://ghcr.io/ being the same
+func (s *DockerCredentialStore) find(serverURL string) *entity.Entry {
+	name := registryName(serverURL)
+	for _, entry := range s.entries() {
+		if registryName(entry.URI) == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// registryName returns the host and path of a server URL without scheme and
+// trailing slash, e.g. index.docker.io/v1 for https://index.docker.io/v1/
+func registryName(serverURL string) string {
+	raw := strings.TrimSpace(serverURL)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(strings.TrimSpace(serverURL), "/")
+	}
+	return strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/")
+}
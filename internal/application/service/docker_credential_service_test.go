@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/dockercredential"
+)
+
+func TestDockerCredentialStore(t *testing.T) {
+	vault := entity.NewVault()
+
+	// A login for the same host outside the folder is not a registry login
+	other := entity.NewEntry(entity.EntryTypeLogin, "GitHub")
+	other.URI, other.Username, other.Password = "https://ghcr.io", "alice", "web-password"
+	vault.AddEntry(other)
+
+	store := NewDockerCredentialStore(vault)
+	if _, _, err := store.Get("ghcr.io"); !errors.Is(err, dockercredential.ErrNotFound) {
+		t.Fatalf("Get() before Add() error = %v", err)
+	}
+
+	if err := store.Add(&dockercredential.Credentials{ServerURL: "ghcr.io", Username: "alice", Secret: "token"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if !store.Changed() || len(vault.Folders) != 1 || vault.Folders[0].Name != DockerCredentialFolder {
+		t.Fatalf("Add() did not create the %s folder: %+v", DockerCredentialFolder, vault.Folders)
+	}
+	entry := vault.Entries[len(vault.Entries)-1]
+	if entry.Name != "ghcr.io" || entry.FolderID != vault.Folders[0].ID || entry.Password != "token" {
+		t.Errorf("added entry = %+v", entry)
+	}
+
+	// Server URLs match with or without scheme and trailing slash
+	username, secret, err := store.Get("https://GHCR.io/")
+	if err != nil || username != "alice" || secret != "token" {
+		t.Errorf("Get() = %q, %q, %v", username, secret, err)
+	}
+
+	// Storing again replaces the login instead of adding one
+	if err := store.Add(&dockercredential.Credentials{ServerURL: "https://ghcr.io", Username: "bob", Secret: "new"}); err != nil {
+		t.Fatalf("Add() replacing error = %v", err)
+	}
+	if _, secret, _ := store.Get("ghcr.io"); secret != "new" || len(vault.Entries) != 2 {
+		t.Errorf("replaced secret = %q with %d entries", secret, len(vault.Entries))
+	}
+	_ = store.Add(&dockercredential.Credentials{ServerURL: "https://index.docker.io/v1/", Username: "carol", Secret: "hub"})
+
+	servers, _ := store.List()
+	if len(servers) != 2 || servers["ghcr.io"] != "bob" || servers["https://index.docker.io/v1/"] != "carol" {
+		t.Errorf("List() = %v", servers)
+	}
+
+	if err := store.Delete("ghcr.io"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete("ghcr.io"); !errors.Is(err, dockercredential.ErrNotFound) {
+		t.Errorf("second Delete() error = %v", err)
+	}
+	if vault.FindEntry(other.ID) == nil || other.Password != "web-password" {
+		t.Error("the login outside the folder was changed")
+	}
+}
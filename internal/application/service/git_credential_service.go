@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/gitcredential"
+)
+
+// GitEraseResult lists the entries matching a credential git rejected
+type GitEraseResult struct {
+	Removed  []*entity.Entry // logins the helper created, holding nothing but the credential
+	Rejected []*entity.Entry // other logins, kept but no longer given to git
+}
+
+// GitCredentialAnswer is the outcome of a credential helper operation
+type GitCredentialAnswer struct {
+	Output   string   // attributes for git
+	Messages []string // notes for the user
+	Changed  bool     // whether the vault needs to be saved
+}
+
+// ServeGitCredential answers an operation of git's credential helper protocol on vault
+// Operations added to git later are ignored, as the protocol requires
+func ServeGitCredential(vault *entity.Vault, operation, input string) (GitCredentialAnswer, error) {
+	var answer GitCredentialAnswer
+	cred, err := gitcredential.Read(strings.NewReader(input))
+	if err != nil {
+		return answer, err
+	}
+
+	switch operation {
+	case "get":
+		entry := FindGitCredential(vault, cred)
+		if entry == nil {
+			return answer, nil
+		}
+		var output strings.Builder
+		login := &gitcredential.Credential{Username: entry.Username, Password: entry.Password}
+		if err := login.Write(&output); err != nil {
+			return answer, err
+		}
+		answer.Output = output.String()
+
+	case "store":
+		entry, changed, err := StoreGitCredential(vault, cred)
+		if err != nil || !changed {
+			return answer, err
+		}
+		answer.Changed = true
+		answer.Messages = append(answer.Messages, fmt.Sprintf("Saved the credential for %s in %q", cred.URL(), entry.Name))
+
+	case "erase":
+		result := EraseGitCredential(vault, cred)
+		answer.Changed = len(result.Removed) > 0 || len(result.Rejected) > 0
+		for _, entry := range result.Removed {
+			answer.Messages = append(answer.Messages, fmt.Sprintf("Removed %q", entry.Name))
+		}
+		for _, entry := range result.Rejected {
+			answer.Messages = append(answer.Messages, fmt.Sprintf("Kept %q, git gets it again once its password changes", entry.Name))
+		}
+	}
+	return answer, nil
+}
+
+// FindGitCredential returns the login git should use, or nil
+// Entries match by the protocol, host and, when git sends one, path of their website;
+// the login for the most specific path wins, then the most recently changed one
+// Logins whose current password git rejected are skipped
+func FindGitCredential(vault *entity.Vault, cred *gitcredential.Credential) *entity.Entry {
+	var best *entity.Entry
+	bestScore := 0
+	for _, entry := range vault.Entries {
+		if entry.Password == "" || gitRejected(entry) {
+			continue
+		}
+		score := gitCredentialScore(entry, cred)
+		if score > bestScore || (score > 0 && score == bestScore && entry.UpdatedAt.After(best.UpdatedAt)) {
+			best, bestScore = entry, score
+		}
+	}
+	return best
+}
+
+// StoreGitCredential saves a credential git used successfully
+// The password of the login get would return for the username is updated,
+// otherwise a login named after the host is created and marked as the helper's
+// Returns the entry and whether the vault changed
+func StoreGitCredential(vault *entity.Vault, cred *gitcredential.Credential) (*entity.Entry, bool, error) {
+	if cred.Protocol == "" || cred.Host == "" {
+		return nil, false, errors.New("git sent no protocol or host")
+	}
+	if cred.Username == "" || cred.Password == "" {
+		return nil, false, errors.New("git sent no username or password")
+	}
+
+	if entry := FindGitCredential(vault, cred); entry != nil {
+		if entry.Password == cred.Password {
+			return entry, false, nil
+		}
+		entry.SetPassword(cred.Password)
+		entry.Update()
+		return entry, true, nil
+	}
+
+	entry := entity.NewEntry(entity.EntryTypeLogin, cred.Host)
+	entry.Username = cred.Username
+	entry.SetPassword(cred.Password)
+	entry.URI = cred.URL()
+	entry.CreatedByGitHelper = true
+	vault.AddEntry(entry)
+	return entry, true, nil
+}
+
+// EraseGitCredential handles the logins matching a credential git rejected
+// Logins the helper created and holding nothing else are removed; any other login,
+// e.g. the website login of a host, is kept and skipped by get until its password changes
+func EraseGitCredential(vault *entity.Vault, cred *gitcredential.Credential) GitEraseResult {
+	var result GitEraseResult
+	for _, entry := range append([]*entity.Entry(nil), vault.Entries...) {
+		if gitCredentialScore(entry, cred) == 0 || (cred.Password != "" && entry.Password != cred.Password) {
+			continue
+		}
+		if entry.CreatedByGitHelper && holdsOnlyCredential(entry) {
+			vault.RemoveEntry(entry.ID)
+			result.Removed = append(result.Removed, entry)
+			continue
+		}
+		if gitRejected(entry) {
+			continue
+		}
+		entry.GitRejectedAt = time.Now()
+		entry.Update()
+		result.Rejected = append(result.Rejected, entry)
+	}
+	return result
+}
+
+// gitRejected reports whether git rejected the current password of a login
+func gitRejected(entry *entity.Entry) bool {
+	return !entry.GitRejectedAt.IsZero() && !entry.PasswordDate().After(entry.GitRejectedAt)
+}
+
+// gitCredentialScore rates how well a login matches a credential, 0 meaning not at all
+func gitCredentialScore(entry *entity.Entry, cred *gitcredential.Credential) int {
+	if entry.Type != entity.EntryTypeLogin || cred.Host == "" {
+		return 0
+	}
+	if cred.Username != "" && entry.Username != cred.Username {
+		return 0
+	}
+
+	uri := strings.TrimSpace(entry.URI)
+	if uri == "" {
+		return 0
+	}
+	explicitScheme := strings.Contains(uri, "://")
+	if !explicitScheme {
+		uri = "https://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0
+	}
+
+	// Websites without a scheme are assumed to be served over HTTP(S)
+	protocol := strings.ToLower(cred.Protocol)
+	if explicitScheme && !strings.EqualFold(u.Scheme, protocol) {
+		return 0
+	}
+	if !explicitScheme && protocol != "https" && protocol != "http" {
+		return 0
+	}
+	if !strings.EqualFold(strings.TrimSuffix(u.Host, "."), cred.Host) {
+		return 0
+	}
+
+	entryPath := gitRepositoryPath(u.Path)
+	credPath := gitRepositoryPath(cred.Path)
+	switch {
+	case entryPath == "":
+		return 2
+	case credPath == "":
+		// git did not say which repository, a login for one of them still helps
+		return 1
+	case credPath == entryPath || strings.HasPrefix(credPath, entryPath+"/"):
+		return 3 + len(entryPath)
+	default:
+		return 0
+	}
+}
+
+// gitRepositoryPath normalizes a repository path, team/repo.git and /team/repo/ are the same
+func gitRepositoryPath(path string) string {
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+// holdsOnlyCredential reports whether an entry holds nothing besides a login
+func holdsOnlyCredential(entry *entity.Entry) bool {
+	return strings.TrimSpace(entry.Notes) == "" &&
+		!entry.HasTOTP() && entry.TOTPSecret == "" &&
+		len(entry.CustomFields) == 0 &&
+		!entry.IsFavorite
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/gitcredential"
+)
+
+// readGitCredential parses credential attributes as git sends them
+func readGitCredential(t *testing.T, input string) *gitcredential.Credential {
+	t.Helper()
+	cred, err := gitcredential.Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read(%q) error = %v", input, err)
+	}
+	return cred
+}
+
+// newGitVault returns a vault with logins for several git hosts
+func newGitVault() *entity.Vault {
+	vault := entity.NewVault()
+	addTestLogin(vault, "GitHub", "alice", "web-password").URI = "https://github.com"
+	addTestLogin(vault, "Work repo", "alice-work", "token").URI = "https://github.com/acme/app"
+	addTestLogin(vault, "Gitea", "bob", "gitea").URI = "http://git.lan:3000/"
+	addTestLogin(vault, "GitLab", "carol", "gitlab").URI = "gitlab.com"
+
+	note := entity.NewEntry(entity.EntryTypeSecureNote, "Not a login")
+	note.URI, note.Password = "https://github.com", "note"
+	vault.AddEntry(note)
+	return vault
+}
+
+func TestFindGitCredential(t *testing.T) {
+	vault := newGitVault()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"protocol=https\nhost=github.com\n", "GitHub"},
+		{"protocol=https\nhost=github.com\npath=acme/app.git\n", "Work repo"},
+		{"protocol=https\nhost=github.com\npath=acme/other.git\n", "GitHub"},
+		{"protocol=https\nhost=GitHub.com\nusername=alice-work\n", "Work repo"},
+		{"protocol=https\nhost=github.com\nusername=mallory\n", ""},
+		{"protocol=http\nhost=github.com\n", ""},
+		{"protocol=http\nhost=git.lan:3000\npath=bob/dotfiles.git\n", "Gitea"},
+		{"protocol=http\nhost=git.lan\n", ""},
+		{"protocol=https\nhost=gitlab.com\n", "GitLab"},
+		{"protocol=ssh\nhost=gitlab.com\n", ""},
+		{"url=https://example.com/repo.git\n", ""},
+	}
+	for _, tt := range tests {
+		got := FindGitCredential(vault, readGitCredential(t, tt.input))
+		if name := entryName(got); name != tt.want {
+			t.Errorf("FindGitCredential(%q) = %q, want %q", tt.input, name, tt.want)
+		}
+	}
+}
+
+func TestStoreGitCredential(t *testing.T) {
+	vault := newGitVault()
+
+	entry, changed, err := StoreGitCredential(vault,
+		readGitCredential(t, "protocol=https\nhost=git.example.com\npath=team/repo.git\nusername=dave\npassword=first\n"))
+	if err != nil || !changed {
+		t.Fatalf("StoreGitCredential() = %v, %v, want a new entry", changed, err)
+	}
+	if entry.Name != "git.example.com" || entry.URI != "https://git.example.com/team/repo.git" ||
+		entry.Username != "dave" || entry.Password != "first" || vault.FindEntry(entry.ID) == nil ||
+		!entry.CreatedByGitHelper {
+		t.Errorf("created entry = %+v", entry)
+	}
+
+	// Storing the same credential again changes nothing, a new password updates the entry
+	query := "protocol=https\nhost=git.example.com\npath=team/repo.git\nusername=dave\n"
+	if _, changed, _ := StoreGitCredential(vault, readGitCredential(t, query+"password=first\n")); changed {
+		t.Error("storing an unchanged credential changed the vault")
+	}
+	updated, changed, err := StoreGitCredential(vault, readGitCredential(t, query+"password=second\n"))
+	if err != nil || !changed || updated != entry || entry.Password != "second" {
+		t.Errorf("StoreGitCredential(new password) = %+v, %v, %v", updated, changed, err)
+	}
+	if found := FindGitCredential(vault, readGitCredential(t, query)); found != entry {
+		t.Errorf("FindGitCredential() after store = %q", entryName(found))
+	}
+
+	if _, _, err := StoreGitCredential(vault, readGitCredential(t, "protocol=https\nhost=github.com\nusername=alice\n")); err == nil {
+		t.Error("StoreGitCredential() without a password succeeded")
+	}
+}
+
+func TestEraseGitCredential(t *testing.T) {
+	vault := newGitVault()
+	count := len(vault.Entries)
+
+	// A website login git rejected is kept, but no longer given to git
+	rejected := "protocol=https\nhost=github.com\nusername=alice\n"
+	result := EraseGitCredential(vault, readGitCredential(t, rejected+"password=web-password\n"))
+	if len(result.Removed) != 0 || len(result.Rejected) != 1 || result.Rejected[0].Name != "GitHub" || len(vault.Entries) != count {
+		t.Fatalf("EraseGitCredential(GitHub) = %+v, %d entries left", result, len(vault.Entries))
+	}
+	if found := FindGitCredential(vault, readGitCredential(t, rejected)); found != nil {
+		t.Errorf("FindGitCredential() after erase = %q, want the rejected login skipped", found.Name)
+	}
+	if tags := result.Rejected[0].Tags; len(tags) != 0 {
+		t.Errorf("rejected login has tags %q, want them left alone", tags)
+	}
+	if result := EraseGitCredential(vault, readGitCredential(t, rejected+"password=web-password\n")); len(result.Rejected) != 0 {
+		t.Errorf("erasing the same credential again = %+v, want no change", result)
+	}
+
+	// A new password makes the login usable again
+	github := result.Rejected[0]
+	github.SetPassword("new-password")
+	if found := FindGitCredential(vault, readGitCredential(t, rejected)); found != github {
+		t.Errorf("FindGitCredential() after a password change = %q", entryName(found))
+	}
+
+	// Only logins the helper created are removed, and only for the rejected password
+	created, _, err := StoreGitCredential(vault,
+		readGitCredential(t, "protocol=https\nhost=git.example.com\nusername=dave\npassword=token\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	erase := "protocol=https\nhost=git.example.com\nusername=dave\n"
+	if result := EraseGitCredential(vault, readGitCredential(t, erase+"password=old-token\n")); len(result.Removed)+len(result.Rejected) != 0 {
+		t.Errorf("EraseGitCredential(other password) = %+v", result)
+	}
+	result = EraseGitCredential(vault, readGitCredential(t, erase+"password=token\n"))
+	if len(result.Removed) != 1 || result.Removed[0] != created || vault.FindEntry(created.ID) != nil {
+		t.Errorf("EraseGitCredential(created login) = %+v", result)
+	}
+}
+
+// addTestLogin adds a login to vault and returns it for further fields
+func addTestLogin(vault *entity.Vault, name, username, password string) *entity.Entry {
+	entry := entity.NewEntry(entity.EntryTypeLogin, name)
+	entry.Username, entry.Password = username, password
+	vault.AddEntry(entry)
+	return entry
+}
+
+// entryName returns the name of entry or "" when there is none
+func entryName(entry *entity.Entry) string {
+	if entry == nil {
+		return ""
+	}
+	return entry.Name
+}
@@ -3,9 +3,13 @@ package service
 import (
 	"crypto/rand"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/hambosto/passmanager/pkg/passwordrules"
 	"github.com/hambosto/passmanager/pkg/validator"
 )
 
@@ -21,14 +25,36 @@ type PasswordConfig struct {
 	MinLower         int
 	MinNumbers       int
 	MinSymbols       int
+
+	// Symbols replaces the default symbol set when not empty
+	Symbols string
+	// AllowedChars are extra characters the password may contain
+	AllowedChars string
+	// ExcludedChars are never generated, whichever set they belong to
+	ExcludedChars string
+
+	// Rules holds site requirements in passwordrules syntax
+	// When set they replace the character set toggles and minimums
+	Rules string
+
+	// Mode selects pattern or pronounceable generation instead of random characters
+	Mode PasswordMode
+	// Pattern is the character class pattern used in pattern mode
+	Pattern string
 }
 
 // PassphraseConfig contains configuration for passphrase generation
 type PassphraseConfig struct {
 	WordCount     int
 	Separator     string
-	Capitalize    bool
+	Capitalize    bool // title-case the first letter of each word
 	IncludeNumber bool
+
+	// WordList is the list words are drawn from, nil for the compiled-in list
+	WordList *WordList
+	// InsertDigit and InsertSymbol add a random digit or symbol at a random position in each word
+	InsertDigit  bool
+	InsertSymbol bool
 }
 
 const (
@@ -37,6 +63,9 @@ const (
 	numbers          = "0123456789"
 	symbols          = "!@#$%^&*()-_=+[]{}|;:,.<>?"
 	ambiguous        = "0O1lI"
+
+	// passphraseSymbols are inserted into words; none of them is a separator choice
+	passphraseSymbols = "!#$%&*+=?@^~"
 )
 
 // DefaultPasswordConfig returns a sensible default password configuration
@@ -52,6 +81,7 @@ func DefaultPasswordConfig() PasswordConfig {
 		MinLower:         1,
 		MinNumbers:       1,
 		MinSymbols:       1,
+		Pattern:          DefaultPasswordPattern,
 	}
 }
 
@@ -62,99 +92,210 @@ func DefaultPassphraseConfig() PassphraseConfig {
 		Separator:     "-",
 		Capitalize:    true,
 		IncludeNumber: true,
+		WordList:      DefaultWordList(),
 	}
 }
 
 // GeneratePassword generates a random password based on the configuration
 func GeneratePassword(config PasswordConfig) (string, error) {
+	switch config.Mode {
+	case PasswordModePattern:
+		return GeneratePatternPassword(config)
+	case PasswordModePronounceable:
+		return GeneratePronounceablePassword(config)
+	case PasswordModeRandom:
+	default:
+		return "", fmt.Errorf("unknown password mode %q", config.Mode)
+	}
+
+	if strings.TrimSpace(config.Rules) != "" {
+		rules, err := passwordrules.Parse(config.Rules)
+		if err != nil {
+			return "", err
+		}
+		return GeneratePasswordFromRules(rules, config.Length, config.ExcludeAmbiguous)
+	}
+
 	// Validate configuration
 	if config.Length < 4 {
 		return "", fmt.Errorf("password length must be at least 4")
 	}
 
-	minRequired := config.MinUpper + config.MinLower + config.MinNumbers + config.MinSymbols
-	if minRequired > config.Length {
-		return "", fmt.Errorf("minimum requirements exceed password length")
+	pool, classes, err := randomPool(config)
+	if err != nil {
+		return "", err
 	}
 
-	// Build character pool
-	var pool string
-	if config.IncludeUpper {
-		pool += uppercaseLetters
+	minRequired := 0
+	for _, class := range classes {
+		minRequired += class.min
 	}
-	if config.IncludeLower {
-		pool += lowercaseLetters
+	if minRequired > config.Length {
+		return "", fmt.Errorf("minimum requirements exceed password length")
 	}
-	if config.IncludeNumbers {
-		pool += numbers
+
+	// Satisfy the minimums first, then fill the remaining positions from the whole pool
+	// Shuffling afterwards leaves every position equally likely to hold a required character
+	password := make([]byte, 0, config.Length)
+	for _, class := range classes {
+		for i := 0; i < class.min; i++ {
+			password = append(password, randomChar(class.chars))
+		}
 	}
-	if config.IncludeSymbols {
-		pool += symbols
+	for len(password) < config.Length {
+		password = append(password, randomChar(pool))
 	}
+	shuffle(password)
 
-	if pool == "" {
-		return "", fmt.Errorf("no character sets selected")
-	}
+	return string(password), nil
+}
 
-	// Remove ambiguous characters if requested
-	if config.ExcludeAmbiguous {
-		pool = removeChars(pool, ambiguous)
-	}
+// characterClass is an enabled character set and the minimum count of its characters
+type characterClass struct {
+	name  string
+	chars string
+	min   int
+}
 
-	// Generate password with minimum requirements
-	password := make([]byte, config.Length)
+// randomPool returns the characters random passwords are drawn from and the enabled
+// character classes, both without excluded and, if requested, ambiguous characters
+func randomPool(config PasswordConfig) (string, []characterClass, error) {
+	symbolSet := symbols
+	if config.Symbols != "" {
+		symbolSet = config.Symbols
+	}
+	if err := validateCharset("symbol set", config.Symbols); err != nil {
+		return "", nil, err
+	}
+	if err := validateCharset("allowed characters", config.AllowedChars); err != nil {
+		return "", nil, err
+	}
 
-	// First, satisfy minimum requirements
-	pos := 0
-	if config.MinUpper > 0 && config.IncludeUpper {
-		chars := uppercaseLetters
+	filter := func(chars string) string {
+		chars = removeChars(chars, config.ExcludedChars)
 		if config.ExcludeAmbiguous {
 			chars = removeChars(chars, ambiguous)
 		}
-		for i := 0; i < config.MinUpper; i++ {
-			password[pos] = randomChar(chars)
-			pos++
-		}
+		return uniqueChars(chars)
 	}
 
-	if config.MinLower > 0 && config.IncludeLower {
-		chars := lowercaseLetters
-		if config.ExcludeAmbiguous {
-			chars = removeChars(chars, ambiguous)
+	var classes []characterClass
+	for _, class := range []struct {
+		enabled bool
+		characterClass
+	}{
+		{config.IncludeUpper, characterClass{"uppercase", uppercaseLetters, config.MinUpper}},
+		{config.IncludeLower, characterClass{"lowercase", lowercaseLetters, config.MinLower}},
+		{config.IncludeNumbers, characterClass{"number", numbers, config.MinNumbers}},
+		{config.IncludeSymbols, characterClass{"symbol", symbolSet, config.MinSymbols}},
+	} {
+		if !class.enabled {
+			continue
+		}
+		if class.min < 0 {
+			return "", nil, fmt.Errorf("minimum %s count cannot be negative", class.name)
 		}
-		for i := 0; i < config.MinLower; i++ {
-			password[pos] = randomChar(chars)
-			pos++
+		class.chars = filter(class.chars)
+		if class.chars == "" && class.min > 0 {
+			return "", nil, fmt.Errorf("all %s characters are excluded", class.name)
 		}
+		classes = append(classes, class.characterClass)
 	}
 
-	if config.MinNumbers > 0 && config.IncludeNumbers {
-		chars := numbers
-		if config.ExcludeAmbiguous {
-			chars = removeChars(chars, ambiguous)
+	var pool strings.Builder
+	for _, class := range classes {
+		pool.WriteString(class.chars)
+	}
+	pool.WriteString(config.AllowedChars)
+
+	// Duplicates would make some characters more likely than others
+	chars := filter(pool.String())
+	if chars == "" {
+		return "", nil, fmt.Errorf("no character sets selected")
+	}
+	return chars, classes, nil
+}
+
+// validateCharset checks that a user-defined character set holds printable ASCII only
+func validateCharset(name, chars string) error {
+	for _, c := range chars {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("%s may only contain printable ASCII characters, not %q", name, c)
 		}
-		for i := 0; i < config.MinNumbers; i++ {
-			password[pos] = randomChar(chars)
-			pos++
+	}
+	return nil
+}
+
+// uniqueChars removes repeated characters, keeping the first occurrence
+func uniqueChars(chars string) string {
+	var b strings.Builder
+	for i, c := range chars {
+		if !strings.ContainsRune(chars[:i], c) {
+			b.WriteRune(c)
 		}
 	}
+	return b.String()
+}
+
+// maxRuleAttempts bounds the retries needed to satisfy a max-consecutive rule
+const maxRuleAttempts = 100
+
+// GeneratePasswordFromRules generates a password satisfying site rules
+// The length is clamped to the rules' limits. Spaces are never generated, and
+// ambiguous characters are only dropped from sets that keep other characters
+func GeneratePasswordFromRules(rules *passwordrules.Rules, length int, excludeAmbiguous bool) (string, error) {
+	if rules.MinLength > 0 && length < rules.MinLength {
+		length = rules.MinLength
+	}
+	if rules.MaxLength > 0 && length > rules.MaxLength {
+		length = rules.MaxLength
+	}
+	if length < 1 {
+		return "", fmt.Errorf("password length must be at least 1")
+	}
 
-	if config.MinSymbols > 0 && config.IncludeSymbols {
-		for i := 0; i < config.MinSymbols; i++ {
-			password[pos] = randomChar(symbols)
-			pos++
+	filter := func(chars string) string {
+		chars = removeChars(chars, " ")
+		if excludeAmbiguous {
+			if unambiguous := removeChars(chars, ambiguous); unambiguous != "" {
+				return unambiguous
+			}
 		}
+		return chars
 	}
 
-	// Fill remaining positions with random characters from pool
-	for i := pos; i < config.Length; i++ {
-		password[i] = randomChar(pool)
+	pool := filter(rules.Pool())
+	if pool == "" {
+		return "", fmt.Errorf("password rules allow no characters")
 	}
 
-	// Shuffle the password to distribute required characters
-	shuffle(password)
+	var required []string
+	for _, set := range rules.RequiredSets() {
+		set = filter(set)
+		if set == "" {
+			return "", fmt.Errorf("password rules require a character that cannot be generated")
+		}
+		required = append(required, set)
+	}
+	if len(required) > length {
+		return "", fmt.Errorf("password rules need %d characters but allow only %d", len(required), length)
+	}
 
-	return string(password), nil
+	for attempt := 0; attempt < maxRuleAttempts; attempt++ {
+		password := make([]byte, length)
+		for i, set := range required {
+			password[i] = randomChar(set)
+		}
+		for i := len(required); i < length; i++ {
+			password[i] = randomChar(pool)
+		}
+		shuffle(password)
+
+		if rules.Check(string(password)) == nil {
+			return string(password), nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a password satisfying the rules")
 }
 
 // GeneratePassphrase generates a random passphrase based on the configuration
@@ -163,18 +304,32 @@ func GeneratePassphrase(config PassphraseConfig) (string, error) {
 		return "", fmt.Errorf("word count must be at least 1")
 	}
 
+	list := config.WordList
+	if list == nil {
+		list = DefaultWordList()
+	}
+	if len(list.Words) < 2 {
+		return "", fmt.Errorf("word list %s has fewer than 2 words", list.Name)
+	}
+
 	// Select random words from the word list
 	words := make([]string, config.WordCount)
 	for i := 0; i < config.WordCount; i++ {
-		wordIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(effWordList))))
+		wordIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(list.Words))))
 		if err != nil {
 			return "", fmt.Errorf("failed to generate random word: %w", err)
 		}
-		words[i] = effWordList[wordIndex.Int64()]
+		words[i] = list.Words[wordIndex.Int64()]
 
 		// Capitalize if requested
 		if config.Capitalize {
-			words[i] = strings.Title(words[i])
+			words[i] = titleCase(words[i])
+		}
+		if config.InsertDigit {
+			words[i] = insertRandom(words[i], numbers)
+		}
+		if config.InsertSymbol {
+			words[i] = insertRandom(words[i], passphraseSymbols)
 		}
 	}
 
@@ -193,6 +348,50 @@ func GeneratePassphrase(config PassphraseConfig) (string, error) {
 	return passphrase, nil
 }
 
+// PassphraseEntropy returns the entropy in bits of passphrases generated with config
+// It is derived from the word list size; the positions of inserted characters are
+// not counted, so the result is a lower bound
+func PassphraseEntropy(config PassphraseConfig) float64 {
+	list := config.WordList
+	if list == nil {
+		list = DefaultWordList()
+	}
+
+	perWord := list.Entropy()
+	if config.InsertDigit {
+		perWord += math.Log2(float64(len(numbers)))
+	}
+	if config.InsertSymbol {
+		perWord += math.Log2(float64(len(passphraseSymbols)))
+	}
+
+	entropy := perWord * float64(config.WordCount)
+	if config.IncludeNumber {
+		entropy += math.Log2(100)
+	}
+	return entropy
+}
+
+// titleCase upper-cases the first letter of a word and keeps the rest unchanged
+func titleCase(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToTitle(r)) + word[size:]
+}
+
+// insertRandom inserts a random character from chars at a random position in word
+func insertRandom(word, chars string) string {
+	runes := []rune(word)
+	pos, err := rand.Int(rand.Reader, big.NewInt(int64(len(runes)+1)))
+	if err != nil {
+		panic(err)
+	}
+	i := int(pos.Int64())
+	return string(runes[:i]) + string(randomChar(chars)) + string(runes[i:])
+}
+
 // randomChar returns a random character from the given string
 func randomChar(chars string) byte {
 	if len(chars) == 0 {
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// chiSquare returns Pearson's chi-squared statistic of observed counts against a uniform distribution
+func chiSquare(counts []int) float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	expected := float64(total) / float64(len(counts))
+
+	statistic := 0.0
+	for _, count := range counts {
+		diff := float64(count) - expected
+		statistic += diff * diff / expected
+	}
+	return statistic
+}
+
+func TestGeneratePasswordMinimums(t *testing.T) {
+	config := DefaultPasswordConfig()
+	config.Length = 16
+	config.MinUpper, config.MinLower, config.MinNumbers, config.MinSymbols = 3, 2, 4, 5
+
+	for i := 0; i < 500; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword() error = %v", err)
+		}
+		if len(password) != config.Length {
+			t.Fatalf("GeneratePassword() = %q, want length %d", password, config.Length)
+		}
+
+		var upper, lower, digits, other int
+		for _, c := range password {
+			switch {
+			case unicode.IsUpper(c):
+				upper++
+			case unicode.IsLower(c):
+				lower++
+			case unicode.IsDigit(c):
+				digits++
+			default:
+				other++
+			}
+		}
+		if upper < 3 || lower < 2 || digits < 4 || other < 5 {
+			t.Fatalf("GeneratePassword() = %q misses a minimum", password)
+		}
+	}
+
+	// Minimums of disabled sets are ignored
+	if _, err := GeneratePassword(PasswordConfig{Length: 8, IncludeLower: true, MinUpper: 20}); err != nil {
+		t.Errorf("GeneratePassword() with a disabled minimum error = %v", err)
+	}
+}
+
+func TestGeneratePasswordCharacterSets(t *testing.T) {
+	config := PasswordConfig{
+		Length:         20,
+		IncludeLower:   true,
+		IncludeSymbols: true,
+		MinSymbols:     2,
+		Symbols:        "#!",
+		AllowedChars:   "~7",
+		ExcludedChars:  "aeiou!",
+	}
+
+	allowed := removeChars(lowercaseLetters, "aeiou") + "#~7"
+	seen := make(map[rune]bool)
+	for i := 0; i < 500; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword() error = %v", err)
+		}
+		if strings.Count(password, "#") < 2 {
+			t.Fatalf("GeneratePassword() = %q, want at least two symbols", password)
+		}
+		for _, c := range password {
+			if !strings.ContainsRune(allowed, c) {
+				t.Fatalf("GeneratePassword() = %q contains %q", password, c)
+			}
+			seen[c] = true
+		}
+	}
+	for _, c := range allowed {
+		if !seen[c] {
+			t.Errorf("GeneratePassword() never generated %q", c)
+		}
+	}
+}
+
+func TestGeneratePasswordUniform(t *testing.T) {
+	config := PasswordConfig{Length: 16, IncludeLower: true, IncludeNumbers: true, AllowedChars: "a0"}
+	pool := lowercaseLetters + numbers
+
+	counts := make([]int, len(pool))
+	for i := 0; i < 2000; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword() error = %v", err)
+		}
+		for _, c := range password {
+			counts[strings.IndexRune(pool, c)]++
+		}
+	}
+
+	// 35 degrees of freedom; 80 is exceeded by chance with a probability below 1e-4,
+	// and would be far exceeded if the repeated allowed characters were weighted double
+	if statistic := chiSquare(counts); statistic > 80 {
+		t.Errorf("character frequencies are not uniform: chi-squared = %.1f, counts = %v", statistic, counts)
+	}
+}
+
+func TestGeneratePasswordRequiredPositions(t *testing.T) {
+	config := PasswordConfig{Length: 8, IncludeUpper: true, IncludeLower: true, MinUpper: 2}
+
+	counts := make([]int, config.Length)
+	for i := 0; i < 4000; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword() error = %v", err)
+		}
+		for pos, c := range password {
+			if unicode.IsUpper(c) {
+				counts[pos]++
+			}
+		}
+	}
+
+	// Required characters must not cluster at the start: 7 degrees of freedom,
+	// exceeded by chance with a probability below 1e-4
+	if statistic := chiSquare(counts); statistic > 30 {
+		t.Errorf("upper case positions are not uniform: chi-squared = %.1f, counts = %v", statistic, counts)
+	}
+}
+
+func TestGeneratePasswordErrors(t *testing.T) {
+	tests := map[string]PasswordConfig{
+		"minimums exceed length": {Length: 4, IncludeUpper: true, IncludeLower: true, MinUpper: 3, MinLower: 2},
+		"negative minimum":       {Length: 8, IncludeLower: true, MinLower: -1},
+		"symbols excluded":       {Length: 8, IncludeLower: true, IncludeSymbols: true, MinSymbols: 1, Symbols: "#", ExcludedChars: "#"},
+		"symbol set with space":  {Length: 8, IncludeSymbols: true, Symbols: "# !"},
+		"non-ASCII allowed":      {Length: 8, IncludeLower: true, AllowedChars: "é"},
+		"everything excluded":    {Length: 8, IncludeNumbers: true, ExcludedChars: numbers},
+	}
+	for name, config := range tests {
+		if password, err := GeneratePassword(config); err == nil {
+			t.Errorf("GeneratePassword(%s) = %q, want error", name, password)
+		}
+	}
+}
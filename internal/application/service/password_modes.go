@@ -0,0 +1,230 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PasswordMode selects how GeneratePassword builds a password
+type PasswordMode string
+
+const (
+	// PasswordModeRandom draws every character from the selected character sets
+	PasswordModeRandom PasswordMode = ""
+	// PasswordModePattern fills a pattern of character classes such as "Cvccvc-9999"
+	PasswordModePattern PasswordMode = "pattern"
+	// PasswordModePronounceable alternates consonants and vowels
+	PasswordModePronounceable PasswordMode = "pronounceable"
+)
+
+// DefaultPasswordPattern is an easy to type pattern with about 37 bits of entropy
+const DefaultPasswordPattern = "Cvccvc-9999"
+
+const (
+	consonants = "bcdfghjklmnpqrstvwxyz"
+	vowels     = "aeiou"
+
+	// pronounceableConsonants leaves out letters that are easily misheard
+	pronounceableConsonants = "bdfghjkmnprstvz"
+)
+
+// patternClasses maps pattern characters to the characters they stand for
+var patternClasses = map[rune]string{
+	'c': consonants,
+	'C': strings.ToUpper(consonants),
+	'v': vowels,
+	'V': strings.ToUpper(vowels),
+	'a': lowercaseLetters,
+	'A': uppercaseLetters,
+	'l': lowercaseLetters + uppercaseLetters,
+	'9': numbers,
+}
+
+// patternClass returns the characters a pattern character stands for
+// The s and x classes draw their symbols from symbolSet
+func patternClass(c rune, symbolSet string) (string, bool) {
+	switch c {
+	case 's':
+		return symbolSet, true
+	case 'x':
+		return lowercaseLetters + uppercaseLetters + numbers + symbolSet, true
+	}
+	chars, ok := patternClasses[c]
+	return chars, ok
+}
+
+// PatternHelp describes the pattern syntax
+const PatternHelp = `c/C consonant  v/V vowel  a/A letter  l mixed-case letter  9 digit  s symbol  x any  \ literal`
+
+// patternToken is one position of a parsed pattern
+type patternToken struct {
+	chars   string // characters to choose from
+	literal rune   // used when chars is empty
+}
+
+// parsePattern parses the password pattern of config
+// Class characters are listed in PatternHelp; "\" makes the next character literal
+// and every other character is copied as is. Classes honor the custom symbol set
+// and excluded characters of config
+func parsePattern(config PasswordConfig) ([]patternToken, error) {
+	symbolSet := symbols
+	if config.Symbols != "" {
+		symbolSet = config.Symbols
+	}
+	if err := validateCharset("symbol set", config.Symbols); err != nil {
+		return nil, err
+	}
+
+	var tokens []patternToken
+	escaped := false
+
+	for _, c := range config.Pattern {
+		if escaped {
+			tokens = append(tokens, patternToken{literal: c})
+			escaped = false
+			continue
+		}
+		if c == '\\' {
+			escaped = true
+			continue
+		}
+
+		chars, ok := patternClass(c, symbolSet)
+		if !ok {
+			tokens = append(tokens, patternToken{literal: c})
+			continue
+		}
+		chars = uniqueChars(removeChars(chars, config.ExcludedChars))
+		if chars == "" {
+			return nil, fmt.Errorf("all characters of pattern class %q are excluded", c)
+		}
+		if config.ExcludeAmbiguous {
+			if unambiguous := removeChars(chars, ambiguous); unambiguous != "" {
+				chars = unambiguous
+			}
+		}
+		tokens = append(tokens, patternToken{chars: chars})
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("pattern ends with an escape character")
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	return tokens, nil
+}
+
+// GeneratePatternPassword generates a password following the pattern of config
+func GeneratePatternPassword(config PasswordConfig) (string, error) {
+	tokens, err := parsePattern(config)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, token := range tokens {
+		if token.chars == "" {
+			b.WriteRune(token.literal)
+		} else {
+			b.WriteByte(randomChar(token.chars))
+		}
+	}
+	return b.String(), nil
+}
+
+// pronounceableSets returns the character set of each position of a pronounceable password
+// Letters alternate between consonants and vowels starting with a consonant; with
+// numbers enabled the last two positions are digits
+func pronounceableSets(config PasswordConfig) ([]string, error) {
+	if config.Length < 4 {
+		return nil, fmt.Errorf("password length must be at least 4")
+	}
+
+	consonantSet := removeChars(pronounceableConsonants, config.ExcludedChars)
+	vowelSet := removeChars(vowels, config.ExcludedChars)
+	digitSet := removeChars(numbers, config.ExcludedChars)
+	if config.ExcludeAmbiguous {
+		digitSet = removeChars(digitSet, ambiguous)
+	}
+	if consonantSet == "" || vowelSet == "" || (config.IncludeNumbers && digitSet == "") {
+		return nil, fmt.Errorf("too many characters are excluded for a pronounceable password")
+	}
+
+	letters := config.Length
+	if config.IncludeNumbers {
+		letters -= 2
+	}
+
+	sets := make([]string, 0, config.Length)
+	for i := 0; i < letters; i++ {
+		if i%2 == 0 {
+			sets = append(sets, consonantSet)
+		} else {
+			sets = append(sets, vowelSet)
+		}
+	}
+	for len(sets) < config.Length {
+		sets = append(sets, digitSet)
+	}
+	return sets, nil
+}
+
+// GeneratePronounceablePassword generates a password of alternating consonants and vowels
+// IncludeUpper capitalizes the first letter and IncludeNumbers ends it with two digits
+func GeneratePronounceablePassword(config PasswordConfig) (string, error) {
+	sets, err := pronounceableSets(config)
+	if err != nil {
+		return "", err
+	}
+
+	password := make([]rune, len(sets))
+	for i, set := range sets {
+		password[i] = rune(randomChar(set))
+	}
+	if upper := unicode.ToUpper(password[0]); config.IncludeUpper && !strings.ContainsRune(config.ExcludedChars, upper) {
+		password[0] = upper
+	}
+	return string(password), nil
+}
+
+// PasswordEntropy returns the entropy in bits of passwords generated with config
+// Pattern and pronounceable passwords are rated by the size of the set each position
+// is drawn from; random passwords by their length and character pool
+func PasswordEntropy(config PasswordConfig) (float64, error) {
+	var sets []string
+
+	switch config.Mode {
+	case PasswordModePattern:
+		tokens, err := parsePattern(config)
+		if err != nil {
+			return 0, err
+		}
+		for _, token := range tokens {
+			if token.chars != "" {
+				sets = append(sets, token.chars)
+			}
+		}
+
+	case PasswordModePronounceable:
+		var err error
+		if sets, err = pronounceableSets(config); err != nil {
+			return 0, err
+		}
+
+	default:
+		pool, _, err := randomPool(config)
+		if err != nil {
+			return 0, err
+		}
+		return float64(config.Length) * math.Log2(float64(len(pool))), nil
+	}
+
+	entropy := 0.0
+	for _, set := range sets {
+		entropy += math.Log2(float64(len(set)))
+	}
+	return entropy, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePatternPassword(t *testing.T) {
+	tests := map[string]*regexp.Regexp{
+		"Cvccvc-9999": regexp.MustCompile(`^[B-DF-HJ-NP-TV-Z][aeiou][b-df-hj-np-tv-z]{2}[aeiou][b-df-hj-np-tv-z]-[0-9]{4}$`),
+		`aA\9\\9`:     regexp.MustCompile(`^[a-z][A-Z]9\\[0-9]$`),
+		"ll.x":        regexp.MustCompile(`^[a-zA-Z]{2}\..$`),
+	}
+	for pattern, want := range tests {
+		for i := 0; i < 50; i++ {
+			password, err := GeneratePatternPassword(PasswordConfig{Pattern: pattern})
+			if err != nil {
+				t.Fatalf("GeneratePatternPassword(%q) error = %v", pattern, err)
+			}
+			if !want.MatchString(password) {
+				t.Fatalf("GeneratePatternPassword(%q) = %q", pattern, password)
+			}
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		password, _ := GeneratePatternPassword(PasswordConfig{Pattern: "xxxxxxxx", ExcludeAmbiguous: true})
+		if strings.ContainsAny(password, ambiguous) {
+			t.Fatalf("GeneratePatternPassword excluding ambiguous = %q", password)
+		}
+	}
+
+	// Custom symbols and excluded characters apply to pattern classes too
+	custom := PasswordConfig{Pattern: "ssssxxxxxxxx", Symbols: "#$", ExcludedChars: "$aeiouAEIOU0123"}
+	for i := 0; i < 100; i++ {
+		password, err := GeneratePatternPassword(custom)
+		if err != nil {
+			t.Fatalf("GeneratePatternPassword(custom) error = %v", err)
+		}
+		if !strings.HasPrefix(password, "####") || strings.ContainsAny(password, custom.ExcludedChars) {
+			t.Fatalf("GeneratePatternPassword(custom) = %q", password)
+		}
+		if strings.ContainsAny(password, "!@%^&*") {
+			t.Fatalf("GeneratePatternPassword(custom) = %q uses default symbols", password)
+		}
+	}
+
+	for _, config := range []PasswordConfig{
+		{Pattern: "s", Symbols: "#", ExcludedChars: "#"},
+		{Pattern: "9", ExcludedChars: "0123456789"},
+		{Pattern: "s", Symbols: "# "},
+	} {
+		if _, err := GeneratePatternPassword(config); err == nil {
+			t.Errorf("GeneratePatternPassword(%+v) succeeded, want error", config)
+		}
+	}
+
+	for _, pattern := range []string{"", `abc\`} {
+		if _, err := GeneratePatternPassword(PasswordConfig{Pattern: pattern}); err == nil {
+			t.Errorf("GeneratePatternPassword(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestGeneratePronounceablePassword(t *testing.T) {
+	config := PasswordConfig{Mode: PasswordModePronounceable, Length: 10, IncludeUpper: true, IncludeNumbers: true}
+	want := regexp.MustCompile(`^[BDFGHJKMNPRSTVZ][aeiou]([bdfghjkmnprstvz][aeiou]){3}[0-9]{2}$`)
+	for i := 0; i < 50; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword(pronounceable) error = %v", err)
+		}
+		if !want.MatchString(password) {
+			t.Fatalf("GeneratePassword(pronounceable) = %q", password)
+		}
+	}
+
+	excluded := PasswordConfig{Length: 10, IncludeUpper: true, IncludeNumbers: true, ExcludedChars: "aeBb9"}
+	for i := 0; i < 50; i++ {
+		password, err := GeneratePronounceablePassword(excluded)
+		if err != nil {
+			t.Fatalf("GeneratePronounceablePassword(excluded) error = %v", err)
+		}
+		if strings.ContainsAny(password, excluded.ExcludedChars) {
+			t.Fatalf("GeneratePronounceablePassword(excluded) = %q", password)
+		}
+	}
+	if _, err := GeneratePronounceablePassword(PasswordConfig{Length: 10, ExcludedChars: "aeiou"}); err == nil {
+		t.Error("GeneratePronounceablePassword(no vowels) succeeded, want error")
+	}
+
+	if _, err := GeneratePronounceablePassword(PasswordConfig{Length: 3}); err == nil {
+		t.Error("GeneratePronounceablePassword(length 3) succeeded, want error")
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PasswordConfig
+		want   float64
+	}{
+		{
+			name:   "pattern",
+			config: PasswordConfig{Mode: PasswordModePattern, Pattern: "Cvccvc-9999"},
+			want:   4*math.Log2(21) + 2*math.Log2(5) + 4*math.Log2(10),
+		},
+		{
+			name:   "pattern literals",
+			config: PasswordConfig{Mode: PasswordModePattern, Pattern: `\c-\9`},
+			want:   0,
+		},
+		{
+			name:   "pronounceable",
+			config: PasswordConfig{Mode: PasswordModePronounceable, Length: 8, IncludeNumbers: true},
+			want:   3*math.Log2(15) + 3*math.Log2(5) + 2*math.Log2(10),
+		},
+		{
+			name:   "random",
+			config: PasswordConfig{Length: 12, IncludeLower: true, IncludeNumbers: true},
+			want:   12 * math.Log2(36),
+		},
+	}
+	for _, tt := range tests {
+		got, err := PasswordEntropy(tt.config)
+		if err != nil {
+			t.Fatalf("PasswordEntropy(%s) error = %v", tt.name, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("PasswordEntropy(%s) = %f, want %f", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := PasswordEntropy(PasswordConfig{Length: 12}); err == nil {
+		t.Error("PasswordEntropy without character sets succeeded, want error")
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/passwordrules"
+)
+
+// bundledPasswordRules is a subset of the password-rules.json quirks file
+// published by Apple's password-manager-resources project
+//
+//go:embed data/password-rules.json
+var bundledPasswordRules []byte
+
+// PasswordRulesDirectory is a local index of site password rules
+type PasswordRulesDirectory struct {
+	byDomain map[string]string
+}
+
+var (
+	bundledRulesOnce      sync.Once
+	bundledRulesDirectory *PasswordRulesDirectory
+)
+
+// ParsePasswordRulesDirectory parses a password-rules.json file, a map from
+// domain to an object holding the "password-rules" string
+// Domains whose rules do not parse are skipped
+func ParsePasswordRulesDirectory(data []byte) (*PasswordRulesDirectory, error) {
+	var sites map[string]struct {
+		Rules string `json:"password-rules"`
+	}
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return nil, fmt.Errorf("failed to parse password rules: %w", err)
+	}
+
+	directory := &PasswordRulesDirectory{byDomain: make(map[string]string)}
+	for domain, site := range sites {
+		if _, err := passwordrules.Parse(site.Rules); err != nil {
+			continue
+		}
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			directory.byDomain[domain] = site.Rules
+		}
+	}
+	return directory, nil
+}
+
+// BundledPasswordRules returns the password rules shipped with the application
+func BundledPasswordRules() *PasswordRulesDirectory {
+	bundledRulesOnce.Do(func() {
+		directory, err := ParsePasswordRulesDirectory(bundledPasswordRules)
+		if err != nil {
+			directory = &PasswordRulesDirectory{byDomain: make(map[string]string)}
+		}
+		bundledRulesDirectory = directory
+	})
+	return bundledRulesDirectory
+}
+
+// Lookup returns the rules listed for a host or one of its parent domains
+func (d *PasswordRulesDirectory) Lookup(host string) (string, bool) {
+	if d == nil || host == "" {
+		return "", false
+	}
+
+	for _, domain := range parentDomains(host) {
+		if rules, ok := d.byDomain[domain]; ok {
+			return rules, true
+		}
+	}
+	return "", false
+}
+
+// Len returns the number of domains in the directory
+func (d *PasswordRulesDirectory) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.byDomain)
+}
+
+// PasswordRulesForEntry returns the rules the generator should follow for an entry
+// Rules stored on the entry win over the bundled rules for its website
+func PasswordRulesForEntry(entry *entity.Entry) string {
+	if entry == nil {
+		return ""
+	}
+	if rules := strings.TrimSpace(entry.PasswordRules); rules != "" {
+		return rules
+	}
+	rules, _ := BundledPasswordRules().Lookup(entry.Host())
+	return rules
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/passwordrules"
+)
+
+func TestBundledPasswordRules(t *testing.T) {
+	directory := BundledPasswordRules()
+	if directory.Len() == 0 {
+		t.Fatal("bundled password rules are empty")
+	}
+
+	// Every bundled rule must be satisfiable
+	for domain, text := range directory.byDomain {
+		rules, err := passwordrules.Parse(text)
+		if err != nil {
+			t.Fatalf("%s: %v", domain, err)
+		}
+		for i := 0; i < 20; i++ {
+			password, err := GeneratePasswordFromRules(rules, 16, true)
+			if err != nil {
+				t.Fatalf("%s: GeneratePasswordFromRules() error = %v", domain, err)
+			}
+			if err := rules.Check(password); err != nil {
+				t.Fatalf("%s: %q breaks the rules: %v", domain, password, err)
+			}
+		}
+	}
+}
+
+func TestPasswordRulesForEntry(t *testing.T) {
+	entry := &entity.Entry{URI: "https://secure.chase.com/login"}
+	if rules := PasswordRulesForEntry(entry); !strings.Contains(rules, "max-consecutive: 2") {
+		t.Errorf("PasswordRulesForEntry() = %q, want the chase.com rules", rules)
+	}
+
+	entry.PasswordRules = "minlength: 30;"
+	if rules := PasswordRulesForEntry(entry); rules != "minlength: 30;" {
+		t.Errorf("PasswordRulesForEntry() = %q, want the entry's own rules", rules)
+	}
+
+	if rules := PasswordRulesForEntry(&entity.Entry{URI: "https://unknown.example"}); rules != "" {
+		t.Errorf("PasswordRulesForEntry() = %q, want none", rules)
+	}
+}
+
+func TestGeneratePasswordWithRules(t *testing.T) {
+	config := DefaultPasswordConfig()
+	config.Length = 40
+	config.Rules = "required: upper; required: [!#]; allowed: digit; maxlength: 12; max-consecutive: 1;"
+
+	rules, err := passwordrules.Parse(config.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		password, err := GeneratePassword(config)
+		if err != nil {
+			t.Fatalf("GeneratePassword() error = %v", err)
+		}
+		if len(password) != 12 {
+			t.Fatalf("len(%q) = %d, want length clamped to 12", password, len(password))
+		}
+		if strings.ContainsAny(password, "0O1lI") {
+			t.Fatalf("%q contains ambiguous characters", password)
+		}
+		if err := rules.Check(password); err != nil {
+			t.Fatalf("%q breaks the rules: %v", password, err)
+		}
+	}
+
+	config.Rules = "required: [ ];"
+	if _, err := GeneratePassword(config); err == nil {
+		t.Error("GeneratePassword() with an unsatisfiable rule expected error")
+	}
+	config.Rules = "required upper"
+	if _, err := GeneratePassword(config); err == nil {
+		t.Error("GeneratePassword() with invalid rules expected error")
+	}
+}
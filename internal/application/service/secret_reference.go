@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+)
+
+// SecretReferencePrefix starts references to a field of an entry,
+// pm://Entry/field or pm://Folder/Subfolder/Entry/field
+const SecretReferencePrefix = "pm://"
+
+// MaskedSecret replaces resolved secrets in masked output
+const MaskedSecret = "<concealed by passmanager>"
+
+// templateReference matches {{ pm://... }} in templates
+var templateReference = regexp.MustCompile(`\{\{\s*(pm://[^{}]*?)\s*\}\}`)
+
+// SecretReference names a field of an entry, optionally inside a folder
+// Segments are percent-decoded, so names containing a slash are written with %2F
+type SecretReference struct {
+	Folders []string
+	Entry   string
+	Field   string
+}
+
+// IsSecretReference reports whether value is a pm:// reference
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, SecretReferencePrefix)
+}
+
+// ParseSecretReference parses pm://[Folder/...]Entry/field
+func ParseSecretReference(ref string) (*SecretReference, error) {
+	if !IsSecretReference(ref) {
+		return nil, fmt.Errorf("%q is not a %s reference", ref, SecretReferencePrefix)
+	}
+
+	segments := strings.Split(strings.TrimPrefix(ref, SecretReferencePrefix), "/")
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("%q names no entry and field, use %sEntry/field", ref, SecretReferencePrefix)
+	}
+	for i, segment := range segments {
+		name, err := url.PathUnescape(segment)
+		if err != nil || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%q has an invalid or empty name", ref)
+		}
+		segments[i] = name
+	}
+
+	last := len(segments) - 1
+	return &SecretReference{
+		Folders: segments[:last-1],
+		Entry:   segments[last-1],
+		Field:   segments[last],
+	}, nil
+}
+
+// SecretResolver resolves references against an unlocked vault or through the agent
+type SecretResolver struct {
+	vault  *entity.Vault
+	totp   *TOTPService
+	remote func(ref string) (string, error)
+}
+
+// NewSecretResolver creates a resolver of references to entries of vault
+func NewSecretResolver(vault *entity.Vault) *SecretResolver {
+	return &SecretResolver{vault: vault, totp: NewTOTPService()}
+}
+
+// NewAgentSecretResolver creates a resolver asking the agent behind client, which
+// resolves the references without handing out the vault
+func NewAgentSecretResolver(client *agent.Client) *SecretResolver {
+	return &SecretResolver{remote: client.Resolve}
+}
+
+// Resolve returns the value a reference points to
+// Besides the fields of EntryField, totp gives the current time-based code
+func (r *SecretResolver) Resolve(ref string) (string, error) {
+	if r.remote != nil {
+		return r.remote(ref)
+	}
+	parsed, err := ParseSecretReference(ref)
+	if err != nil {
+		return "", err
+	}
+	entry, err := r.findEntry(parsed)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.EqualFold(parsed.Field, "totp") {
+		if !entry.HasTOTP() {
+			return "", fmt.Errorf("entry %q has no one-time password", entry.Name)
+		}
+		if entry.TOTP.IsHOTP() {
+			return "", fmt.Errorf("entry %q has a counter-based code, which references cannot use up", entry.Name)
+		}
+		code, _, err := r.totp.GenerateCode(entry.TOTP)
+		return code, err
+	}
+	return EntryField(entry, parsed.Field)
+}
+
+// ResolveEnv resolves the variables of an environment in KEY=VALUE form whose
+// whole value is a reference, returning the new environment and the resolved values
+func (r *SecretResolver) ResolveEnv(env []string) ([]string, []string, error) {
+	resolved := make([]string, 0, len(env))
+	var secrets []string
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		if !IsSecretReference(value) {
+			resolved = append(resolved, variable)
+			continue
+		}
+		secret, err := r.Resolve(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", key, err)
+		}
+		resolved = append(resolved, key+"="+secret)
+		secrets = append(secrets, secret)
+	}
+	return resolved, secrets, nil
+}
+
+// RenderTemplate replaces every {{ pm://... }} in template with the value it references
+func (r *SecretResolver) RenderTemplate(template string) (string, error) {
+	var firstErr error
+	rendered := templateReference.ReplaceAllStringFunc(template, func(match string) string {
+		ref := templateReference.FindStringSubmatch(match)[1]
+		value, err := r.Resolve(ref)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", ref, err)
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return rendered, nil
+}
+
+// HasSecretReferences reports whether an environment in KEY=VALUE form references secrets
+func HasSecretReferences(env []string) bool {
+	for _, variable := range env {
+		if _, value, _ := strings.Cut(variable, "="); IsSecretReference(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTemplateReferences reports whether a template contains {{ pm://... }}
+func HasTemplateReferences(template string) bool {
+	return templateReference.MatchString(template)
+}
+
+// findEntry returns the single entry named by a reference
+// Without folders the entry is looked up in the whole vault
+func (r *SecretResolver) findEntry(ref *SecretReference) (*entity.Entry, error) {
+	var matches []*entity.Entry
+	for _, entry := range r.vault.Entries {
+		if !strings.EqualFold(entry.Name, ref.Entry) {
+			continue
+		}
+		if len(ref.Folders) > 0 && !r.inFolder(entry, ref.Folders) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no entry %s", ref.describe())
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d entries match %s, add their folder to the reference", len(matches), ref.describe())
+	}
+}
+
+// inFolder reports whether an entry's folder path matches folders, case-insensitively
+func (r *SecretResolver) inFolder(entry *entity.Entry, folders []string) bool {
+	var path []string
+	folder := r.vault.FindFolder(entry.FolderID)
+	for folder != nil && len(path) <= len(r.vault.Folders) {
+		path = append([]string{folder.Name}, path...)
+		folder = r.vault.FindFolder(folder.ParentID)
+	}
+
+	if len(path) != len(folders) {
+		return false
+	}
+	for i := range path {
+		if !strings.EqualFold(path[i], folders[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// describe names the entry of a reference for error messages
+func (r *SecretReference) describe() string {
+	if len(r.Folders) == 0 {
+		return fmt.Sprintf("named %q", r.Entry)
+	}
+	return fmt.Sprintf("named %q in %s", r.Entry, strings.Join(r.Folders, "/"))
+}
+
+// EntryField returns a built-in field of an entry or a custom field by name
+func EntryField(entry *entity.Entry, field string) (string, error) {
+	switch strings.ToLower(field) {
+	case "username":
+		return entry.Username, nil
+	case "password":
+		return entry.Password, nil
+	case "uri", "url":
+		return entry.URI, nil
+	case "notes":
+		return entry.Notes, nil
+	case "name", "title":
+		return entry.Name, nil
+	}
+	for name, value := range entry.CustomFields {
+		if strings.EqualFold(name, field) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("entry %q has no field %q", entry.Name, field)
+}
+
+// MaskingWriter replaces secrets in what is written through it with MaskedSecret
+// Output that may be the start of a secret is held back until it is known not to
+// be, so call Flush when done
+type MaskingWriter struct {
+	w       io.Writer
+	secrets [][]byte
+	buf     []byte
+}
+
+// NewMaskingWriter creates a writer masking secrets in output written to w
+func NewMaskingWriter(w io.Writer, secrets []string) *MaskingWriter {
+	m := &MaskingWriter{w: w}
+	for _, secret := range secrets {
+		if secret != "" {
+			m.secrets = append(m.secrets, []byte(secret))
+		}
+	}
+	// Prefer the longest secret where several start at the same place
+	sort.Slice(m.secrets, func(i, j int) bool { return len(m.secrets[i]) > len(m.secrets[j]) })
+	return m
+}
+
+// Write masks and writes p, keeping back a possible start of a secret
+func (m *MaskingWriter) Write(p []byte) (int, error) {
+	m.buf = append(m.buf, p...)
+	if err := m.write(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the output held back
+func (m *MaskingWriter) Flush() error {
+	return m.write(true)
+}
+
+// write masks the buffer and writes it up to a possible start of a secret,
+// or completely when final
+func (m *MaskingWriter) write(final bool) error {
+	var out []byte
+	i := 0
+scan:
+	for i < len(m.buf) {
+		rest := m.buf[i:]
+		for _, secret := range m.secrets {
+			if bytes.HasPrefix(rest, secret) {
+				out = append(out, MaskedSecret...)
+				i += len(secret)
+				continue scan
+			}
+		}
+		if !final {
+			for _, secret := range m.secrets {
+				if len(rest) < len(secret) && bytes.HasPrefix(secret, rest) {
+					break scan
+				}
+			}
+		}
+		out = append(out, m.buf[i])
+		i++
+	}
+	m.buf = append(m.buf[:0], m.buf[i:]...)
+
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(out)
+	return err
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+)
+
+// newReferenceVault returns a vault with entries named alike in different folders
+func newReferenceVault() *entity.Vault {
+	vault := entity.NewVault()
+	work := entity.NewFolder("Work", "")
+	prod := entity.NewFolder("Prod", work.ID)
+	vault.AddFolder(work)
+	vault.AddFolder(prod)
+
+	addTestLogin(vault, "Database", "app", "work-db").FolderID = work.ID
+	addTestLogin(vault, "Database", "app", "prod-db").FolderID = prod.ID
+	addTestLogin(vault, "API/Key", "app", "api-key").CustomFields["Token"] = "t0ken"
+	addTestLogin(vault, "Mail", "app", "mail").TOTP = &entity.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Period: 30}
+	return vault
+}
+
+func TestSecretResolver(t *testing.T) {
+	resolver := NewSecretResolver(newReferenceVault())
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "pm://Work/Database/password", want: "work-db"},
+		{ref: "pm://work/prod/database/Password", want: "prod-db"},
+		{ref: "pm://Work/Prod/Database/username", want: "app"},
+		{ref: "pm://API%2FKey/token", want: "t0ken"},
+		{ref: "pm://Database/password", wantErr: "2 entries"},
+		{ref: "pm://Prod/Database/password", wantErr: "no entry"},
+		{ref: "pm://Work/Database/pin", wantErr: "no field"},
+		{ref: "pm://Database", wantErr: "no entry and field"},
+		{ref: "pm://Work//password", wantErr: "empty"},
+		{ref: "pm://API%2FKey/totp", wantErr: "no one-time password"},
+	}
+	for _, tt := range tests {
+		got, err := resolver.Resolve(tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	if code, err := resolver.Resolve("pm://Mail/totp"); err != nil || len(code) != 6 {
+		t.Errorf("Resolve(totp) = %q, %v", code, err)
+	}
+}
+
+func TestSecretResolverEnvAndTemplate(t *testing.T) {
+	resolver := NewSecretResolver(newReferenceVault())
+
+	env := []string{"HOME=/home/app", "DB_PASSWORD=pm://Work/Prod/Database/password", "EMPTY"}
+	if !HasSecretReferences(env) || HasSecretReferences(env[:1]) {
+		t.Error("HasSecretReferences() did not find the reference")
+	}
+	resolved, secrets, err := resolver.ResolveEnv(env)
+	if err != nil {
+		t.Fatalf("ResolveEnv() error = %v", err)
+	}
+	if strings.Join(resolved, " ") != "HOME=/home/app DB_PASSWORD=prod-db EMPTY" || len(secrets) != 1 || secrets[0] != "prod-db" {
+		t.Errorf("ResolveEnv() = %q, %q", resolved, secrets)
+	}
+	if _, _, err := resolver.ResolveEnv([]string{"TOKEN=pm://Missing/password"}); err == nil || !strings.HasPrefix(err.Error(), "TOKEN: ") {
+		t.Errorf("ResolveEnv(missing) error = %v", err)
+	}
+
+	template := "user: {{pm://Work/Database/username}}\npassword: \"{{ pm://Work/Database/password }}\"\nliteral: pm://Work/Database/password\n"
+	rendered, err := resolver.RenderTemplate(template)
+	if err != nil {
+		t.Fatalf("RenderTemplate() error = %v", err)
+	}
+	if rendered != "user: app\npassword: \"work-db\"\nliteral: pm://Work/Database/password\n" {
+		t.Errorf("RenderTemplate() = %q", rendered)
+	}
+	if _, err := resolver.RenderTemplate("{{ pm://Nope/password }}"); err == nil || !strings.Contains(err.Error(), "pm://Nope/password") {
+		t.Errorf("RenderTemplate(missing) error = %v", err)
+	}
+}
+
+func TestMaskingWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewMaskingWriter(&out, []string{"s3cret", "s3cretive", ""})
+
+	// Secrets split across writes are masked as well
+	for _, chunk := range []string{"token=s3", "cret and s3cretive", "!\npartial s3cr"} {
+		if n, err := writer.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if !strings.HasSuffix(out.String(), "partial ") {
+		t.Errorf("a possible start of a secret was not held back: %q", out.String())
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "token=" + MaskedSecret + " and " + MaskedSecret + "!\npartial s3cr"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -2,19 +2,133 @@ package service
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hambosto/passmanager/internal/domain/entity"
 	"github.com/hambosto/passmanager/pkg/validator"
 )
 
 // SecurityService handles security-related operations
-type SecurityService struct{}
+type SecurityService struct {
+	breaches  *BreachCatalog
+	twoFactor *TwoFactorDirectory
+}
 
 // NewSecurityService creates a new security service
 func NewSecurityService() *SecurityService {
 	return &SecurityService{}
 }
 
+// LoadBreachCatalog loads the local breach catalog used by FindBreachedEntries
+func (s *SecurityService) LoadBreachCatalog(path string) error {
+	catalog, err := LoadBreachCatalog(path)
+	if err != nil {
+		return err
+	}
+	s.breaches = catalog
+	return nil
+}
+
+// SetBreachCatalog replaces the breach catalog
+func (s *SecurityService) SetBreachCatalog(catalog *BreachCatalog) {
+	s.breaches = catalog
+}
+
+// LoadTwoFactorDirectory loads the local 2FA directory used by FindMissingTwoFactor
+func (s *SecurityService) LoadTwoFactorDirectory(path string) error {
+	directory, err := LoadTwoFactorDirectory(path)
+	if err != nil {
+		return err
+	}
+	s.twoFactor = directory
+	return nil
+}
+
+// SetTwoFactorDirectory replaces the 2FA directory
+func (s *SecurityService) SetTwoFactorDirectory(directory *TwoFactorDirectory) {
+	s.twoFactor = directory
+}
+
+// FindingKind identifies the type of issue found by an audit
+type FindingKind int
+
+const (
+	FindingWeakPassword FindingKind = iota
+	FindingReusedPassword
+	FindingBreachedSite
+	FindingInsecureURI
+	FindingMissingTwoFactor
+)
+
+// String returns the string representation of the finding kind
+func (k FindingKind) String() string {
+	switch k {
+	case FindingWeakPassword:
+		return "Weak password"
+	case FindingReusedPassword:
+		return "Reused password"
+	case FindingBreachedSite:
+		return "Breached site"
+	case FindingInsecureURI:
+		return "Insecure website"
+	case FindingMissingTwoFactor:
+		return "Two-factor available"
+	default:
+		return "Unknown"
+	}
+}
+
+// key returns the stable identifier of the kind used in dismissal IDs
+func (k FindingKind) key() string {
+	switch k {
+	case FindingWeakPassword:
+		return "weak"
+	case FindingReusedPassword:
+		return "reused"
+	case FindingBreachedSite:
+		return "breached"
+	case FindingInsecureURI:
+		return "insecure_uri"
+	case FindingMissingTwoFactor:
+		return "missing_2fa"
+	default:
+		return "unknown"
+	}
+}
+
+// Finding is a single audit result for an entry
+type Finding struct {
+	Kind      FindingKind
+	Entry     *entity.Entry
+	Detail    string
+	Dismissed bool
+
+	// subject narrows the finding ID, e.g. to a specific breach
+	subject string
+}
+
+// ID returns a stable identifier used to dismiss the finding
+func (f Finding) ID() string {
+	id := f.Kind.key() + ":" + f.Entry.ID
+	if f.subject != "" {
+		id += ":" + f.subject
+	}
+	return id
+}
+
+// BreachedEntry pairs an entry with a breach that happened after its password was set
+type BreachedEntry struct {
+	Entry  *entity.Entry
+	Breach Breach
+}
+
+// MissingTwoFactorEntry pairs an entry with the directory listing of a site that supports TOTP
+type MissingTwoFactorEntry struct {
+	Entry *entity.Entry
+	Site  TwoFactorSite
+}
+
 // CheckPasswordStrength checks the strength of a password
 func (s *SecurityService) CheckPasswordStrength(password string) validator.PasswordStrength {
 	entropy := validator.CalculateEntropy(password)
@@ -71,17 +185,154 @@ func (s *SecurityService) FindDuplicatePasswords(vault *entity.Vault) map[string
 	return duplicates
 }
 
+// FindBreachedEntries finds entries whose site was breached after the password was last set
+func (s *SecurityService) FindBreachedEntries(vault *entity.Vault) []BreachedEntry {
+	var breached []BreachedEntry
+
+	if s.breaches.Len() == 0 {
+		return breached
+	}
+
+	for _, entry := range vault.Entries {
+		if entry.Password == "" {
+			continue
+		}
+
+		for _, breach := range s.breaches.Lookup(entry.Host()) {
+			breachDate, err := breach.Date()
+			if err != nil {
+				continue
+			}
+			if entry.PasswordDate().Before(breachDate) {
+				breached = append(breached, BreachedEntry{Entry: entry, Breach: breach})
+			}
+		}
+	}
+
+	return breached
+}
+
+// FindInsecureURIs finds entries whose website uses plain HTTP
+func (s *SecurityService) FindInsecureURIs(vault *entity.Vault) []*entity.Entry {
+	var insecure []*entity.Entry
+
+	for _, entry := range vault.Entries {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(entry.URI)), "http://") {
+			insecure = append(insecure, entry)
+		}
+	}
+
+	return insecure
+}
+
+// FindMissingTwoFactor finds entries for sites that support TOTP but have no secret stored
+// Results are sorted by entry name
+func (s *SecurityService) FindMissingTwoFactor(vault *entity.Vault) []MissingTwoFactorEntry {
+	var missing []MissingTwoFactorEntry
+
+	if s.twoFactor.Len() == 0 {
+		return missing
+	}
+
+	for _, entry := range vault.Entries {
+		if entry.HasTOTP() {
+			continue
+		}
+		if site, ok := s.twoFactor.Lookup(entry.Host()); ok {
+			missing = append(missing, MissingTwoFactorEntry{Entry: entry, Site: site})
+		}
+	}
+
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].Entry.Name < missing[j].Entry.Name
+	})
+
+	return missing
+}
+
+// Audit runs all checks against the vault and returns the findings
+// Findings dismissed in the vault are included with Dismissed set
+func (s *SecurityService) Audit(vault *entity.Vault) []Finding {
+	var findings []Finding
+
+	for _, entry := range s.FindWeakPasswords(vault) {
+		findings = append(findings, Finding{
+			Kind:   FindingWeakPassword,
+			Entry:  entry,
+			Detail: "Password strength is " + s.CheckPasswordStrength(entry.Password).String(),
+		})
+	}
+
+	for _, entries := range s.FindDuplicatePasswords(vault) {
+		for _, entry := range entries {
+			findings = append(findings, Finding{
+				Kind:   FindingReusedPassword,
+				Entry:  entry,
+				Detail: fmt.Sprintf("Same password used by %d entries", len(entries)),
+			})
+		}
+	}
+
+	for _, breached := range s.FindBreachedEntries(vault) {
+		findings = append(findings, Finding{
+			Kind:  FindingBreachedSite,
+			Entry: breached.Entry,
+			Detail: fmt.Sprintf("%s was breached on %s, password last changed %s",
+				breached.Breach.Title, breached.Breach.BreachDate, breached.Entry.PasswordDate().Format("2006-01-02")),
+			subject: breached.Breach.Name,
+		})
+	}
+
+	for _, entry := range s.FindInsecureURIs(vault) {
+		findings = append(findings, Finding{
+			Kind:   FindingInsecureURI,
+			Entry:  entry,
+			Detail: "Website uses http:// instead of https://",
+		})
+	}
+
+	for _, missing := range s.FindMissingTwoFactor(vault) {
+		detail := missing.Site.Name + " supports authenticator codes but no TOTP secret is stored"
+		if missing.Site.Documentation != "" {
+			detail += " (" + missing.Site.Documentation + ")"
+		}
+		findings = append(findings, Finding{
+			Kind:   FindingMissingTwoFactor,
+			Entry:  missing.Entry,
+			Detail: detail,
+		})
+	}
+
+	for i := range findings {
+		findings[i].Dismissed = vault.IsFindingDismissed(findings[i].ID())
+	}
+
+	// Keep output stable across runs since duplicate groups come from a map
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].Entry.Name < findings[j].Entry.Name
+	})
+
+	return findings
+}
+
 // CalculateSecurityScore calculates an overall security score for the vault
+// The score is the share of entries without any active (non-dismissed) finding
 func (s *SecurityService) CalculateSecurityScore(vault *entity.Vault) float64 {
 	if len(vault.Entries) == 0 {
 		return 100.0
 	}
 
-	weakCount := len(s.FindWeakPasswords(vault))
-	duplicateGroups := len(s.FindDuplicatePasswords(vault))
+	flagged := make(map[string]bool)
+	for _, finding := range s.Audit(vault) {
+		if !finding.Dismissed {
+			flagged[finding.Entry.ID] = true
+		}
+	}
 
-	totalIssues := weakCount + duplicateGroups
-	score := 100.0 - (float64(totalIssues) / float64(len(vault.Entries)) * 100.0)
+	score := 100.0 - (float64(len(flagged)) / float64(len(vault.Entries)) * 100.0)
 
 	if score < 0 {
 		score = 0
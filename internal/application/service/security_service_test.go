@@ -0,0 +1,196 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/storage"
+)
+
+const twoFactorDirectoryJSON = `[
+	["GitHub", {"domain": "github.com", "tfa": ["totp", "u2f"], "documentation": "https://docs.github.com/2fa"}],
+	["Google", {"domain": "google.com", "tfa": ["totp"], "additional-domains": ["youtube.com"]}],
+	["SMS Only", {"domain": "sms.example", "tfa": ["sms"]}]
+]`
+
+func TestFindInsecureURIs(t *testing.T) {
+	vault := entity.NewVault()
+	for _, uri := range []string{"http://router.local", " HTTP://Example.com", "https://secure.example", "example.org", ""} {
+		vault.AddEntry(&entity.Entry{Name: uri, URI: uri})
+	}
+
+	insecure := NewSecurityService().FindInsecureURIs(vault)
+	if len(insecure) != 2 || insecure[0].URI != "http://router.local" || insecure[1].URI != " HTTP://Example.com" {
+		t.Errorf("FindInsecureURIs() = %v, want the two http:// entries", insecure)
+	}
+}
+
+func TestLoadTwoFactorDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "all.json")
+	if err := os.WriteFile(path, []byte(twoFactorDirectoryJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	directory, err := LoadTwoFactorDirectory(path)
+	if err != nil {
+		t.Fatalf("LoadTwoFactorDirectory() error = %v", err)
+	}
+	if directory.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", directory.Len())
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"github.com", "GitHub"},
+		{"gist.github.com", "GitHub"},
+		{"www.youtube.com", "Google"},
+		{"sms.example", ""},
+		{"example.com", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		site, ok := directory.Lookup(tt.host)
+		if ok != (tt.want != "") || site.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.host, site.Name, ok, tt.want)
+		}
+	}
+
+	missing, err := LoadTwoFactorDirectory(filepath.Join(dir, "missing.json"))
+	if err != nil || missing.Len() != 0 {
+		t.Errorf("LoadTwoFactorDirectory(missing) = %d sites, %v, want an empty directory", missing.Len(), err)
+	}
+
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`[["GitHub", ["totp"]]]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTwoFactorDirectory(malformed); err == nil {
+		t.Error("LoadTwoFactorDirectory(malformed) succeeded, want an error")
+	}
+}
+
+func TestFindMissingTwoFactor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "all.json")
+	if err := os.WriteFile(path, []byte(twoFactorDirectoryJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	service := NewSecurityService()
+	if err := service.LoadTwoFactorDirectory(path); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := entity.NewVault()
+	vault.AddEntry(&entity.Entry{Name: "YouTube", URI: "https://www.youtube.com"})
+	vault.AddEntry(&entity.Entry{Name: "GitHub", URI: "https://github.com"})
+	vault.AddEntry(&entity.Entry{Name: "Google", URI: "https://accounts.google.com", TOTP: &entity.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP"}})
+	vault.AddEntry(&entity.Entry{Name: "Bank", URI: "https://bank.example"})
+
+	// Run several times since the result used to come from a map
+	for i := 0; i < 5; i++ {
+		missing := service.FindMissingTwoFactor(vault)
+		if len(missing) != 2 || missing[0].Entry.Name != "GitHub" || missing[1].Entry.Name != "YouTube" {
+			t.Fatalf("FindMissingTwoFactor() = %v, want GitHub then YouTube", missing)
+		}
+		if missing[0].Site.Documentation != "https://docs.github.com/2fa" {
+			t.Errorf("Site = %+v, want the GitHub listing", missing[0].Site)
+		}
+	}
+}
+
+func TestVaultDismissFinding(t *testing.T) {
+	vault := entity.NewVault()
+	if vault.IsFindingDismissed("weak:1") {
+		t.Fatal("empty vault reports a dismissed finding")
+	}
+
+	vault.DismissFinding("weak:1")
+	if !vault.IsFindingDismissed("weak:1") || vault.IsFindingDismissed("weak:2") {
+		t.Errorf("DismissedFindings = %v, want only weak:1", vault.DismissedFindings)
+	}
+
+	vault.RestoreFinding("weak:2")
+	vault.RestoreFinding("weak:1")
+	if vault.IsFindingDismissed("weak:1") {
+		t.Error("RestoreFinding() left the finding dismissed")
+	}
+}
+
+func TestFindingIDs(t *testing.T) {
+	vault := entity.NewVault()
+	router := &entity.Entry{ID: "router", Name: "Router", URI: "http://router.local"}
+	shop := &entity.Entry{ID: "shop", Name: "Shop", URI: "http://shop.example", Password: "p"}
+	vault.AddEntry(router)
+	vault.AddEntry(shop)
+
+	service := NewSecurityService()
+	service.SetBreachCatalog(NewBreachCatalog([]Breach{
+		{Name: "ShopLeak", Title: "Shop", Domain: "shop.example", BreachDate: "2999-01-01"},
+	}))
+
+	ids := func() map[string]bool {
+		found := make(map[string]bool)
+		for _, finding := range service.Audit(vault) {
+			found[finding.ID()] = true
+		}
+		return found
+	}
+
+	first := ids()
+	for _, want := range []string{"insecure_uri:router", "insecure_uri:shop", "breached:shop:ShopLeak"} {
+		if !first[want] {
+			t.Errorf("Audit() IDs = %v, missing %q", first, want)
+		}
+	}
+
+	// IDs depend on the entry and subject only, not on vault order or details
+	vault.Entries = []*entity.Entry{shop, router}
+	shop.Name = "Renamed shop"
+	second := ids()
+	if len(first) != len(second) {
+		t.Fatalf("Audit() IDs changed from %v to %v", first, second)
+	}
+	for id := range first {
+		if !second[id] {
+			t.Errorf("Audit() IDs changed from %v to %v", first, second)
+		}
+	}
+}
+
+func TestFindingDismissalSurvivesSave(t *testing.T) {
+	repo := storage.NewFileRepository(filepath.Join(t.TempDir(), "vault.enc"))
+	vaultService := NewVaultService(repo)
+	vault, err := vaultService.CreateVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("CreateVault() error = %v", err)
+	}
+	vault.AddEntry(entity.NewEntry(entity.EntryTypeLogin, "Router"))
+	vault.Entries[0].URI = "http://router.local"
+
+	service := NewSecurityService()
+	findings := service.Audit(vault)
+	if len(findings) != 1 || findings[0].Dismissed {
+		t.Fatalf("Audit() = %v, want one active finding", findings)
+	}
+	vault.DismissFinding(findings[0].ID())
+
+	if err := vaultService.SaveVault(vault); err != nil {
+		t.Fatalf("SaveVault() error = %v", err)
+	}
+	loaded, err := vaultService.UnlockVault(agentTestPassword)
+	if err != nil {
+		t.Fatalf("UnlockVault() error = %v", err)
+	}
+
+	findings = service.Audit(loaded)
+	if len(findings) != 1 || !findings[0].Dismissed {
+		t.Errorf("Audit() after reload = %v, want the finding dismissed", findings)
+	}
+	if score := service.CalculateSecurityScore(loaded); score != 100 {
+		t.Errorf("CalculateSecurityScore() = %v, want 100 with the finding dismissed", score)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// Errors returned to SSH clients
+var (
+	ErrSSHSignRefused    = errors.New("signature refused")
+	ErrSSHKeyNotFound    = errors.New("key not found")
+	ErrSSHAgentReadOnly  = errors.New("keys are managed in passmanager")
+	ErrSSHAgentNoSigners = errors.New("private keys are not handed out")
+)
+
+// SSHSignRequest describes a signature to be confirmed before the agent makes it
+type SSHSignRequest struct {
+	EntryName   string
+	Comment     string
+	Fingerprint string
+	Peer        agent.Peer
+}
+
+// SSHAgentService serves the SSH keys of the unlocked vault to ssh clients
+// Every signature is confirmed first, keys cannot be added, removed or exported
+type SSHAgentService struct {
+	confirm func(SSHSignRequest) bool
+
+	mu   sync.RWMutex
+	keys []sshAgentKey
+}
+
+// sshAgentKey is a copy of an SSH key entry, so signing never reads the vault
+type sshAgentKey struct {
+	name   string
+	key    entity.SSHKey
+	public ssh.PublicKey
+}
+
+// NewSSHAgentService creates an SSH agent without keys; confirm is called for every
+// signature and the signature is refused unless it returns true
+func NewSSHAgentService(confirm func(SSHSignRequest) bool) *SSHAgentService {
+	return &SSHAgentService{confirm: confirm}
+}
+
+// SetKeys replaces the served keys with those of entries, skipping invalid ones
+func (s *SSHAgentService) SetKeys(entries []*entity.Entry) {
+	var keys []sshAgentKey
+	for _, entry := range entries {
+		if !entry.HasSSHKey() {
+			continue
+		}
+		public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(entry.SSHKey.PublicKey))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, sshAgentKey{name: entry.Name, key: *entry.SSHKey, public: public})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// Clear stops serving keys, e.g. when the vault is locked
+func (s *SSHAgentService) Clear() {
+	s.SetKeys(nil)
+}
+
+// ForPeer returns the agent answering one client
+func (s *SSHAgentService) ForPeer(peer agent.Peer) sshagent.Agent {
+	return &sshAgentSession{service: s, peer: peer}
+}
+
+// find returns the served key with the given public key
+func (s *SSHAgentService) find(public ssh.PublicKey) (sshAgentKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob := public.Marshal()
+	for _, key := range s.keys {
+		if bytes.Equal(key.public.Marshal(), blob) {
+			return key, true
+		}
+	}
+	return sshAgentKey{}, false
+}
+
+// sshAgentSession implements the ssh-agent protocol for one client
+type sshAgentSession struct {
+	service *SSHAgentService
+	peer    agent.Peer
+}
+
+// List returns the public keys, commented with the entry name unless they have a comment
+func (a *sshAgentSession) List() ([]*sshagent.Key, error) {
+	a.service.mu.RLock()
+	defer a.service.mu.RUnlock()
+
+	keys := make([]*sshagent.Key, 0, len(a.service.keys))
+	for _, key := range a.service.keys {
+		comment := key.key.Comment
+		if comment == "" {
+			comment = key.name
+		}
+		keys = append(keys, &sshagent.Key{
+			Format:  key.public.Type(),
+			Blob:    key.public.Marshal(),
+			Comment: comment,
+		})
+	}
+	return keys, nil
+}
+
+func (a *sshAgentSession) Sign(public ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(public, data, 0)
+}
+
+// SignWithFlags signs after the user confirmed, honoring requests for SHA-2 RSA signatures
+func (a *sshAgentSession) SignWithFlags(public ssh.PublicKey, data []byte, flags sshagent.SignatureFlags) (*ssh.Signature, error) {
+	key, ok := a.service.find(public)
+	if !ok {
+		return nil, ErrSSHKeyNotFound
+	}
+
+	request := SSHSignRequest{
+		EntryName:   key.name,
+		Comment:     key.key.Comment,
+		Fingerprint: key.key.Fingerprint,
+		Peer:        a.peer,
+	}
+	if a.service.confirm == nil || !a.service.confirm(request) {
+		return nil, ErrSSHSignRefused
+	}
+
+	signer, err := SSHKeySigner(&key.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && public.Type() == ssh.KeyAlgoRSA {
+		switch {
+		case flags&sshagent.SignatureFlagRsaSha512 != 0:
+			return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+		case flags&sshagent.SignatureFlagRsaSha256 != 0:
+			return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA256)
+		}
+	}
+	return signer.Sign(rand.Reader, data)
+}
+
+func (a *sshAgentSession) Add(sshagent.AddedKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+func (a *sshAgentSession) Remove(ssh.PublicKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+func (a *sshAgentSession) RemoveAll() error {
+	return ErrSSHAgentReadOnly
+}
+
+func (a *sshAgentSession) Lock([]byte) error {
+	return ErrSSHAgentReadOnly
+}
+
+func (a *sshAgentSession) Unlock([]byte) error {
+	return ErrSSHAgentReadOnly
+}
+
+// Signers is not offered, it would sign without confirmation
+func (a *sshAgentSession) Signers() ([]ssh.Signer, error) {
+	return nil, ErrSSHAgentNoSigners
+}
+
+func (a *sshAgentSession) Extension(string, []byte) ([]byte, error) {
+	return nil, sshagent.ErrExtensionUnsupported
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"golang.org/x/crypto/ssh"
+)
+
+// GenerateSSHKey generates an Ed25519 key pair in OpenSSH format, encrypting the
+// private key when a passphrase is given
+func GenerateSSHKey(comment, passphrase string) (*entity.SSHKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(private, comment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseSSHKey(string(pem.EncodeToMemory(block)), passphrase, comment)
+}
+
+// ParseSSHKey checks a PEM private key, e.g. pasted from ~/.ssh/id_ed25519, and
+// derives its public key and fingerprint
+func ParseSSHKey(privateKey, passphrase, comment string) (*entity.SSHKey, error) {
+	key := &entity.SSHKey{
+		PrivateKey: strings.TrimSpace(privateKey) + "\n",
+		Comment:    strings.TrimSpace(comment),
+		Passphrase: passphrase,
+	}
+	signer, err := SSHKeySigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	public := signer.PublicKey()
+	key.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public)))
+	if key.Comment != "" {
+		key.PublicKey += " " + key.Comment
+	}
+	key.Fingerprint = ssh.FingerprintSHA256(public)
+	return key, nil
+}
+
+// SSHKeySigner decrypts the private key of an entry for signing
+func SSHKeySigner(key *entity.SSHKey) (ssh.Signer, error) {
+	var raw any
+	var err error
+	if key.Passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(key.PrivateKey), []byte(key.Passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(key.PrivateKey))
+	}
+
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, errors.New("the private key is encrypted, enter its passphrase")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return ssh.NewSignerFromKey(raw)
+}
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/qrcode"
 	"github.com/hambosto/passmanager/pkg/totp"
 )
 
@@ -14,18 +18,190 @@ func NewTOTPService() *TOTPService {
 	return &TOTPService{}
 }
 
-// GenerateCode generates a TOTP code from a secret
-func (s *TOTPService) GenerateCode(secret string) (string, time.Duration, error) {
-	config := totp.DefaultConfig(secret)
-	return config.GenerateCode()
+// GenerateCode generates the current code for an entry's TOTP configuration
+func (s *TOTPService) GenerateCode(cfg *entity.TOTPConfig) (string, time.Duration, error) {
+	if cfg == nil {
+		return "", 0, fmt.Errorf("entry has no TOTP configuration")
+	}
+	return s.ToGeneratorConfig(cfg).GenerateCode()
 }
 
-// ValidateCode validates a TOTP code
-func (s *TOTPService) ValidateCode(secret, code string) bool {
-	config := totp.DefaultConfig(secret)
+// NextHOTPCode generates the next code of a counter-based configuration
+// The counter is advanced in cfg, so the entry must be saved afterwards
+func (s *TOTPService) NextHOTPCode(cfg *entity.TOTPConfig) (string, error) {
+	if cfg == nil || !cfg.IsHOTP() {
+		return "", fmt.Errorf("entry has no HOTP configuration")
+	}
+
+	config := s.ToGeneratorConfig(cfg)
+	code, err := config.NextCode()
+	if err != nil {
+		return "", err
+	}
+	cfg.Counter = config.Counter
+	return code, nil
+}
+
+// ValidateCode validates a code against an entry's TOTP configuration
+// For HOTP a match within the look-ahead window resynchronizes the stored counter
+func (s *TOTPService) ValidateCode(cfg *entity.TOTPConfig, code string) bool {
+	if cfg == nil {
+		return false
+	}
+
+	config := s.ToGeneratorConfig(cfg)
+	if config.IsHOTP() {
+		next, ok := config.ValidateCounter(code, totp.DefaultLookAhead)
+		if ok {
+			cfg.Counter = next
+		}
+		return ok
+	}
 	return config.Validate(code)
 }
 
+// ParseInput parses an otpauth:// URI or a bare Base32 secret into an entry configuration
+func (s *TOTPService) ParseInput(input string) (*entity.TOTPConfig, error) {
+	config, err := totp.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return s.ToEntryConfig(config), nil
+}
+
+// ParseImage decodes a QR code screenshot (PNG or JPEG) holding an otpauth:// URI
+// Google Authenticator exports are accepted when they contain a single account
+func (s *TOTPService) ParseImage(path string) (*entity.TOTPConfig, error) {
+	text, err := s.ReadQRImage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if totp.IsMigrationURI(text) {
+		batch, err := totp.ParseMigrationURI(text)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch.Configs) != 1 || batch.BatchSize != 1 {
+			return nil, fmt.Errorf("QR code is a Google Authenticator export with several accounts, use passmanager import-qr")
+		}
+		return s.ToEntryConfig(batch.Configs[0]), nil
+	}
+
+	config, err := totp.ParseURI(text)
+	if err != nil {
+		return nil, fmt.Errorf("QR code does not contain a valid otpauth:// URI: %w", err)
+	}
+	return s.ToEntryConfig(config), nil
+}
+
+// ReadQRImage returns the text of the QR code in a PNG or JPEG file
+func (s *TOTPService) ReadQRImage(path string) (string, error) {
+	return qrcode.DecodeFile(strings.TrimSpace(path))
+}
+
+// ImportResult summarizes the outcome of importing one-time passwords
+type ImportResult struct {
+	Created []*entity.Entry // new entries
+	Updated []*entity.Entry // existing entries that gained a one-time password
+	Skipped int             // accounts already present in the vault
+}
+
+// ImportAccounts adds one entry per account to the vault
+// Accounts whose secret is already stored are skipped, and a login with the
+// same name and username but no one-time password receives it instead of a new entry
+func (s *TOTPService) ImportAccounts(vault *entity.Vault, configs []*totp.Config) ImportResult {
+	var result ImportResult
+
+	secrets := make(map[string]bool)
+	for _, entry := range vault.Entries {
+		if entry.HasTOTP() {
+			secrets[importKey(entry.TOTP.Secret)] = true
+		}
+	}
+
+	for _, config := range configs {
+		key := importKey(config.Secret)
+		if secrets[key] {
+			result.Skipped++
+			continue
+		}
+		secrets[key] = true
+
+		cfg := s.ToEntryConfig(config)
+		if entry := findImportTarget(vault, cfg); entry != nil {
+			entry.TOTP = cfg
+			entry.TOTPSecret = ""
+			entry.Update()
+			result.Updated = append(result.Updated, entry)
+			continue
+		}
+
+		entry := entity.NewEntry(entity.EntryTypeLogin, "")
+		s.ApplyLabel(entry, cfg)
+		if entry.Name == "" {
+			entry.Name = "Imported account"
+		}
+		entry.TOTP = cfg
+		vault.AddEntry(entry)
+		result.Created = append(result.Created, entry)
+	}
+
+	if len(result.Created)+len(result.Updated) > 0 {
+		vault.Update()
+	}
+	return result
+}
+
+// importKey normalizes a secret for duplicate detection
+func importKey(secret string) string {
+	return totp.NormalizeSecret(secret)
+}
+
+// findImportTarget returns a login without one-time password matching the issuer and account of cfg
+func findImportTarget(vault *entity.Vault, cfg *entity.TOTPConfig) *entity.Entry {
+	if cfg.Issuer == "" {
+		return nil
+	}
+	for _, entry := range vault.Entries {
+		if entry.Type == entity.EntryTypeLogin && !entry.HasTOTP() &&
+			strings.EqualFold(entry.Name, cfg.Issuer) && strings.EqualFold(entry.Username, cfg.Account) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// ApplyLabel fills empty entry name and username from the issuer and account of cfg
+func (s *TOTPService) ApplyLabel(entry *entity.Entry, cfg *entity.TOTPConfig) {
+	if entry.Name == "" {
+		entry.Name = cfg.Issuer
+		if entry.Name == "" {
+			entry.Name = cfg.Account
+		}
+	}
+	if entry.Username == "" {
+		entry.Username = cfg.Account
+	}
+}
+
+// FormatInput renders an entry configuration for editing
+// Configurations using only default parameters are shown as the bare secret
+func (s *TOTPService) FormatInput(cfg *entity.TOTPConfig) string {
+	if cfg == nil {
+		return ""
+	}
+
+	config := s.ToGeneratorConfig(cfg)
+	defaults := totp.DefaultConfig(config.Secret)
+	if config.Type == defaults.Type && config.Algorithm == defaults.Algorithm && config.Digits == defaults.Digits &&
+		config.Period == defaults.Period && config.Encoder == "" && config.Issuer == "" && config.Account == "" {
+		return config.Secret
+	}
+	// The editor saves what it shows, so the mOTP PIN must survive the round trip
+	return config.ToURIWithPIN()
+}
+
 // ParseURI parses an otpauth:// URI
 func (s *TOTPService) ParseURI(uri string) (*totp.Config, error) {
 	return totp.ParseURI(uri)
@@ -34,6 +210,77 @@ func (s *TOTPService) ParseURI(uri string) (*totp.Config, error) {
 // GenerateURI generates an otpauth:// URI
 func (s *TOTPService) GenerateURI(issuer, accountName, secret string) string {
 	config := totp.DefaultConfig(secret)
-	// ToURI returns the URI without parameters
+	config.Issuer = issuer
+	config.Account = accountName
 	return config.ToURI()
 }
+
+// ToGeneratorConfig converts an entry configuration into a code generator configuration
+func (s *TOTPService) ToGeneratorConfig(cfg *entity.TOTPConfig) *totp.Config {
+	config := totp.DefaultConfig(cfg.Secret)
+	if cfg.Type != "" {
+		config.Type = cfg.Type
+	}
+	config.Counter = cfg.Counter
+	if cfg.Algorithm != "" {
+		config.Algorithm = cfg.Algorithm
+	}
+	if cfg.Digits > 0 {
+		config.Digits = cfg.Digits
+	}
+	if cfg.Period > 0 {
+		config.Period = time.Duration(cfg.Period) * time.Second
+	}
+	config.Issuer = cfg.Issuer
+	config.Account = cfg.Account
+	config.Encoder = cfg.Encoder
+	config.PIN = cfg.PIN
+	return config
+}
+
+// ToEntryConfig converts a code generator configuration into an entry configuration
+func (s *TOTPService) ToEntryConfig(config *totp.Config) *entity.TOTPConfig {
+	cfg := &entity.TOTPConfig{
+		Type:      config.Type,
+		Secret:    config.Secret,
+		Algorithm: config.Algorithm,
+		Digits:    config.Digits,
+		Issuer:    config.Issuer,
+		Account:   config.Account,
+		Encoder:   config.Encoder,
+		PIN:       config.PIN,
+	}
+	if config.IsHOTP() {
+		cfg.Counter = config.Counter
+	} else {
+		cfg.Period = int(config.Period / time.Second)
+	}
+	return cfg
+}
+
+// MigrateVault converts raw TOTP secrets stored by older vaults into structured configurations
+// Returns the number of migrated entries; secrets that fail to parse are left untouched
+func (s *TOTPService) MigrateVault(vault *entity.Vault) int {
+	migrated := 0
+
+	for _, entry := range vault.Entries {
+		if entry.TOTPSecret == "" || entry.HasTOTP() {
+			continue
+		}
+
+		cfg, err := s.ParseInput(entry.TOTPSecret)
+		if err != nil {
+			continue
+		}
+
+		entry.TOTP = cfg
+		entry.TOTPSecret = ""
+		migrated++
+	}
+
+	if migrated > 0 {
+		vault.Update()
+	}
+
+	return migrated
+}
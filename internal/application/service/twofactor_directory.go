@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TwoFactorSite describes a site listed in the 2fa.directory catalogue
+type TwoFactorSite struct {
+	Name              string   `json:"-"`
+	Domain            string   `json:"domain"`
+	URL               string   `json:"url"`
+	Methods           []string `json:"tfa"`
+	Documentation     string   `json:"documentation"`
+	AdditionalDomains []string `json:"additional-domains"`
+}
+
+// SupportsTOTP reports whether the site accepts authenticator app codes
+func (s TwoFactorSite) SupportsTOTP() bool {
+	for _, method := range s.Methods {
+		if method == "totp" {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorDirectory is a local index of sites that support TOTP
+type TwoFactorDirectory struct {
+	byDomain map[string]TwoFactorSite
+}
+
+// NewTwoFactorDirectory builds a directory from a list of sites
+// Sites that do not offer TOTP are skipped
+func NewTwoFactorDirectory(sites []TwoFactorSite) *TwoFactorDirectory {
+	directory := &TwoFactorDirectory{byDomain: make(map[string]TwoFactorSite)}
+
+	for _, site := range sites {
+		if !site.SupportsTOTP() {
+			continue
+		}
+		for _, domain := range append([]string{site.Domain}, site.AdditionalDomains...) {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if domain != "" {
+				directory.byDomain[domain] = site
+			}
+		}
+	}
+
+	return directory
+}
+
+// LoadTwoFactorDirectory loads a 2fa.directory v3 JSON file (all.json or totp.json)
+// The file is an array of [name, details] pairs. A missing file yields an empty directory
+func LoadTwoFactorDirectory(path string) (*TwoFactorDirectory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTwoFactorDirectory(nil), nil
+		}
+		return nil, fmt.Errorf("failed to read 2FA directory: %w", err)
+	}
+
+	var pairs [][2]json.RawMessage
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse 2FA directory: %w", err)
+	}
+
+	sites := make([]TwoFactorSite, 0, len(pairs))
+	for _, pair := range pairs {
+		var site TwoFactorSite
+		if err := json.Unmarshal(pair[0], &site.Name); err != nil {
+			return nil, fmt.Errorf("failed to parse 2FA directory: %w", err)
+		}
+		if err := json.Unmarshal(pair[1], &site); err != nil {
+			return nil, fmt.Errorf("failed to parse 2FA directory entry %q: %w", site.Name, err)
+		}
+		sites = append(sites, site)
+	}
+
+	return NewTwoFactorDirectory(sites), nil
+}
+
+// Lookup returns the site listed for a host or one of its parent domains
+func (d *TwoFactorDirectory) Lookup(host string) (TwoFactorSite, bool) {
+	if d == nil || host == "" {
+		return TwoFactorSite{}, false
+	}
+
+	for _, domain := range parentDomains(host) {
+		if site, ok := d.byDomain[domain]; ok {
+			return site, true
+		}
+	}
+	return TwoFactorSite{}, false
+}
+
+// Len returns the number of domains in the directory
+func (d *TwoFactorDirectory) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.byDomain)
+}
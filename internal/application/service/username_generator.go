@@ -0,0 +1,278 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// UsernameMode selects how usernames are generated
+type UsernameMode string
+
+const (
+	// UsernameRandomWords joins random words, e.g. "quantum_walrus42"
+	UsernameRandomWords UsernameMode = "words"
+	// UsernamePlusAddress tags an email address, e.g. "me+github@example.com"
+	UsernamePlusAddress UsernameMode = "plus"
+	// UsernameCatchAll invents an address on a catch-all domain, e.g. "github.x7k2q9@example.com"
+	UsernameCatchAll UsernameMode = "catchall"
+	// UsernameUUID returns a random UUID
+	UsernameUUID UsernameMode = "uuid"
+)
+
+// UsernameModes lists all modes in display order
+var UsernameModes = []UsernameMode{UsernameRandomWords, UsernamePlusAddress, UsernameCatchAll, UsernameUUID}
+
+// Label returns a display name for the mode
+func (m UsernameMode) Label() string {
+	switch m {
+	case UsernameRandomWords:
+		return "Random Words"
+	case UsernamePlusAddress:
+		return "Plus Address"
+	case UsernameCatchAll:
+		return "Catch-all Address"
+	case UsernameUUID:
+		return "UUID"
+	default:
+		return string(m)
+	}
+}
+
+// ParseUsernameMode parses a mode name
+func ParseUsernameMode(name string) (UsernameMode, error) {
+	for _, mode := range UsernameModes {
+		if strings.EqualFold(name, string(mode)) {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown username mode %q (use words, plus, catchall or uuid)", name)
+}
+
+// Default templates of the email modes
+const (
+	DefaultPlusTemplate     = "{site}"
+	DefaultCatchAllTemplate = "{site}.{random}"
+)
+
+// usernameAlphabet is used for the {random} placeholder
+const usernameAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+// UsernameConfig contains configuration for username generation
+type UsernameConfig struct {
+	Mode UsernameMode
+
+	// Random words options
+	WordCount     int
+	Separator     string
+	IncludeNumber bool
+
+	// Email is the address tagged by plus-addressing
+	Email string
+	// Domain receives all mail sent to it in catch-all mode
+	Domain string
+	// Template builds the plus tag or catch-all local part from the placeholders
+	// {site}, {word} and {random}; empty uses the mode's default
+	Template string
+
+	// Site is the site name used by {site}, usually derived with SiteName
+	Site string
+}
+
+// DefaultUsernameConfig returns a sensible default username configuration
+func DefaultUsernameConfig() UsernameConfig {
+	return UsernameConfig{
+		Mode:          UsernameRandomWords,
+		WordCount:     2,
+		Separator:     "_",
+		IncludeNumber: true,
+	}
+}
+
+// GenerateUsername generates a username based on the configuration
+func GenerateUsername(config UsernameConfig) (string, error) {
+	switch config.Mode {
+	case UsernameRandomWords, "":
+		return randomWordsUsername(config)
+
+	case UsernamePlusAddress:
+		local, domain, ok := strings.Cut(strings.TrimSpace(config.Email), "@")
+		if !ok || local == "" || domain == "" {
+			return "", fmt.Errorf("plus addressing needs an email address")
+		}
+		// Drop an existing tag so addresses are always tagged once
+		local, _, _ = strings.Cut(local, "+")
+
+		tag, err := expandUsernameTemplate(config, DefaultPlusTemplate)
+		if err != nil {
+			return "", err
+		}
+		return local + "+" + tag + "@" + domain, nil
+
+	case UsernameCatchAll:
+		domain := strings.TrimPrefix(strings.TrimSpace(config.Domain), "@")
+		if domain == "" {
+			return "", fmt.Errorf("catch-all addresses need a domain")
+		}
+		local, err := expandUsernameTemplate(config, DefaultCatchAllTemplate)
+		if err != nil {
+			return "", err
+		}
+		return local + "@" + domain, nil
+
+	case UsernameUUID:
+		return randomUUID()
+
+	default:
+		return "", fmt.Errorf("unknown username mode %q", config.Mode)
+	}
+}
+
+// randomWordsUsername joins random lower-case words
+func randomWordsUsername(config UsernameConfig) (string, error) {
+	if config.WordCount < 1 {
+		return "", fmt.Errorf("word count must be at least 1")
+	}
+
+	words := make([]string, config.WordCount)
+	for i := range words {
+		word, err := randomWord()
+		if err != nil {
+			return "", err
+		}
+		words[i] = word
+	}
+
+	username := strings.Join(words, config.Separator)
+	if config.IncludeNumber {
+		num, err := rand.Int(rand.Reader, big.NewInt(100))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		username += fmt.Sprintf("%02d", num.Int64())
+	}
+	return username, nil
+}
+
+// expandUsernameTemplate fills the placeholders of the configured template
+// Without a site name, {site} falls back to a random word
+func expandUsernameTemplate(config UsernameConfig, fallback string) (string, error) {
+	template := strings.TrimSpace(config.Template)
+	if template == "" {
+		template = fallback
+	}
+
+	site := sanitizeLocalPart(config.Site)
+	if site == "" && strings.Contains(template, "{site}") {
+		word, err := randomWord()
+		if err != nil {
+			return "", err
+		}
+		site = word
+	}
+
+	// Each occurrence of a random placeholder gets its own value
+	expanded := template
+	for _, placeholder := range []struct {
+		name  string
+		value func() (string, error)
+	}{
+		{"{site}", func() (string, error) { return site, nil }},
+		{"{word}", randomWord},
+		{"{random}", func() (string, error) { return randomString(usernameAlphabet, 6) }},
+	} {
+		for strings.Contains(expanded, placeholder.name) {
+			value, err := placeholder.value()
+			if err != nil {
+				return "", err
+			}
+			expanded = strings.Replace(expanded, placeholder.name, value, 1)
+		}
+	}
+
+	expanded = sanitizeLocalPart(expanded)
+	if expanded == "" {
+		return "", fmt.Errorf("template %q produces an empty address", template)
+	}
+	return expanded, nil
+}
+
+// sanitizeLocalPart lower-cases s and keeps only characters safe in an email local part
+func sanitizeLocalPart(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || strings.ContainsRune("._-", c) {
+			b.WriteRune(c)
+		}
+	}
+	return strings.Trim(b.String(), ".")
+}
+
+// randomWord returns a random word of the built-in list
+func randomWord() (string, error) {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(effWordList))))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random word: %w", err)
+	}
+	return effWordList[index.Int64()], nil
+}
+
+// randomString returns length random characters from chars
+func randomString(chars string, length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random string: %w", err)
+		}
+		b[i] = chars[index.Int64()]
+	}
+	return string(b), nil
+}
+
+// randomUUID returns a random version 4 UUID
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SiteName returns a short site name for a URI, e.g. "github" for https://www.github.com/login
+// Subdomains and the public suffix are dropped; unparseable input yields an empty name
+func SiteName(uri string) string {
+	uri = strings.TrimSpace(uri)
+	if uri == "" {
+		return ""
+	}
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(u.Hostname(), ".")), ".")
+	if len(labels) == 1 {
+		return labels[0]
+	}
+
+	// Skip second-level public suffixes such as co.uk or com.au
+	name := labels[len(labels)-2]
+	if len(labels) > 2 && len(labels[len(labels)-1]) == 2 && secondLevelSuffixes[name] {
+		name = labels[len(labels)-3]
+	}
+	return name
+}
+
+// secondLevelSuffixes are labels that form public suffixes under country domains
+var secondLevelSuffixes = map[string]bool{
+	"co": true, "com": true, "net": true, "org": true, "ac": true, "gov": true, "edu": true, "ne": true, "or": true,
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSiteName(t *testing.T) {
+	tests := map[string]string{
+		"https://www.github.com/login": "github",
+		"accounts.google.com":          "google",
+		"https://shop.example.co.uk/":  "example",
+		"http://localhost:8080":        "localhost",
+		"https://bbc.co.uk":            "bbc",
+		"":                             "",
+	}
+	for uri, want := range tests {
+		if got := SiteName(uri); got != want {
+			t.Errorf("SiteName(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestGenerateUsername(t *testing.T) {
+	words := DefaultUsernameConfig()
+	username, err := GenerateUsername(words)
+	if err != nil {
+		t.Fatalf("GenerateUsername(words) error = %v", err)
+	}
+	if !regexp.MustCompile(`^[a-z]+_[a-z]+[0-9]{2}$`).MatchString(username) {
+		t.Errorf("GenerateUsername(words) = %q", username)
+	}
+
+	plus := UsernameConfig{Mode: UsernamePlusAddress, Email: "me+old@example.com", Site: "GitHub"}
+	if username, err := GenerateUsername(plus); err != nil || username != "me+github@example.com" {
+		t.Errorf("GenerateUsername(plus) = %q, %v", username, err)
+	}
+
+	catchAll := UsernameConfig{Mode: UsernameCatchAll, Domain: "@example.net", Site: "github"}
+	username, err = GenerateUsername(catchAll)
+	if err != nil {
+		t.Fatalf("GenerateUsername(catchall) error = %v", err)
+	}
+	if !regexp.MustCompile(`^github\.[a-z2-9]{6}@example\.net$`).MatchString(username) {
+		t.Errorf("GenerateUsername(catchall) = %q", username)
+	}
+
+	uuid, err := GenerateUsername(UsernameConfig{Mode: UsernameUUID})
+	if err != nil {
+		t.Fatalf("GenerateUsername(uuid) error = %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("GenerateUsername(uuid) = %q", uuid)
+	}
+}
+
+func TestUsernameTemplate(t *testing.T) {
+	config := UsernameConfig{Mode: UsernameCatchAll, Domain: "example.net", Template: "{word}.{word}.{site}"}
+	username, err := GenerateUsername(config)
+	if err != nil {
+		t.Fatalf("GenerateUsername() error = %v", err)
+	}
+	local, _, _ := strings.Cut(username, "@")
+	if parts := strings.Split(local, "."); len(parts) != 3 || strings.ContainsAny(local, "{}") {
+		t.Errorf("GenerateUsername() = %q, want three expanded parts", username)
+	}
+
+	// Without a site the {site} placeholder still yields a word
+	config.Template = ""
+	if username, err := GenerateUsername(config); err != nil || strings.HasPrefix(username, ".") {
+		t.Errorf("GenerateUsername() without site = %q, %v", username, err)
+	}
+
+	config.Template = "!!!"
+	if _, err := GenerateUsername(config); err == nil {
+		t.Error("GenerateUsername() with an empty expansion expected error")
+	}
+}
+
+func TestGenerateUsernameErrors(t *testing.T) {
+	for _, config := range []UsernameConfig{
+		{Mode: UsernamePlusAddress},
+		{Mode: UsernamePlusAddress, Email: "not-an-address"},
+		{Mode: UsernameCatchAll},
+		{Mode: "nickname"},
+		{Mode: UsernameRandomWords, WordCount: 0},
+	} {
+		if _, err := GenerateUsername(config); err == nil {
+			t.Errorf("GenerateUsername(%+v) expected error", config)
+		}
+	}
+
+	if _, err := ParseUsernameMode("CatchAll"); err != nil {
+		t.Errorf("ParseUsernameMode(CatchAll) error = %v", err)
+	}
+}
@@ -43,7 +43,8 @@ func (s *VaultServiceImpl) CreateVault(masterPassword string) (*entity.Vault, er
 
 // UnlockVault unlocks an existing vault with the given master password
 func (s *VaultServiceImpl) UnlockVault(masterPassword string) (*entity.Vault, error) {
-	params, err := crypto.DefaultKeyDerivationParams()
+	// Derive the key with the parameters the vault was created with
+	params, err := s.repository.LoadParams()
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +60,17 @@ func (s *VaultServiceImpl) UnlockVault(masterPassword string) (*entity.Vault, er
 	return vault, nil
 }
 
+// OpenVault opens the vault with an already derived key, such as one held by the agent
+func (s *VaultServiceImpl) OpenVault(key []byte) (*entity.Vault, error) {
+	vault, err := s.repository.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.masterKey = key
+	return vault, nil
+}
+
 // SaveVault saves the current vault
 func (s *VaultServiceImpl) SaveVault(vault *entity.Vault) error { // Load KDF params and save vault
 	if s.masterKey == nil {
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuiltinWordList is the name of the compiled-in word list
+const BuiltinWordList = "builtin"
+
+// wordListExtensions are the file extensions recognized as word lists
+var wordListExtensions = []string{".txt", ".wordlist"}
+
+// WordList is a set of words passphrases are drawn from
+type WordList struct {
+	Name  string
+	Words []string
+}
+
+// DefaultWordList returns the compiled-in word list
+func DefaultWordList() *WordList {
+	return &WordList{Name: BuiltinWordList, Words: effWordList}
+}
+
+// Entropy returns the bits of entropy contributed by one word
+func (l *WordList) Entropy() float64 {
+	if l == nil || len(l.Words) == 0 {
+		return 0
+	}
+	return math.Log2(float64(len(l.Words)))
+}
+
+// ParseWordList reads a word list in diceware format ("11111<TAB>word") or one word per line
+// When numbered lines are present all other lines are ignored, which skips the PGP
+// armor around signed lists. Empty lines, comments and duplicate words are dropped
+func ParseWordList(name string, r io.Reader) (*WordList, error) {
+	var numbered, plain []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && isDiceIndex(fields[0]):
+			numbered = append(numbered, fields[1])
+		case len(fields) == 1:
+			plain = append(plain, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word list %s: %w", name, err)
+	}
+
+	words := plain
+	if len(numbered) > 0 {
+		words = numbered
+	}
+
+	seen := make(map[string]bool, len(words))
+	list := &WordList{Name: name}
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			list.Words = append(list.Words, word)
+		}
+	}
+
+	if len(list.Words) < 2 {
+		return nil, fmt.Errorf("word list %s has fewer than 2 words", name)
+	}
+	return list, nil
+}
+
+// isDiceIndex reports whether s is a diceware index made of the digits 1 to 6
+func isDiceIndex(s string) bool {
+	for _, c := range s {
+		if c < '1' || c > '6' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// LoadWordList reads a word list file, naming it after the file
+func LoadWordList(path string) (*WordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(path)
+	return ParseWordList(strings.TrimSuffix(name, filepath.Ext(name)), file)
+}
+
+// FindWordLists returns the names of the word lists stored in dir, sorted
+// A missing directory has no word lists
+func FindWordLists(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read word list directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || !isWordListExtension(ext) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ext))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// isWordListExtension reports whether ext marks a word list file
+func isWordListExtension(ext string) bool {
+	for _, known := range wordListExtensions {
+		if strings.EqualFold(ext, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveWordList returns the word list selected by name
+// An empty name or "builtin" selects the compiled-in list, a path is read as is,
+// and any other name is looked up in dir with one of the known extensions
+func ResolveWordList(dir, name string) (*WordList, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == BuiltinWordList {
+		return DefaultWordList(), nil
+	}
+
+	if strings.ContainsRune(name, filepath.Separator) || isWordListExtension(filepath.Ext(name)) {
+		path := name
+		if !filepath.IsAbs(path) && !strings.ContainsRune(name, filepath.Separator) {
+			path = filepath.Join(dir, name)
+		}
+		return LoadWordList(path)
+	}
+
+	for _, ext := range wordListExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return LoadWordList(path)
+		}
+	}
+	return nil, fmt.Errorf("word list %q not found in %s", name, dir)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+const signedDiceware = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA1
+
+11111	a
+11112	a&p
+11113	aaa
+11114	a
+-----BEGIN PGP SIGNATURE-----
+iQCVAwUBOd
+-----END PGP SIGNATURE-----
+`
+
+func TestParseWordList(t *testing.T) {
+	list, err := ParseWordList("signed", strings.NewReader(signedDiceware))
+	if err != nil {
+		t.Fatalf("ParseWordList() error = %v", err)
+	}
+	if got := strings.Join(list.Words, ","); got != "a,a&p,aaa" {
+		t.Errorf("Words = %s, want only the numbered words without duplicates", got)
+	}
+
+	plain, err := ParseWordList("plain", strings.NewReader("\ufeff# German\nHaus\n\nBaum\nÜberfluss\n"))
+	if err != nil {
+		t.Fatalf("ParseWordList() error = %v", err)
+	}
+	if got := strings.Join(plain.Words, ","); got != "Haus,Baum,Überfluss" {
+		t.Errorf("Words = %s", got)
+	}
+	if plain.Entropy() != math.Log2(3) {
+		t.Errorf("Entropy() = %f, want log2(3)", plain.Entropy())
+	}
+
+	if _, err := ParseWordList("tiny", strings.NewReader("one\none\n")); err == nil {
+		t.Error("ParseWordList() with a single word expected error")
+	}
+}
+
+func TestResolveWordList(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "eff_short.txt"), []byte("1111\tacid\n1112\tacorn\n1113\tacre\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "deutsch.wordlist"), []byte("haus\nbaum\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := FindWordLists(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ","); got != "deutsch,eff_short" {
+		t.Errorf("FindWordLists() = %s", got)
+	}
+	if names, err := FindWordLists(filepath.Join(dir, "missing")); err != nil || names != nil {
+		t.Errorf("FindWordLists(missing) = %v, %v", names, err)
+	}
+
+	for _, name := range []string{"eff_short", "eff_short.txt", filepath.Join(dir, "eff_short.txt")} {
+		list, err := ResolveWordList(dir, name)
+		if err != nil {
+			t.Fatalf("ResolveWordList(%q) error = %v", name, err)
+		}
+		if list.Name != "eff_short" || len(list.Words) != 3 {
+			t.Errorf("ResolveWordList(%q) = %s with %d words", name, list.Name, len(list.Words))
+		}
+	}
+
+	if list, err := ResolveWordList(dir, ""); err != nil || list.Name != BuiltinWordList {
+		t.Errorf("ResolveWordList(\"\") = %v, %v, want the built-in list", list, err)
+	}
+	if _, err := ResolveWordList(dir, "klingon"); err == nil {
+		t.Error("ResolveWordList(klingon) expected error")
+	}
+}
+
+func TestGeneratePassphraseWordList(t *testing.T) {
+	config := PassphraseConfig{
+		WordCount:    5,
+		Separator:    " ",
+		Capitalize:   true,
+		WordList:     &WordList{Name: "test", Words: []string{"éclair", "naïve"}},
+		InsertDigit:  true,
+		InsertSymbol: true,
+	}
+
+	for i := 0; i < 50; i++ {
+		passphrase, err := GeneratePassphrase(config)
+		if err != nil {
+			t.Fatalf("GeneratePassphrase() error = %v", err)
+		}
+
+		words := strings.Split(passphrase, " ")
+		if len(words) != 5 {
+			t.Fatalf("%q has %d words, want 5", passphrase, len(words))
+		}
+		for _, word := range words {
+			if !strings.ContainsAny(word, numbers) || !strings.ContainsAny(word, passphraseSymbols) {
+				t.Fatalf("word %q lacks the inserted digit or symbol", word)
+			}
+			letters := strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) {
+					return r
+				}
+				return -1
+			}, word)
+			if letters != "Éclair" && letters != "Naïve" {
+				t.Fatalf("word %q is not a title-cased list word", word)
+			}
+		}
+	}
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	list := &WordList{Name: "dice", Words: make([]string, 7776)}
+	config := PassphraseConfig{WordCount: 6, WordList: list}
+	if got := PassphraseEntropy(config); math.Abs(got-6*math.Log2(7776)) > 1e-9 {
+		t.Errorf("PassphraseEntropy() = %f, want %f", got, 6*math.Log2(7776))
+	}
+
+	config.InsertDigit = true
+	config.IncludeNumber = true
+	want := 6*(math.Log2(7776)+math.Log2(10)) + math.Log2(100)
+	if got := PassphraseEntropy(config); math.Abs(got-want) > 1e-9 {
+		t.Errorf("PassphraseEntropy() = %f, want %f", got, want)
+	}
+}
@@ -1,6 +1,10 @@
 package entity
 
-import "time"
+import (
+	"net/url"
+	"strings"
+	"time"
+)
 
 // EntryType represents the type of vault entry
 type EntryType int
@@ -10,6 +14,7 @@ const (
 	EntryTypeSecureNote
 	EntryTypeCard
 	EntryTypeIdentity
+	EntryTypeSSHKey
 )
 
 // String returns the string representation of the entry type
@@ -23,6 +28,8 @@ func (et EntryType) String() string {
 		return "Card"
 	case EntryTypeIdentity:
 		return "Identity"
+	case EntryTypeSSHKey:
+		return "SSH Key"
 	default:
 		return "Unknown"
 	}
@@ -37,7 +44,7 @@ type Entry struct {
 	Password     string            `json:"password,omitempty"`
 	URI          string            `json:"uri,omitempty"`
 	Notes        string            `json:"notes,omitempty"`
-	TOTPSecret   string            `json:"totp_secret,omitempty"`
+	TOTP         *TOTPConfig       `json:"totp,omitempty"`
 	CustomFields map[string]string `json:"custom_fields,omitempty"`
 	FolderID     string            `json:"folder_id,omitempty"`
 	IsFavorite   bool              `json:"is_favorite"`
@@ -45,6 +52,75 @@ type Entry struct {
 	CreatedAt    time.Time         `json:"created_at"`
 	UpdatedAt    time.Time         `json:"updated_at"`
 	AccessedAt   time.Time         `json:"accessed_at,omitempty"`
+
+	// PasswordUpdatedAt records when the password was last changed
+	PasswordUpdatedAt time.Time `json:"password_updated_at,omitempty"`
+
+	// PasswordRules holds the site's password requirements in Apple's passwordrules syntax
+	PasswordRules string `json:"password_rules,omitempty"`
+
+	// AutoTypeSequence overrides the keystrokes typed by auto-type, e.g. "{USERNAME}{ENTER}{DELAY 500}{PASSWORD}{ENTER}"
+	AutoTypeSequence string `json:"auto_type_sequence,omitempty"`
+
+	// CreatedByGitHelper marks logins the git credential helper created, the only ones
+	// it removes when git rejects them
+	CreatedByGitHelper bool `json:"created_by_git_helper,omitempty"`
+
+	// GitRejectedAt records when git rejected the password, the helper skips the login
+	// until the password changes
+	GitRejectedAt time.Time `json:"git_rejected_at,omitempty"`
+
+	// SSHKey holds the key pair of SSH key entries
+	SSHKey *SSHKey `json:"ssh_key,omitempty"`
+
+	// TOTPSecret holds the raw secret or URI stored by older vaults
+	// It is migrated into TOTP on unlock and only kept if it could not be parsed
+	TOTPSecret string `json:"totp_secret,omitempty"`
+}
+
+// DefaultAutoTypeSequence types the username and password into a login form
+const DefaultAutoTypeSequence = "{USERNAME}{TAB}{PASSWORD}{ENTER}"
+
+// TOTPConfig holds the one-time password parameters of an entry
+type TOTPConfig struct {
+	Type      string `json:"type,omitempty"` // "totp" (default) or "hotp"
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period,omitempty"`  // seconds, TOTP only
+	Counter   uint64 `json:"counter,omitempty"` // next counter value, HOTP only
+	Issuer    string `json:"issuer,omitempty"`
+	Account   string `json:"account,omitempty"`
+	Encoder   string `json:"encoder,omitempty"` // "" (RFC digits), "steam" or "motp"
+	PIN       string `json:"pin,omitempty"`     // mOTP PIN
+}
+
+// IsHOTP returns true for counter-based one-time passwords
+func (c *TOTPConfig) IsHOTP() bool {
+	return c.Type == "hotp"
+}
+
+// Label returns a short display name for the kind of one-time password
+func (c *TOTPConfig) Label() string {
+	switch {
+	case c.Encoder == "steam":
+		return "Steam Guard"
+	case c.Encoder == "motp":
+		return "mOTP"
+	case c.IsHOTP():
+		return "HOTP"
+	default:
+		return "TOTP"
+	}
+}
+
+// SSHKey holds an SSH key pair served by the SSH agent
+type SSHKey struct {
+	PrivateKey  string `json:"private_key"`          // PEM, OpenSSH format for generated keys
+	PublicKey   string `json:"public_key"`           // authorized_keys line
+	Fingerprint string `json:"fingerprint"`          // SHA256:...
+	Comment     string `json:"comment,omitempty"`    // shown by ssh-add -l
+	Passphrase  string `json:"passphrase,omitempty"` // decrypts PrivateKey if it is encrypted
 }
 
 // Card represents credit card information
@@ -89,6 +165,16 @@ func NewEntry(entryType EntryType, name string) *Entry {
 	}
 }
 
+// HasTOTP returns true if the entry has a usable TOTP configuration
+func (e *Entry) HasTOTP() bool {
+	return e.TOTP != nil && e.TOTP.Secret != ""
+}
+
+// HasSSHKey returns true if the entry holds an SSH private key
+func (e *Entry) HasSSHKey() bool {
+	return e.SSHKey != nil && e.SSHKey.PrivateKey != ""
+}
+
 // UpdateAccessTime updates the last accessed timestamp
 func (e *Entry) UpdateAccessTime() {
 	e.AccessedAt = time.Now()
@@ -98,3 +184,38 @@ func (e *Entry) UpdateAccessTime() {
 func (e *Entry) Update() {
 	e.UpdatedAt = time.Now()
 }
+
+// SetPassword sets the password and records the change time if it differs
+func (e *Entry) SetPassword(password string) {
+	if password == e.Password {
+		return
+	}
+	e.Password = password
+	e.PasswordUpdatedAt = time.Now()
+}
+
+// PasswordDate returns when the password was last set
+// Entries saved before password changes were tracked fall back to the creation time
+func (e *Entry) PasswordDate() time.Time {
+	if !e.PasswordUpdatedAt.IsZero() {
+		return e.PasswordUpdatedAt
+	}
+	return e.CreatedAt
+}
+
+// Host returns the lower-cased host name of the entry URI, or "" if it has none
+func (e *Entry) Host() string {
+	uri := strings.TrimSpace(e.URI)
+	if uri == "" {
+		return ""
+	}
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
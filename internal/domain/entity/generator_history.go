@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// GeneratedValue is a generated password kept in the vault so it survives failed sign-ups
+type GeneratedValue struct {
+	Value     string    `json:"value"`
+	Mode      string    `json:"mode"` // generator mode, e.g. "random" or "passphrase"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddGeneratedValue records a generated value as the newest history item
+// An earlier copy of the same value is moved to the front, and only the newest
+// limit values are kept; a limit below one disables the history
+// It reports whether the vault changed
+func (v *Vault) AddGeneratedValue(value, mode string, limit int) bool {
+	if limit < 1 {
+		if len(v.GeneratorHistory) == 0 {
+			return false
+		}
+		v.ClearGeneratorHistory()
+		return true
+	}
+
+	history := []GeneratedValue{{Value: value, Mode: mode, CreatedAt: time.Now()}}
+	for _, item := range v.GeneratorHistory {
+		if item.Value != value && len(history) < limit {
+			history = append(history, item)
+		}
+	}
+	v.GeneratorHistory = history
+	v.UpdatedAt = time.Now()
+	return true
+}
+
+// ClearGeneratorHistory removes all generated values
+func (v *Vault) ClearGeneratorHistory() {
+	if len(v.GeneratorHistory) == 0 {
+		return
+	}
+	v.GeneratorHistory = nil
+	v.UpdatedAt = time.Now()
+}
@@ -0,0 +1,72 @@
+package entity
+
+import "testing"
+
+// historyValues returns the values of the vault's generator history, newest first
+func historyValues(v *Vault) []string {
+	values := make([]string, len(v.GeneratorHistory))
+	for i, item := range v.GeneratorHistory {
+		values[i] = item.Value
+	}
+	return values
+}
+
+func TestAddGeneratedValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		add    []string
+		limit  int
+		want   []string
+		change bool // whether the last AddGeneratedValue call changed the vault
+	}{
+		{"newest first", []string{"a", "b", "c"}, 5, []string{"c", "b", "a"}, true},
+		{"repeat moves to front", []string{"a", "b", "c", "a"}, 5, []string{"a", "c", "b"}, true},
+		{"repeat of newest", []string{"a", "b", "b"}, 5, []string{"b", "a"}, true},
+		{"limit keeps newest", []string{"a", "b", "c", "d"}, 2, []string{"d", "c"}, true},
+		{"limit of one", []string{"a", "b"}, 1, []string{"b"}, true},
+		{"disabled", []string{"a", "b"}, 0, []string{}, false},
+		{"negative limit", []string{"a"}, -1, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := NewVault()
+			var changed bool
+			for _, value := range tt.add {
+				changed = vault.AddGeneratedValue(value, "random", tt.limit)
+			}
+
+			got := historyValues(vault)
+			if len(got) != len(tt.want) {
+				t.Fatalf("history = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("history = %v, want %v", got, tt.want)
+				}
+			}
+			if changed != tt.change {
+				t.Errorf("AddGeneratedValue() = %v, want %v", changed, tt.change)
+			}
+		})
+	}
+}
+
+func TestAddGeneratedValueClearsWhenDisabled(t *testing.T) {
+	vault := NewVault()
+	vault.AddGeneratedValue("a", "random", 5)
+	vault.AddGeneratedValue("b", "passphrase", 5)
+	if vault.GeneratorHistory[0].Mode != "passphrase" {
+		t.Errorf("Mode = %q, want passphrase", vault.GeneratorHistory[0].Mode)
+	}
+
+	if !vault.AddGeneratedValue("c", "random", 0) {
+		t.Error("AddGeneratedValue(limit 0) did not report clearing the history")
+	}
+	if len(vault.GeneratorHistory) != 0 {
+		t.Errorf("history = %v, want it cleared", historyValues(vault))
+	}
+	if vault.AddGeneratedValue("d", "random", 0) {
+		t.Error("AddGeneratedValue(limit 0) reported a change to an empty history")
+	}
+}
@@ -10,6 +10,16 @@ type Vault struct {
 	Settings  Settings  `json:"settings"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// DismissedFindings maps security audit finding IDs to when they were dismissed
+	DismissedFindings map[string]time.Time `json:"dismissed_findings,omitempty"`
+
+	// GeneratorHistory holds recently generated passwords, newest first
+	GeneratorHistory []GeneratedValue `json:"generator_history,omitempty"`
+
+	// Revision counts the saves of the vault, a copy loaded before the last save is
+	// refused so it cannot overwrite changes made by another process
+	Revision uint64 `json:"revision,omitempty"`
 }
 
 // Settings represents vault-specific settings
@@ -67,6 +77,18 @@ func (v *Vault) RemoveEntry(id string) bool {
 	return false
 }
 
+// PutEntry replaces the entry with the same ID, or adds entry when there is none
+func (v *Vault) PutEntry(entry *Entry) {
+	for i, existing := range v.Entries {
+		if existing.ID == entry.ID {
+			v.Entries[i] = entry
+			v.UpdatedAt = time.Now()
+			return
+		}
+	}
+	v.AddEntry(entry)
+}
+
 // FindEntry finds an entry by ID
 func (v *Vault) FindEntry(id string) *Entry {
 	for _, entry := range v.Entries {
@@ -109,3 +131,27 @@ func (v *Vault) FindFolder(id string) *Folder {
 func (v *Vault) Update() {
 	v.UpdatedAt = time.Now()
 }
+
+// DismissFinding marks a security audit finding as dismissed
+func (v *Vault) DismissFinding(id string) {
+	if v.DismissedFindings == nil {
+		v.DismissedFindings = make(map[string]time.Time)
+	}
+	v.DismissedFindings[id] = time.Now()
+	v.UpdatedAt = time.Now()
+}
+
+// RestoreFinding clears a previous dismissal
+func (v *Vault) RestoreFinding(id string) {
+	if _, ok := v.DismissedFindings[id]; !ok {
+		return
+	}
+	delete(v.DismissedFindings, id)
+	v.UpdatedAt = time.Now()
+}
+
+// IsFindingDismissed reports whether a security audit finding was dismissed
+func (v *Vault) IsFindingDismissed(id string) bool {
+	_, ok := v.DismissedFindings[id]
+	return ok
+}
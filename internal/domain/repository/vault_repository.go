@@ -1,13 +1,19 @@
 package repository
 
 import (
+	"errors"
+
 	"github.com/hambosto/passmanager/internal/domain/entity"
 	"github.com/hambosto/passmanager/internal/infrastructure/crypto"
 )
 
+// ErrVaultChanged is returned when saving a vault another process saved since it was loaded
+var ErrVaultChanged = errors.New("vault was changed by another process")
+
 // VaultRepository defines the interface for vault persistence
 type VaultRepository interface {
-	// Save saves the vault with the given key and KDF params
+	// Save saves the vault with the given key and KDF params and advances its revision
+	// It returns ErrVaultChanged when the stored vault has another revision
 	Save(vault *entity.Vault, key []byte, kdfParams *crypto.KeyDerivationParams) error
 
 	// Load loads the vault using the given key
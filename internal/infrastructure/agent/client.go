@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+)
+
+// requestTimeout bounds a request, unlocking derives the key and takes the longest
+const requestTimeout = 30 * time.Second
+
+// Client sends requests to an agent
+type Client struct {
+	call func(req Request) (Response, error)
+}
+
+// NewClient creates a client of the agent listening on path
+func NewClient(path string) *Client {
+	return &Client{call: func(req Request) (Response, error) {
+		return send(path, req)
+	}}
+}
+
+// NewLocalClient creates a client passing requests to handler in this process
+// Commands use it to work the same way when no agent is running
+func NewLocalClient(handler Handler) *Client {
+	return &Client{call: func(req Request) (Response, error) {
+		return handler.Handle(req), nil
+	}}
+}
+
+// send delivers one request over a new connection
+func send(path string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	// Check who serves the socket before sending anything, e.g. the master password
+	if err := checkServer(conn.(*net.UnixConn)); err != nil {
+		return Response{}, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("no answer from agent: %w", err)
+	}
+	return resp, nil
+}
+
+// checkServer makes sure the process serving a connection runs as the current user
+func checkServer(conn *net.UnixConn) error {
+	peer, err := peerCredentials(conn)
+	if err != nil {
+		return fmt.Errorf("failed to check agent: %w", err)
+	}
+	if peer.UID != os.Getuid() {
+		return fmt.Errorf("%w (uid %d)", ErrUntrusted, peer.UID)
+	}
+	return nil
+}
+
+// do sends a request and returns the response or the error it carries
+func (c *Client) do(req Request) (Response, error) {
+	resp, err := c.call(req)
+	if err != nil {
+		return resp, err
+	}
+	return resp, resp.Err()
+}
+
+// Status returns whether the agent is unlocked and the time until it locks itself
+func (c *Client) Status() (unlocked bool, lockIn time.Duration, err error) {
+	resp, err := c.do(Request{Op: OpStatus})
+	return resp.Unlocked, time.Duration(resp.LockIn) * time.Second, err
+}
+
+// Unlock unlocks the agent with the master password
+func (c *Client) Unlock(password string) error {
+	_, err := c.do(Request{Op: OpUnlock, Password: password})
+	return err
+}
+
+// Lock makes the agent forget the vault key
+func (c *Client) Lock() error {
+	_, err := c.do(Request{Op: OpLock})
+	return err
+}
+
+// Stop locks the agent and makes it exit
+func (c *Client) Stop() error {
+	_, err := c.do(Request{Op: OpStop})
+	return err
+}
+
+// List returns the entries of the vault without their secrets
+func (c *Client) List() ([]EntrySummary, error) {
+	resp, err := c.do(Request{Op: OpList})
+	return resp.Entries, err
+}
+
+// Get returns the entry named name
+func (c *Client) Get(name string) (*entity.Entry, error) {
+	resp, err := c.do(Request{Op: OpGet, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entry, nil
+}
+
+// Add stores a new entry in the vault
+func (c *Client) Add(entry *entity.Entry) error {
+	_, err := c.do(Request{Op: OpAdd, Entry: entry})
+	return err
+}
+
+// TOTP returns the current one-time password of the entry named name and how long
+// it stays valid, counter-based codes are used up and valid until the next one
+func (c *Client) TOTP(name string) (string, time.Duration, error) {
+	resp, err := c.do(Request{Op: OpTOTP, Name: name})
+	return resp.OTP, time.Duration(resp.ExpiresIn) * time.Second, err
+}
+
+// Resolve returns the value a pm:// secret reference points to
+func (c *Client) Resolve(ref string) (string, error) {
+	resp, err := c.do(Request{Op: OpResolve, Name: ref})
+	return resp.Output, err
+}
+
+// GitCredential answers an operation of git's credential helper protocol, returning
+// the answer for git and notes for the user
+func (c *Client) GitCredential(action, input string) (string, []string, error) {
+	resp, err := c.do(Request{Op: OpGitCredential, Action: action, Input: input})
+	return resp.Output, resp.Messages, err
+}
+
+// DockerCredential answers an action of docker's credential helper protocol
+func (c *Client) DockerCredential(action, input string) (string, error) {
+	resp, err := c.do(Request{Op: OpDockerCredential, Action: action, Input: input})
+	return resp.Output, err
+}
+
+// Load returns the decrypted vault and the path of the file the agent serves
+func (c *Client) Load() (*entity.Vault, string, error) {
+	resp, err := c.do(Request{Op: OpLoad})
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.Vault == nil {
+		return nil, "", errors.New("agent sent no vault")
+	}
+	return resp.Vault, resp.Path, nil
+}
+
+// Save writes vault with the agent's key and advances its revision, a vault changed
+// since it was loaded is refused with repository.ErrVaultChanged
+func (c *Client) Save(vault *entity.Vault) error {
+	resp, err := c.do(Request{Op: OpSave, Vault: vault})
+	if err != nil {
+		return err
+	}
+	vault.Revision = resp.Revision
+	return nil
+}
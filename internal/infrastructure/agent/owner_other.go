@@ -0,0 +1,10 @@
+//go:build !unix
+
+package agent
+
+import "os"
+
+// fileOwner reports that file owners are unknown on this platform
+func fileOwner(os.FileInfo) (int, bool) {
+	return 0, false
+}
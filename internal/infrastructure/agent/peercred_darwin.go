@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentialsSupported reports whether clients can be verified
+func peerCredentialsSupported() error {
+	return nil
+}
+
+// peerCredentials returns the process connected to conn
+func peerCredentials(conn *net.UnixConn) (Peer, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return Peer{}, err
+	}
+	var cred *unix.Xucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil {
+		return Peer{}, err
+	}
+	if credErr != nil {
+		return Peer{}, credErr
+	}
+	peer := Peer{UID: int(cred.Uid)}
+	_ = raw.Control(func(fd uintptr) {
+		if pid, err := unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID); err == nil {
+			peer.PID = pid
+		}
+	})
+	return peer, nil
+}
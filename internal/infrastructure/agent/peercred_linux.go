@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// peerCredentialsSupported reports whether clients can be verified
+func peerCredentialsSupported() error {
+	return nil
+}
+
+// peerCredentials returns the process connected to conn
+func peerCredentials(conn *net.UnixConn) (Peer, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return Peer{}, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return Peer{}, err
+	}
+	if credErr != nil {
+		return Peer{}, credErr
+	}
+
+	peer := Peer{UID: int(cred.Uid), PID: int(cred.Pid)}
+	if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", cred.Pid)); err == nil {
+		peer.Command = strings.TrimSpace(string(comm))
+	}
+	return peer, nil
+}
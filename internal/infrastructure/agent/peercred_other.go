@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// peerCredentialsSupported reports whether clients can be verified, the agent does
+// not run where it cannot check who connects
+func peerCredentialsSupported() error {
+	return fmt.Errorf("the agent is not supported on %s: peer credentials are unavailable", runtime.GOOS)
+}
+
+func peerCredentials(conn *net.UnixConn) (Peer, error) {
+	return Peer{}, peerCredentialsSupported()
+}
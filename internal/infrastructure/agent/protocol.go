@@ -0,0 +1,153 @@
+// Package agent serves an unlocked vault to local clients over a Unix socket
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/domain/repository"
+)
+
+// SocketEnv overrides the socket path of the agent
+const SocketEnv = "PASSMANAGER_AGENT_SOCK"
+
+// Operations understood by the agent
+const (
+	OpStatus = "status"
+	OpUnlock = "unlock"
+	OpLock   = "lock"
+	OpStop   = "stop"
+	OpList   = "list"
+	OpGet    = "get"
+	OpAdd    = "add"
+	OpTOTP   = "totp"
+	// OpResolve resolves a pm:// secret reference, OpGitCredential and
+	// OpDockerCredential answer a credential helper request
+	OpResolve          = "resolve"
+	OpGitCredential    = "git-credential"
+	OpDockerCredential = "docker-credential"
+	// OpLoad returns the decrypted vault and OpSave writes one back with the agent's
+	// key, so the interface can work on the vault without the key
+	OpLoad = "load"
+	OpSave = "save"
+)
+
+// Error codes of failed requests
+const (
+	CodeLocked    = "locked"
+	CodeForbidden = "forbidden"
+	CodeInvalid   = "invalid"
+	CodeFailed    = "failed"
+	// CodeChanged refuses a save over a vault changed since it was loaded
+	CodeChanged = "changed"
+)
+
+// Errors returned for the matching error codes
+var (
+	ErrLocked    = errors.New("agent is locked")
+	ErrForbidden = errors.New("agent refused the connection")
+	// ErrUntrusted is returned by clients when another user serves the socket
+	ErrUntrusted = errors.New("agent socket is served by another user")
+)
+
+// Request is one request to the agent, sent as a line of JSON
+type Request struct {
+	Op       string        `json:"op"`
+	Password string        `json:"password,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Entry    *entity.Entry `json:"entry,omitempty"`
+	// Action and Input are the operation and the protocol text of a credential helper
+	Action string `json:"action,omitempty"`
+	Input  string `json:"input,omitempty"`
+	// Vault is the vault to save
+	Vault *entity.Vault `json:"vault,omitempty"`
+}
+
+// Response answers a request, Code and Error are set when it failed
+type Response struct {
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	Unlocked bool `json:"unlocked,omitempty"`
+	// LockIn is the number of seconds until the agent locks itself, 0 without auto-lock
+	LockIn int `json:"lock_in,omitempty"`
+
+	Entries []EntrySummary `json:"entries,omitempty"`
+	Entry   *entity.Entry  `json:"entry,omitempty"`
+
+	// OTP is the one-time password of a totp request, valid for ExpiresIn seconds
+	OTP       string `json:"otp,omitempty"`
+	ExpiresIn int    `json:"expires_in,omitempty"`
+
+	// Output is a resolved reference or the answer of a credential helper, Messages
+	// are notes of the helper for the user
+	Output   string   `json:"output,omitempty"`
+	Messages []string `json:"messages,omitempty"`
+
+	// Vault and Path are the loaded vault and its file, Revision is the revision a
+	// saved vault has now
+	Vault    *entity.Vault `json:"vault,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Revision uint64        `json:"revision,omitempty"`
+}
+
+// EntrySummary describes an entry in a listing without its secrets
+type EntrySummary struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// Failure returns a failed response
+func Failure(code string, err error) Response {
+	return Response{Code: code, Error: err.Error()}
+}
+
+// Err returns the error a response carries, nil when it succeeded
+func (r Response) Err() error {
+	switch {
+	case r.Code == "" && r.Error == "":
+		return nil
+	case r.Code == CodeLocked:
+		return ErrLocked
+	case r.Code == CodeForbidden:
+		return ErrForbidden
+	case r.Code == CodeChanged:
+		return repository.ErrVaultChanged
+	}
+	return errors.New(r.Error)
+}
+
+// SSHSocketEnv overrides the socket path of the SSH agent
+const SSHSocketEnv = "PASSMANAGER_SSH_AUTH_SOCK"
+
+// SSHSocketPath returns where the SSH agent listens: $PASSMANAGER_SSH_AUTH_SOCK or
+// ssh-agent.sock next to the agent socket
+func SSHSocketPath() string {
+	if path := os.Getenv(SSHSocketEnv); path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(runtimeSocketPath()), "ssh-agent.sock")
+}
+
+// SocketPath returns where the agent listens: $PASSMANAGER_AGENT_SOCK, a socket in
+// $XDG_RUNTIME_DIR, or one in a per-user directory under the temporary directory
+func SocketPath() string {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path
+	}
+	return runtimeSocketPath()
+}
+
+// runtimeSocketPath returns the default agent socket in the user's runtime directory
+func runtimeSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "passmanager", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("passmanager-%d", os.Getuid()), "agent.sock")
+}
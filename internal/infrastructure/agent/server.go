@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// Handler answers the requests of clients
+type Handler interface {
+	Handle(req Request) Response
+}
+
+// maxRequestSize bounds a request line, a saved vault is the largest request
+const maxRequestSize = 32 << 20
+
+var errRequestTooLarge = fmt.Errorf("request larger than %d bytes", maxRequestSize)
+
+// idleTimeout closes connections that stopped sending requests
+const idleTimeout = time.Minute
+
+// Peer identifies the process at the other end of a connection
+type Peer struct {
+	UID int
+	// PID and Command are 0 and empty where the platform does not report them
+	PID     int
+	Command string
+}
+
+// String describes the peer for confirmation prompts
+func (p Peer) String() string {
+	switch {
+	case p.Command != "":
+		return fmt.Sprintf("%s (pid %d)", p.Command, p.PID)
+	case p.PID != 0:
+		return fmt.Sprintf("pid %d", p.PID)
+	}
+	return "unknown process"
+}
+
+// Server accepts clients on a Unix socket, speaking either the agent's JSON protocol
+// or the ssh-agent protocol
+// Only processes of the user running the server are served
+type Server struct {
+	listener net.Listener
+	path     string
+	serve    func(conn *net.UnixConn, peer Peer)
+	refuse   func(conn *net.UnixConn, err error)
+
+	mu     sync.Mutex
+	closed bool
+	conns  sync.WaitGroup
+}
+
+// Listen serves handler on a socket at path
+func Listen(path string, handler Handler) (*Server, error) {
+	server, err := listen(path)
+	if err != nil {
+		return nil, err
+	}
+	server.serve = func(conn *net.UnixConn, _ Peer) {
+		server.serveRequests(conn, handler)
+	}
+	server.refuse = func(conn *net.UnixConn, err error) {
+		_ = json.NewEncoder(conn).Encode(Failure(CodeForbidden, err))
+	}
+	return server, nil
+}
+
+// ListenSSH serves the ssh-agent protocol on a socket at path, newAgent returns the
+// keyring answering a client so requests can be attributed to it
+func ListenSSH(path string, newAgent func(peer Peer) sshagent.Agent) (*Server, error) {
+	server, err := listen(path)
+	if err != nil {
+		return nil, err
+	}
+	server.serve = func(conn *net.UnixConn, peer Peer) {
+		_ = sshagent.ServeAgent(newAgent(peer), conn)
+	}
+	server.refuse = func(*net.UnixConn, error) {}
+	return server, nil
+}
+
+// listen creates the socket at path, readable only by the current user, creating its
+// directory when missing and refusing one other users can reach
+// A socket left behind by an agent that exited is replaced
+func listen(path string) (*Server, error) {
+	if err := peerCredentialsSupported(); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := checkSocketDir(dir); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &Server{listener: listener, path: path}, nil
+}
+
+// checkSocketDir makes sure the directory of a socket is private to the current user
+// Another user could otherwise have created it first, e.g. under /tmp, and replace
+// the socket with their own
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+	if uid, ok := fileOwner(info); !ok || uid != os.Getuid() {
+		return fmt.Errorf("socket directory %s is not owned by you", dir)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		return fmt.Errorf("socket directory %s has mode %o, it must be 700", dir, perm)
+	}
+	return nil
+}
+
+// Path returns the socket path
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Serve accepts clients until the server is closed
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				s.conns.Wait()
+				return nil
+			}
+			return err
+		}
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			s.serveConn(conn.(*net.UnixConn))
+		}()
+	}
+}
+
+// Close stops accepting clients and removes the socket
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	_ = os.Remove(s.path)
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// serveConn serves a client after checking it runs as the current user
+func (s *Server) serveConn(conn *net.UnixConn) {
+	defer conn.Close()
+
+	peer, err := peerCredentials(conn)
+	if err == nil && peer.UID != os.Getuid() {
+		err = fmt.Errorf("peer runs as uid %d", peer.UID)
+	}
+	if err != nil {
+		s.refuse(conn, err)
+		return
+	}
+	s.serve(conn, peer)
+}
+
+// serveRequests answers the requests of one client, one JSON line each
+func (s *Server) serveRequests(conn *net.UnixConn, handler Handler) {
+	encoder := json.NewEncoder(conn)
+	reader := bufio.NewReaderSize(conn, 64*1024)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		line, err := readLine(reader)
+		if err != nil {
+			if errors.Is(err, errRequestTooLarge) {
+				_ = encoder.Encode(Failure(CodeInvalid, err))
+			}
+			return
+		}
+
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = Failure(CodeInvalid, fmt.Errorf("invalid request: %w", err))
+		} else {
+			resp = handler.Handle(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+
+		if req.Op == OpStop && resp.Err() == nil {
+			_ = s.Close()
+			return
+		}
+	}
+}
+
+// readLine reads a request line of at most maxRequestSize bytes
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxRequestSize {
+			return nil, errRequestTooLarge
+		}
+		if err == nil {
+			return line, nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,232 @@
+//go:build linux || darwin
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// echoHandler answers get requests with an entry of the requested name
+type echoHandler struct{}
+
+func (echoHandler) Handle(req Request) Response {
+	switch req.Op {
+	case OpGet:
+		return Response{Entry: entity.NewEntry(entity.EntryTypeLogin, req.Name)}
+	case OpLock, OpStop:
+		return Response{}
+	}
+	return Failure(CodeLocked, ErrLocked)
+}
+
+// socketPath returns a path in a private temporary directory, which Listen creates
+func socketPath(t *testing.T, name string) string {
+	return filepath.Join(t.TempDir(), "run", name)
+}
+
+// startServer serves echoHandler on a socket in a temporary directory
+func startServer(t *testing.T) *Server {
+	t.Helper()
+	server, err := Listen(socketPath(t, "agent.sock"), echoHandler{})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve() }()
+	t.Cleanup(func() {
+		server.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+	return server
+}
+
+func TestServerRequests(t *testing.T) {
+	server := startServer(t)
+	client := NewClient(server.Path())
+
+	entry, err := client.Get("GitHub")
+	if err != nil || entry.Name != "GitHub" {
+		t.Fatalf("Get() = %+v, %v", entry, err)
+	}
+	if _, err := client.List(); !errors.Is(err, ErrLocked) {
+		t.Errorf("List() error = %v, want ErrLocked", err)
+	}
+
+	info, err := os.Stat(server.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+	if info, _ := os.Stat(filepath.Dir(server.Path())); info.Mode().Perm() != 0o700 {
+		t.Errorf("socket directory mode = %o, want 700", info.Mode().Perm())
+	}
+}
+
+func TestServerConnection(t *testing.T) {
+	server := startServer(t)
+	conn, err := net.Dial("unix", server.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	peer, err := peerCredentials(conn.(*net.UnixConn))
+	if err != nil || peer.UID != os.Getuid() || peer.PID != os.Getpid() {
+		t.Errorf("peerCredentials() = %+v, %v, want this process", peer, err)
+	}
+
+	// Several requests share a connection, broken ones are answered and skipped
+	decoder := json.NewDecoder(conn)
+	for _, line := range []string{"not json\n", `{"op":"get","name":"a"}` + "\n"} {
+		if _, err := conn.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		var resp Response
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("reading the answer to %q: %v", line, err)
+		}
+		if want := strings.HasPrefix(line, "not"); (resp.Code == CodeInvalid) != want {
+			t.Errorf("answer to %q = %+v", line, resp)
+		}
+	}
+
+	// Oversized requests end the connection
+	go conn.Write([]byte(strings.Repeat("x", 2*maxRequestSize)))
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil || resp.Code != CodeInvalid {
+		t.Errorf("answer to an oversized request = %+v, %v", resp, err)
+	}
+}
+
+func TestListenSocketInUse(t *testing.T) {
+	server := startServer(t)
+	if _, err := Listen(server.Path(), echoHandler{}); err == nil {
+		t.Error("Listen() on the socket of a running agent succeeded")
+	}
+
+	// A socket left behind without a listener is replaced
+	stale := socketPath(t, "stale.sock")
+	if err := os.Mkdir(filepath.Dir(stale), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	replaced, err := Listen(stale, echoHandler{})
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket error = %v", err)
+	}
+	replaced.Close()
+}
+
+func TestListenSocketDirectory(t *testing.T) {
+	// A directory other users can enter could hold a socket they replaced
+	shared := filepath.Join(t.TempDir(), "shared")
+	if err := os.Mkdir(shared, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(filepath.Join(shared, "agent.sock"), echoHandler{}); err == nil || !strings.Contains(err.Error(), "700") {
+		t.Errorf("Listen() in a directory with mode 755 error = %v", err)
+	}
+
+	// The directory itself is checked, not where a link points
+	private := filepath.Dir(socketPath(t, "agent.sock"))
+	if err := os.Mkdir(private, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(private, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(filepath.Join(link, "agent.sock"), echoHandler{}); err == nil {
+		t.Error("Listen() in a symlinked directory succeeded")
+	}
+}
+
+func TestClientChecksServer(t *testing.T) {
+	server := startServer(t)
+	conn, err := net.Dial("unix", server.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := checkServer(conn.(*net.UnixConn)); err != nil {
+		t.Errorf("checkServer() of an agent of the current user error = %v", err)
+	}
+}
+
+func TestServerStop(t *testing.T) {
+	server, err := Listen(socketPath(t, "agent.sock"), echoHandler{})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve() }()
+
+	if err := NewClient(server.Path()).Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if _, err := os.Stat(server.Path()); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Stop(): %v", err)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv(SocketEnv, "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := SocketPath(); got != "/run/user/1000/passmanager/agent.sock" {
+		t.Errorf("SocketPath() = %q", got)
+	}
+	t.Setenv(SocketEnv, "/tmp/custom.sock")
+	if got := SocketPath(); got != "/tmp/custom.sock" {
+		t.Errorf("SocketPath() with %s = %q", SocketEnv, got)
+	}
+}
+
+func TestListenSSH(t *testing.T) {
+	peers := make(chan Peer, 1)
+	server, err := ListenSSH(socketPath(t, "ssh-agent.sock"), func(peer Peer) sshagent.Agent {
+		peers <- peer
+		return sshagent.NewKeyring()
+	})
+	if err != nil {
+		t.Fatalf("ListenSSH() error = %v", err)
+	}
+	go server.Serve()
+	defer server.Close()
+
+	conn, err := net.Dial("unix", server.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if keys, err := sshagent.NewClient(conn).List(); err != nil || len(keys) != 0 {
+		t.Errorf("List() = %v, %v", keys, err)
+	}
+	if peer := <-peers; peer.UID != os.Getuid() || peer.PID != os.Getpid() {
+		t.Errorf("peer = %+v, want this process", peer)
+	}
+}
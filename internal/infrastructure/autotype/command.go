@@ -0,0 +1,117 @@
+package autotype
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ydotoolKeycodes maps keys to the Linux input event codes ydotool sends
+var ydotoolKeycodes = map[Key]int{
+	KeyEscape:    1,
+	KeyBackspace: 14,
+	KeyTab:       15,
+	KeyEnter:     28,
+	KeySpace:     57,
+	KeyHome:      102,
+	KeyUp:        103,
+	KeyLeft:      105,
+	KeyRight:     106,
+	KeyEnd:       107,
+	KeyDown:      108,
+	KeyDelete:    111,
+}
+
+// CommandTyper types by running a keyboard automation tool
+// Text is passed on standard input so secrets never show up in process listings
+type CommandTyper struct {
+	name string
+	// typeArgs returns the arguments for typing standard input with a delay in milliseconds
+	typeArgs func(delay string) []string
+	// keyArgs returns the arguments for pressing a key
+	keyArgs func(key Key) ([]string, error)
+}
+
+// xdotoolTyper uses xdotool on X11
+func xdotoolTyper() *CommandTyper {
+	return &CommandTyper{
+		name: TyperXdotool,
+		typeArgs: func(delay string) []string {
+			return []string{"xdotool", "type", "--clearmodifiers", "--delay", delay, "--file", "-"}
+		},
+		keyArgs: func(key Key) ([]string, error) {
+			return []string{"xdotool", "key", "--clearmodifiers", string(key)}, nil
+		},
+	}
+}
+
+// ydotoolTyper uses ydotool, which works on any compositor through uinput
+func ydotoolTyper() *CommandTyper {
+	return &CommandTyper{
+		name: TyperYdotool,
+		typeArgs: func(delay string) []string {
+			return []string{"ydotool", "type", "--key-delay", delay, "--file", "-"}
+		},
+		keyArgs: func(key Key) ([]string, error) {
+			code, ok := ydotoolKeycodes[key]
+			if !ok {
+				return nil, fmt.Errorf("ydotool cannot press %s", key)
+			}
+			return []string{"ydotool", "key", fmt.Sprintf("%d:1", code), fmt.Sprintf("%d:0", code)}, nil
+		},
+	}
+}
+
+// wtypeTyper uses wtype on compositors with the virtual keyboard protocol
+func wtypeTyper() *CommandTyper {
+	return &CommandTyper{
+		name: TyperWtype,
+		typeArgs: func(delay string) []string {
+			return []string{"wtype", "-d", delay, "-"}
+		},
+		keyArgs: func(key Key) ([]string, error) {
+			return []string{"wtype", "-k", string(key)}, nil
+		},
+	}
+}
+
+// Name returns the backend name
+func (t *CommandTyper) Name() string {
+	return t.name
+}
+
+// Type runs the tool with text on its standard input
+func (t *CommandTyper) Type(text string, delay time.Duration) error {
+	if text == "" {
+		return nil
+	}
+	return run(t.typeArgs(strconv.FormatInt(delay.Milliseconds(), 10)), text)
+}
+
+// Press runs the tool to press key
+func (t *CommandTyper) Press(key Key) error {
+	args, err := t.keyArgs(key)
+	if err != nil {
+		return err
+	}
+	return run(args, "")
+}
+
+// run runs a tool, describing failures with its error output
+func run(args []string, stdin string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package autotype
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keystroke is one call recorded by Recorder: typed text or a pressed key
+type Keystroke struct {
+	Text  string
+	Key   Key
+	Delay time.Duration
+}
+
+// Recorder records keystrokes instead of sending them
+// It is meant for tests and for previewing sequences
+type Recorder struct {
+	mu         sync.Mutex
+	keystrokes []Keystroke
+}
+
+// Name returns the backend name
+func (r *Recorder) Name() string {
+	return "recorder"
+}
+
+// Type records typed text
+func (r *Recorder) Type(text string, delay time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keystrokes = append(r.keystrokes, Keystroke{Text: text, Delay: delay})
+	return nil
+}
+
+// Press records a pressed key
+func (r *Recorder) Press(key Key) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keystrokes = append(r.keystrokes, Keystroke{Key: key})
+	return nil
+}
+
+// Keystrokes returns the recorded keystrokes
+func (r *Recorder) Keystrokes() []Keystroke {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Keystroke(nil), r.keystrokes...)
+}
+
+// String renders the recording with keys in braces, e.g. "alice{Tab}secret{Return}"
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, k := range r.Keystrokes() {
+		if k.Key != "" {
+			b.WriteString("{" + string(k.Key) + "}")
+		} else {
+			b.WriteString(k.Text)
+		}
+	}
+	return b.String()
+}
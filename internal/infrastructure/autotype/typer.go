@@ -0,0 +1,96 @@
+package autotype
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Typer names accepted by NewTyper
+const (
+	TyperAuto    = "auto"
+	TyperXdotool = "xdotool"
+	TyperYdotool = "ydotool"
+	TyperWtype   = "wtype"
+)
+
+// TyperNames lists the typing backends in the order they are documented
+var TyperNames = []string{TyperAuto, TyperXdotool, TyperYdotool, TyperWtype}
+
+// ErrNoTyper is returned when no typing tool is available for the session
+var ErrNoTyper = errors.New("no auto-type tool found, install xdotool (X11), wtype or ydotool (Wayland)")
+
+// Key is a key pressed by auto-type, named by its X keysym
+type Key string
+
+// Keys supported in auto-type sequences
+const (
+	KeyTab       Key = "Tab"
+	KeyEnter     Key = "Return"
+	KeySpace     Key = "space"
+	KeyBackspace Key = "BackSpace"
+	KeyDelete    Key = "Delete"
+	KeyEscape    Key = "Escape"
+	KeyUp        Key = "Up"
+	KeyDown      Key = "Down"
+	KeyLeft      Key = "Left"
+	KeyRight     Key = "Right"
+	KeyHome      Key = "Home"
+	KeyEnd       Key = "End"
+)
+
+// Typer sends keystrokes to the focused window
+type Typer interface {
+	// Name returns the backend name as used in the configuration
+	Name() string
+	// Type types text, pausing delay between keystrokes
+	Type(text string, delay time.Duration) error
+	// Press presses and releases a key
+	Press(key Key) error
+}
+
+// NewTyper creates the typing backend with the given name
+// "auto" and the empty name pick a tool for the current session with DetectTyper
+func NewTyper(name string) (Typer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case TyperAuto, "":
+		detected := DetectTyper(os.Getenv, exec.LookPath)
+		if detected == "" {
+			return nil, ErrNoTyper
+		}
+		return NewTyper(detected)
+	case TyperXdotool:
+		return xdotoolTyper(), nil
+	case TyperYdotool:
+		return ydotoolTyper(), nil
+	case TyperWtype:
+		return wtypeTyper(), nil
+	default:
+		return nil, fmt.Errorf("unknown auto-type backend %q (use %s)", name, strings.Join(TyperNames, ", "))
+	}
+}
+
+// DetectTyper returns the name of the installed tool suited to the session, or ""
+// On Wayland wtype is preferred; ydotool works on any compositor but needs its daemon
+func DetectTyper(getenv func(string) string, lookPath func(string) (string, error)) string {
+	installed := func(tool string) bool {
+		_, err := lookPath(tool)
+		return err == nil
+	}
+
+	if getenv("WAYLAND_DISPLAY") != "" {
+		switch {
+		case installed("wtype"):
+			return TyperWtype
+		case installed("ydotool"):
+			return TyperYdotool
+		}
+	}
+	if getenv("DISPLAY") != "" && installed("xdotool") {
+		return TyperXdotool
+	}
+	return ""
+}
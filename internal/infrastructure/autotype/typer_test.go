@@ -0,0 +1,128 @@
+package autotype
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectTyper(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		installed []string
+		want      string
+	}{
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wtype", "ydotool", "xdotool"}, TyperWtype},
+		{"wayland with ydotool", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"ydotool"}, TyperYdotool},
+		{"xwayland only", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"xdotool"}, TyperXdotool},
+		{"x11", map[string]string{"DISPLAY": ":0"}, []string{"xdotool", "wtype"}, TyperXdotool},
+		{"nothing installed", map[string]string{"DISPLAY": ":0"}, nil, ""},
+		{"no session", nil, []string{"xdotool"}, ""},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		lookPath := func(tool string) (string, error) {
+			for _, installed := range tt.installed {
+				if tool == installed {
+					return "/usr/bin/" + tool, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+		if got := DetectTyper(getenv, lookPath); got != tt.want {
+			t.Errorf("DetectTyper(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewTyper(t *testing.T) {
+	for _, name := range TyperNames[1:] {
+		typer, err := NewTyper(name)
+		if err != nil || typer.Name() != name {
+			t.Errorf("NewTyper(%q) = %v, %v", name, typer, err)
+		}
+	}
+	if _, err := NewTyper("telepathy"); err == nil {
+		t.Error("NewTyper(unknown) succeeded, want error")
+	}
+}
+
+// fakeTool installs a script named tool on PATH that logs its arguments and input
+func fakeTool(t *testing.T, tool string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	script := "#!/bin/sh\necho \"args: $*\" >> " + log + "\necho \"stdin: $(cat)\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(dir, tool), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestCommandTyperKeepsSecretsOffTheCommandLine(t *testing.T) {
+	tests := []struct {
+		typer    *CommandTyper
+		typeArgs string
+		keyArgs  string
+	}{
+		{xdotoolTyper(), "type --clearmodifiers --delay 15 --file -", "key --clearmodifiers Return"},
+		{ydotoolTyper(), "type --key-delay 15 --file -", "key 28:1 28:0"},
+		{wtypeTyper(), "-d 15 -", "-k Return"},
+	}
+	for _, tt := range tests {
+		log := fakeTool(t, tt.typer.Name())
+		if err := tt.typer.Type("hunter2", 15*time.Millisecond); err != nil {
+			t.Fatalf("%s: Type() error = %v", tt.typer.Name(), err)
+		}
+		if err := tt.typer.Press(KeyEnter); err != nil {
+			t.Fatalf("%s: Press() error = %v", tt.typer.Name(), err)
+		}
+
+		data, err := os.ReadFile(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "args: " + tt.typeArgs + "\nstdin: hunter2\nargs: " + tt.keyArgs + "\nstdin: \n"
+		if got := string(data); got != want {
+			t.Errorf("%s ran:\n%s\nwant:\n%s", tt.typer.Name(), got, want)
+		}
+		if strings.Contains(tt.typeArgs, "hunter2") {
+			t.Errorf("%s passes the text as an argument", tt.typer.Name())
+		}
+	}
+}
+
+func TestCommandTyperError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'failed to connect to daemon' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "ydotool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	err := ydotoolTyper().Type("text", 0)
+	if err == nil || !strings.Contains(err.Error(), "failed to connect to daemon") {
+		t.Errorf("Type() error = %v, want the tool's message", err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := &Recorder{}
+	_ = recorder.Type("alice", 0)
+	_ = recorder.Press(KeyTab)
+	if got := recorder.String(); got != "alice{Tab}" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	goclipboard "github.com/tiagomelo/go-clipboard/clipboard"
+)
+
+// Backend names accepted by NewBackend
+const (
+	BackendAuto    = "auto"
+	BackendSystem  = "system"
+	BackendOSC52   = "osc52"
+	BackendTmux    = "tmux"
+	BackendWayland = "wayland"
+	BackendX11     = "x11"
+	BackendMemory  = "memory"
+)
+
+// BackendNames lists the backends in the order they are documented
+var BackendNames = []string{BackendAuto, BackendSystem, BackendOSC52, BackendTmux, BackendWayland, BackendX11, BackendMemory}
+
+// ErrPasteUnsupported is returned by backends that cannot read the clipboard
+var ErrPasteUnsupported = errors.New("clipboard backend cannot read the clipboard")
+
+// Backend reads and writes a clipboard
+type Backend interface {
+	// Name returns the backend name as used in the configuration
+	Name() string
+	CopyText(text string) error
+	// PasteText returns the clipboard contents, or ErrPasteUnsupported
+	PasteText() (string, error)
+}
+
+// NewBackend creates the backend with the given name
+// "auto" and the empty name pick a backend for the current session with DetectBackend
+func NewBackend(name string) (Backend, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case BackendAuto, "":
+		return NewBackend(DetectBackend(os.Getenv, exec.LookPath))
+	case BackendSystem:
+		return systemBackend{goclipboard.New()}, nil
+	case BackendOSC52:
+		return NewOSC52Backend(nil), nil
+	case BackendTmux:
+		return tmuxBackend(), nil
+	case BackendWayland:
+		return waylandBackend(), nil
+	case BackendX11:
+		return x11Backend(), nil
+	case BackendMemory:
+		return &MemoryBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown clipboard backend %q (use %s)", name, strings.Join(BackendNames, ", "))
+	}
+}
+
+// DetectBackend returns the name of the backend suited to the session described by
+// the environment: over SSH the local clipboard is reached through tmux or the
+// terminal, otherwise the Wayland or X11 tools are used when installed
+func DetectBackend(getenv func(string) string, lookPath func(string) (string, error)) string {
+	installed := func(tool string) bool {
+		_, err := lookPath(tool)
+		return err == nil
+	}
+
+	if getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != "" {
+		if getenv("TMUX") != "" && installed("tmux") {
+			return BackendTmux
+		}
+		return BackendOSC52
+	}
+	if getenv("WAYLAND_DISPLAY") != "" && installed("wl-copy") {
+		return BackendWayland
+	}
+	if getenv("DISPLAY") != "" && installed("xclip") {
+		return BackendX11
+	}
+	return BackendSystem
+}
+
+// disabledBackend stands in for a backend that could not be created
+type disabledBackend struct {
+	err error
+}
+
+// NewDisabledBackend returns a backend failing every operation with err
+// It replaces a misconfigured backend so secrets never reach a clipboard the user did not choose
+func NewDisabledBackend(err error) Backend {
+	return disabledBackend{err: err}
+}
+
+// Name returns the backend name
+func (disabledBackend) Name() string {
+	return "disabled"
+}
+
+// CopyText refuses to copy
+func (b disabledBackend) CopyText(string) error {
+	return b.err
+}
+
+// PasteText refuses to paste
+func (b disabledBackend) PasteText() (string, error) {
+	return "", b.err
+}
+
+// systemBackend uses go-clipboard, which picks a tool for the platform
+type systemBackend struct {
+	goclipboard.Clipboard
+}
+
+// Name returns the backend name
+func (systemBackend) Name() string {
+	return BackendSystem
+}
@@ -0,0 +1,108 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDetectBackend(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		installed []string
+		want      string
+	}{
+		{"ssh", map[string]string{"SSH_TTY": "/dev/pts/1", "WAYLAND_DISPLAY": "wayland-0"}, []string{"wl-copy"}, BackendOSC52},
+		{"ssh in tmux", map[string]string{"SSH_CONNECTION": "10.0.0.1 22", "TMUX": "/tmp/tmux"}, []string{"tmux"}, BackendTmux},
+		{"ssh in tmux without the tool", map[string]string{"SSH_TTY": "/dev/pts/1", "TMUX": "/tmp/tmux"}, nil, BackendOSC52},
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wl-copy", "xclip"}, BackendWayland},
+		{"x11", map[string]string{"DISPLAY": ":0"}, []string{"xclip"}, BackendX11},
+		{"wayland without wl-copy", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, nil, BackendSystem},
+		{"nothing", nil, nil, BackendSystem},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		lookPath := func(tool string) (string, error) {
+			for _, installed := range tt.installed {
+				if tool == installed {
+					return "/usr/bin/" + tool, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+		if got := DetectBackend(getenv, lookPath); got != tt.want {
+			t.Errorf("DetectBackend(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	for _, name := range BackendNames {
+		backend, err := NewBackend(name)
+		if err != nil {
+			t.Fatalf("NewBackend(%q) error = %v", name, err)
+		}
+		if name != BackendAuto && backend.Name() != name {
+			t.Errorf("NewBackend(%q).Name() = %q", name, backend.Name())
+		}
+	}
+	_, err := NewBackend("carrier-pigeon")
+	if err == nil {
+		t.Fatal("NewBackend(unknown) succeeded, want error")
+	}
+
+	// A manager on a disabled backend reports the error instead of copying anywhere
+	manager := NewManagerWithBackend(NewDisabledBackend(err), time.Minute)
+	if copyErr := manager.CopyWithTimeout("secret"); !errors.Is(copyErr, err) {
+		t.Errorf("CopyWithTimeout() error = %v, want %v", copyErr, err)
+	}
+	if manager.Remaining() != 0 {
+		t.Error("failed copy scheduled a clear")
+	}
+}
+
+func TestOSC52Sequence(t *testing.T) {
+	if got, want := osc52Sequence("hunter2", false), "\x1b]52;c;aHVudGVyMg==\a"; got != want {
+		t.Errorf("osc52Sequence() = %q, want %q", got, want)
+	}
+	if got, want := osc52Sequence("hunter2", true), "\x1bPtmux;\x1b\x1b]52;c;aHVudGVyMg==\a\x1b\\"; got != want {
+		t.Errorf("osc52Sequence(tmux) = %q, want %q", got, want)
+	}
+}
+
+func TestOSC52Manager(t *testing.T) {
+	var terminal bytes.Buffer
+	backend := &OSC52Backend{out: &terminal}
+	manager := NewManagerWithBackend(backend, 0)
+
+	if err := manager.CopyWithTimeout("hunter2"); err != nil {
+		t.Fatalf("CopyWithTimeout() error = %v", err)
+	}
+	if _, err := backend.PasteText(); !errors.Is(err, ErrPasteUnsupported) {
+		t.Errorf("PasteText() error = %v, want ErrPasteUnsupported", err)
+	}
+
+	// The clipboard cannot be read back, so clearing always overwrites it
+	manager.Clear()
+	if got, want := terminal.String(), osc52Sequence("hunter2", false)+osc52Sequence("", false); got != want {
+		t.Errorf("terminal output = %q, want %q", got, want)
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	backend := &MemoryBackend{}
+	manager := NewManagerWithBackend(backend, 0)
+	manager.SetRestorePrevious(true)
+
+	_ = backend.CopyText("previous")
+	_ = manager.CopyWithTimeout("secret")
+	if got, _ := backend.PasteText(); got != "secret" {
+		t.Errorf("PasteText() = %q, want the secret", got)
+	}
+	manager.Clear()
+	if got, _ := backend.PasteText(); got != "previous" {
+		t.Errorf("PasteText() after Clear() = %q, want the previous text", got)
+	}
+}
@@ -1,26 +1,62 @@
 package clipboard
 
 import (
+	"crypto/sha256"
+	"strings"
 	"sync"
 	"time"
-
-	goclipboard "github.com/tiagomelo/go-clipboard/clipboard"
 )
 
 // Manager handles clipboard operations with auto-clear functionality
+// It only clears content it placed itself, recognized by its hash, so text the
+// user copied in the meantime survives the timeout
 type Manager struct {
-	clipboard goclipboard.Clipboard
-	timeout   time.Duration
-	timer     *time.Timer
-	mu        sync.Mutex
+	backend Backend
+	timeout time.Duration
+	timer   *time.Timer
+	mu      sync.Mutex
+
+	// Hash of the secret currently owned by the manager
+	owned     bool
+	hash      [sha256.Size]byte
+	expiresAt time.Time
+
+	// generation counts copies so a timer that fired for an earlier secret is ignored
+	generation uint64
+
+	// Clipboard contents before the first secret was copied
+	restore     bool
+	previous    string
+	hasPrevious bool
 }
 
 // NewManager creates a new clipboard manager with the specified timeout
+// The backend is detected from the session, see DetectBackend
 func NewManager(timeout time.Duration) *Manager {
+	backend, _ := NewBackend(BackendAuto)
+	return NewManagerWithBackend(backend, timeout)
+}
+
+// NewManagerWithBackend creates a clipboard manager on top of the given backend
+func NewManagerWithBackend(backend Backend, timeout time.Duration) *Manager {
 	return &Manager{
-		clipboard: goclipboard.New(),
-		timeout:   timeout,
+		backend: backend,
+		timeout: timeout,
+	}
+}
+
+// Backend returns the clipboard backend in use
+func (m *Manager) Backend() Backend {
+	return m.backend
+}
+
+// SensitiveHintError returns why copies are not marked with SensitiveHints although
+// the backend normally marks them, such as a compositor without data control
+func (m *Manager) SensitiveHintError() error {
+	if owner, ok := m.backend.(*OwnerBackend); ok {
+		return owner.FallbackError()
 	}
+	return nil
 }
 
 // CopyWithTimeout copies text to clipboard and clears it after timeout
@@ -28,33 +64,96 @@ func (m *Manager) CopyWithTimeout(text string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Copy to clipboard using go-clipboard (Wayland compatible)
-	if err := m.clipboard.CopyText(text); err != nil {
+	// Remember what the user had copied, unless it is an earlier secret of ours
+	if m.restore && !m.ownsContent() {
+		m.previous, m.hasPrevious = "", false
+		if current, err := m.backend.PasteText(); err == nil && current != "" {
+			m.previous, m.hasPrevious = current, true
+		}
+	}
+
+	if err := m.backend.CopyText(text); err != nil {
 		return err
 	}
+	m.owned = true
+	m.hash = sha256.Sum256([]byte(text))
+	m.generation++
 
 	// Stop existing timer if any
 	if m.timer != nil {
 		m.timer.Stop()
+		m.timer = nil
 	}
 
 	// Set new timer to clear clipboard
+	m.expiresAt = time.Time{}
 	if m.timeout > 0 {
-		m.timer = time.AfterFunc(m.timeout, func() {
-			m.Clear()
-		})
+		m.expiresAt = time.Now().Add(m.timeout)
+		generation := m.generation
+		m.timer = time.AfterFunc(m.timeout, func() { m.expire(generation) })
 	}
 
 	return nil
 }
 
-// Clear clears the clipboard
+// Clear removes the manager's secret from the clipboard
+// Content copied by someone else is left alone; with restoring enabled the previous
+// contents are put back, otherwise the clipboard is emptied
 func (m *Manager) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.clear()
+}
 
-	// Clear clipboard by copying empty string
-	_ = m.clipboard.CopyText("")
+// expire clears the clipboard when the timer of a copy fires
+// A timer that fired while a newer secret was being copied belongs to an earlier
+// generation and leaves the new secret alone
+func (m *Manager) expire(generation uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if generation != m.generation {
+		return
+	}
+	m.clear()
+}
+
+// clear removes the manager's secret from the clipboard
+// Must be called with the lock held
+func (m *Manager) clear() {
+	if m.owned && m.ownsContent() {
+		if m.restore && m.hasPrevious {
+			_ = m.backend.CopyText(m.previous)
+		} else {
+			_ = m.backend.CopyText("")
+		}
+	}
+	m.forget()
+}
+
+// ownsContent reports whether the clipboard still holds the manager's secret
+// A clipboard that cannot be read is assumed to hold it, so secrets are never left behind
+// Must be called with the lock held
+func (m *Manager) ownsContent() bool {
+	if !m.owned {
+		return false
+	}
+	current, err := m.backend.PasteText()
+	if err != nil {
+		return true
+	}
+	// Some clipboard tools add a trailing newline when pasting
+	return sha256.Sum256([]byte(current)) == m.hash ||
+		sha256.Sum256([]byte(strings.TrimSuffix(current, "\n"))) == m.hash
+}
+
+// forget drops the owned secret, the saved contents and the timer
+// Must be called with the lock held
+func (m *Manager) forget() {
+	m.owned = false
+	m.hash = [sha256.Size]byte{}
+	m.expiresAt = time.Time{}
+	m.previous, m.hasPrevious = "", false
 
 	// Stop timer if running
 	if m.timer != nil {
@@ -63,9 +162,28 @@ func (m *Manager) Clear() {
 	}
 }
 
+// Remaining returns the time until the clipboard is cleared, or zero if no clear is pending
+func (m *Manager) Remaining() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.owned || m.expiresAt.IsZero() {
+		return 0
+	}
+	return max(0, time.Until(m.expiresAt))
+}
+
 // SetTimeout updates the auto-clear timeout
 func (m *Manager) SetTimeout(timeout time.Duration) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.timeout = timeout
 }
+
+// SetRestorePrevious sets whether clearing puts back what was on the clipboard
+// before the first secret was copied
+func (m *Manager) SetRestorePrevious(restore bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restore = restore
+}
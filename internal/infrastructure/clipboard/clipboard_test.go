@@ -0,0 +1,148 @@
+package clipboard
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClipboard is an in-memory clipboard
+type fakeClipboard struct {
+	mu       sync.Mutex
+	text     string
+	suffix   string // appended when pasting, like some clipboard tools do
+	pasteErr error
+}
+
+func (f *fakeClipboard) Name() string {
+	return "fake"
+}
+
+func (f *fakeClipboard) CopyText(text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.text = text
+	return nil
+}
+
+func (f *fakeClipboard) PasteText() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pasteErr != nil {
+		return "", f.pasteErr
+	}
+	return f.text + f.suffix, nil
+}
+
+func (f *fakeClipboard) get() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text
+}
+
+func TestClearOwnContent(t *testing.T) {
+	fake := &fakeClipboard{text: "notes", suffix: "\n"}
+	manager := NewManagerWithBackend(fake, 0)
+
+	if err := manager.CopyWithTimeout("secret"); err != nil {
+		t.Fatalf("CopyWithTimeout() error = %v", err)
+	}
+	manager.Clear()
+	if got := fake.get(); got != "" {
+		t.Errorf("clipboard after Clear() = %q, want empty", got)
+	}
+}
+
+func TestClearKeepsForeignContent(t *testing.T) {
+	fake := &fakeClipboard{}
+	manager := NewManagerWithBackend(fake, 0)
+
+	_ = manager.CopyWithTimeout("secret")
+	_ = fake.CopyText("copied by the user")
+	manager.Clear()
+	if got := fake.get(); got != "copied by the user" {
+		t.Errorf("clipboard after Clear() = %q, want the user's text", got)
+	}
+
+	// The secret is forgotten, so a later clear leaves an equal text alone too
+	_ = fake.CopyText("secret")
+	manager.Clear()
+	if got := fake.get(); got != "secret" {
+		t.Errorf("clipboard after second Clear() = %q, want it untouched", got)
+	}
+}
+
+func TestClearWhenUnreadable(t *testing.T) {
+	fake := &fakeClipboard{}
+	manager := NewManagerWithBackend(fake, 0)
+
+	_ = manager.CopyWithTimeout("secret")
+	fake.pasteErr = errors.New("no paste tool")
+	manager.Clear()
+	if got := fake.get(); got != "" {
+		t.Errorf("clipboard after Clear() = %q, want the secret removed", got)
+	}
+}
+
+func TestRestorePrevious(t *testing.T) {
+	fake := &fakeClipboard{text: "shopping list"}
+	manager := NewManagerWithBackend(fake, 0)
+	manager.SetRestorePrevious(true)
+
+	// A second secret must not be mistaken for the user's contents
+	_ = manager.CopyWithTimeout("first secret")
+	_ = manager.CopyWithTimeout("second secret")
+	manager.Clear()
+	if got := fake.get(); got != "shopping list" {
+		t.Errorf("clipboard after Clear() = %q, want the previous contents", got)
+	}
+}
+
+func TestTimeoutAndRemaining(t *testing.T) {
+	fake := &fakeClipboard{}
+	manager := NewManagerWithBackend(fake, 50*time.Millisecond)
+
+	if remaining := manager.Remaining(); remaining != 0 {
+		t.Errorf("Remaining() before copying = %v, want 0", remaining)
+	}
+
+	_ = manager.CopyWithTimeout("secret")
+	if remaining := manager.Remaining(); remaining <= 0 || remaining > 50*time.Millisecond {
+		t.Errorf("Remaining() after copying = %v", remaining)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.get() != "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := fake.get(); got != "" {
+		t.Fatalf("clipboard after timeout = %q, want empty", got)
+	}
+	if remaining := manager.Remaining(); remaining != 0 {
+		t.Errorf("Remaining() after clearing = %v, want 0", remaining)
+	}
+}
+
+func TestStaleTimerKeepsNewSecret(t *testing.T) {
+	fake := &fakeClipboard{}
+	manager := NewManagerWithBackend(fake, time.Hour)
+
+	_ = manager.CopyWithTimeout("first secret")
+	stale := manager.generation
+	_ = manager.CopyWithTimeout("second secret")
+
+	// The first timer fired but only got the lock after the second copy
+	manager.expire(stale)
+	if got := fake.get(); got != "second secret" {
+		t.Fatalf("clipboard after stale timer = %q, want the second secret", got)
+	}
+	if manager.Remaining() == 0 {
+		t.Error("stale timer cancelled the pending clear")
+	}
+
+	manager.expire(manager.generation)
+	if got := fake.get(); got != "" {
+		t.Errorf("clipboard after current timer = %q, want empty", got)
+	}
+}
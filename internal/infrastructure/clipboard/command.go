@@ -0,0 +1,84 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CommandBackend copies and pastes by running clipboard tools
+type CommandBackend struct {
+	name  string
+	copy  []string
+	paste []string
+}
+
+// tmuxBackend stores text in a tmux buffer; -w also forwards it to the outer
+// terminal's clipboard when tmux's set-clipboard option allows it
+func tmuxBackend() *CommandBackend {
+	return &CommandBackend{
+		name:  BackendTmux,
+		copy:  []string{"tmux", "load-buffer", "-w", "-"},
+		paste: []string{"tmux", "save-buffer", "-"},
+	}
+}
+
+// waylandBackend owns the selection through data control so secrets are marked
+// sensitive, and uses wl-copy and wl-paste from wl-clipboard otherwise
+func waylandBackend() *OwnerBackend {
+	return NewOwnerBackend(BackendWayland, connectWayland, &CommandBackend{
+		name:  BackendWayland,
+		copy:  []string{"wl-copy"},
+		paste: []string{"wl-paste", "--no-newline"},
+	})
+}
+
+// x11Backend owns the CLIPBOARD selection so secrets are marked sensitive, and
+// uses xclip when the display cannot be reached directly
+func x11Backend() *OwnerBackend {
+	return NewOwnerBackend(BackendX11, connectX11, &CommandBackend{
+		name:  BackendX11,
+		copy:  []string{"xclip", "-selection", "clipboard"},
+		paste: []string{"xclip", "-selection", "clipboard", "-o"},
+	})
+}
+
+// Name returns the backend name
+func (b *CommandBackend) Name() string {
+	return b.name
+}
+
+// CopyText runs the copy command with text on its standard input
+func (b *CommandBackend) CopyText(text string) error {
+	cmd := exec.Command(b.copy[0], b.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return commandError(b.copy[0], err, stderr.String())
+	}
+	return nil
+}
+
+// PasteText runs the paste command and returns its output
+func (b *CommandBackend) PasteText() (string, error) {
+	cmd := exec.Command(b.paste[0], b.paste[1:]...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", commandError(b.paste[0], err, stderr.String())
+	}
+	return string(out), nil
+}
+
+// commandError describes a failed clipboard tool, including its error output
+func commandError(tool string, err error, stderr string) error {
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, stderr)
+	}
+	return fmt.Errorf("%s failed: %w", tool, err)
+}
@@ -0,0 +1,30 @@
+package clipboard
+
+import "sync"
+
+// MemoryBackend keeps the clipboard inside the process
+// It never reaches the system clipboard and is meant for tests and headless use
+type MemoryBackend struct {
+	mu   sync.Mutex
+	text string
+}
+
+// Name returns the backend name
+func (b *MemoryBackend) Name() string {
+	return BackendMemory
+}
+
+// CopyText stores text
+func (b *MemoryBackend) CopyText(text string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.text = text
+	return nil
+}
+
+// PasteText returns the stored text
+func (b *MemoryBackend) PasteText() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.text, nil
+}
@@ -0,0 +1,61 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+)
+
+// OSC52Backend copies through the terminal with the OSC 52 escape sequence, which
+// reaches the local clipboard from SSH sessions. Terminals do not let programs read
+// the clipboard back, so PasteText is unsupported
+type OSC52Backend struct {
+	out  io.Writer
+	tmux bool
+}
+
+// NewOSC52Backend creates an OSC 52 backend writing to out, or to the controlling
+// terminal when out is nil. Inside tmux the sequence is wrapped for passthrough
+func NewOSC52Backend(out io.Writer) *OSC52Backend {
+	return &OSC52Backend{out: out, tmux: os.Getenv("TMUX") != ""}
+}
+
+// Name returns the backend name
+func (b *OSC52Backend) Name() string {
+	return BackendOSC52
+}
+
+// CopyText sends text to the terminal's clipboard
+func (b *OSC52Backend) CopyText(text string) error {
+	out := b.out
+	if out == nil {
+		// Write to the terminal directly so the sequence does not mix with piped output
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			out = os.Stderr
+		} else {
+			defer tty.Close()
+			out = tty
+		}
+	}
+
+	_, err := io.WriteString(out, osc52Sequence(text, b.tmux))
+	return err
+}
+
+// PasteText is not supported by terminals
+func (b *OSC52Backend) PasteText() (string, error) {
+	return "", ErrPasteUnsupported
+}
+
+// osc52Sequence returns the escape sequence setting the clipboard to text
+// tmux passes sequences wrapped in a DCS through to the outer terminal when
+// allow-passthrough is on; escape characters inside must be doubled
+func osc52Sequence(text string, tmux bool) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		sequence = "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return sequence
+}
@@ -0,0 +1,148 @@
+package clipboard
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrTooLarge is returned by an Owner for content it cannot transfer in one piece
+var ErrTooLarge = errors.New("clipboard content is too large for the selection owner")
+
+// SensitiveHints are offered next to copied secrets so clipboard history managers
+// skip them: KDE Klipper, CopyQ and cliphist honor the KDE password manager hint,
+// other tools the concealed type of the nspasteboard.org convention
+var SensitiveHints = map[string]string{
+	"x-kde-passwordManagerHint":                 "secret",
+	"application/x-nspasteboard-concealed-type": "secret",
+}
+
+// textTypes are the MIME types and X11 targets text is offered as
+var textTypes = []string{"text/plain;charset=utf-8", "text/plain", "UTF8_STRING", "STRING", "TEXT"}
+
+// Offer is clipboard content together with the types it is offered as
+type Offer struct {
+	Text string
+	// Hints are extra MIME types and their data, such as SensitiveHints
+	Hints map[string]string
+}
+
+// Types returns the text types followed by the hint types, sorted
+func (o Offer) Types() []string {
+	types := append([]string(nil), textTypes...)
+	hints := make([]string, 0, len(o.Hints))
+	for mime := range o.Hints {
+		hints = append(hints, mime)
+	}
+	sort.Strings(hints)
+	return append(types, hints...)
+}
+
+// Data returns the content offered as mime
+func (o Offer) Data(mime string) (string, bool) {
+	for _, textType := range textTypes {
+		if mime == textType {
+			return o.Text, true
+		}
+	}
+	data, ok := o.Hints[mime]
+	return data, ok
+}
+
+// Owner holds a clipboard selection inside the process and serves it to other
+// clients in every type of the offer
+// The X11 and Wayland owners speak the few requests they need directly instead of
+// pulling in a protocol library: the clipboard tools offer a single type per call,
+// and no Go Wayland binding covers the data control protocol. Content over the X
+// server's request size is refused rather than sent incrementally, and Wayland
+// transfers are written in the background so a stalled reader cannot block the owner
+type Owner interface {
+	// Own makes offer the clipboard content until another client takes over
+	Own(offer Offer) error
+	// Current returns the offered text while the selection is still owned
+	Current() (string, bool)
+	Close() error
+}
+
+// OwnerBackend copies by owning the selection, so secrets can carry SensitiveHints,
+// and uses a fallback backend when no owner can be connected or for reading
+// content of other clients
+type OwnerBackend struct {
+	name     string
+	connect  func() (Owner, error)
+	fallback Backend
+
+	mu          sync.Mutex
+	owner       Owner
+	connected   bool
+	fallbackErr error
+}
+
+// NewOwnerBackend creates a backend owning the selection with the owner returned by
+// connect, which is called on the first copy
+func NewOwnerBackend(name string, connect func() (Owner, error), fallback Backend) *OwnerBackend {
+	return &OwnerBackend{name: name, connect: connect, fallback: fallback}
+}
+
+// Name returns the backend name
+func (b *OwnerBackend) Name() string {
+	return b.name
+}
+
+// CopyText offers text with SensitiveHints; clearing offers an empty text without hints
+func (b *OwnerBackend) CopyText(text string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if owner := b.ownerLocked(); owner != nil {
+		offer := Offer{Text: text}
+		if text != "" {
+			offer.Hints = SensitiveHints
+		}
+		err := owner.Own(offer)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrTooLarge) {
+			// The owner still works for smaller content
+			return b.fallback.CopyText(text)
+		}
+		// A broken connection is not retried, the fallback takes over
+		_ = owner.Close()
+		b.owner = nil
+		b.fallbackErr = err
+	}
+	return b.fallback.CopyText(text)
+}
+
+// PasteText returns the owned text, or reads the clipboard with the fallback
+func (b *OwnerBackend) PasteText() (string, error) {
+	b.mu.Lock()
+	owner := b.owner
+	b.mu.Unlock()
+
+	if owner != nil {
+		if text, ok := owner.Current(); ok {
+			return text, nil
+		}
+	}
+	return b.fallback.PasteText()
+}
+
+// FallbackError returns why copies go through the fallback without SensitiveHints,
+// or nil while the selection is owned or before the first copy
+func (b *OwnerBackend) FallbackError() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fallbackErr
+}
+
+// ownerLocked connects the owner once
+// Must be called with the lock held
+func (b *OwnerBackend) ownerLocked() Owner {
+	if !b.connected {
+		b.connected = true
+		b.owner, b.fallbackErr = b.connect()
+	}
+	return b.owner
+}
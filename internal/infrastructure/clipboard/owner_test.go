@@ -0,0 +1,163 @@
+package clipboard
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeOwner records the offers it owns, like a selection owner seen by other clients
+type fakeOwner struct {
+	mu     sync.Mutex
+	offers []Offer
+	owned  bool
+	ownErr error
+}
+
+func (f *fakeOwner) Own(offer Offer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ownErr != nil {
+		return f.ownErr
+	}
+	f.offers = append(f.offers, offer)
+	f.owned = true
+	return nil
+}
+
+func (f *fakeOwner) Current() (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.offers) == 0 {
+		return "", false
+	}
+	return f.offers[len(f.offers)-1].Text, f.owned
+}
+
+func (f *fakeOwner) Close() error {
+	return nil
+}
+
+// takeOver simulates another client setting the selection
+func (f *fakeOwner) takeOver() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.owned = false
+}
+
+// assertSensitive checks that an offer carries the text and every sensitive hint
+func assertSensitive(t *testing.T, offer Offer, text string) {
+	t.Helper()
+	types := offer.Types()
+	for mime, want := range SensitiveHints {
+		if !slices.Contains(types, mime) {
+			t.Errorf("offer types %v do not include %s", types, mime)
+		}
+		if got, _ := offer.Data(mime); got != want {
+			t.Errorf("offer data for %s = %q, want %q", mime, got, want)
+		}
+	}
+	for _, mime := range []string{"text/plain;charset=utf-8", "UTF8_STRING"} {
+		if got, ok := offer.Data(mime); !ok || got != text {
+			t.Errorf("offer data for %s = %q, want %q", mime, got, text)
+		}
+	}
+}
+
+func TestOwnerBackendMarksSecrets(t *testing.T) {
+	owner := &fakeOwner{}
+	backend := NewOwnerBackend("fake", func() (Owner, error) { return owner, nil }, &fakeClipboard{})
+	manager := NewManagerWithBackend(backend, 0)
+
+	if err := manager.CopyWithTimeout("hunter2"); err != nil {
+		t.Fatalf("CopyWithTimeout() error = %v", err)
+	}
+	if len(owner.offers) != 1 {
+		t.Fatalf("owner got %d offers, want 1", len(owner.offers))
+	}
+	assertSensitive(t, owner.offers[0], "hunter2")
+
+	// Clearing offers empty text, which is no secret
+	manager.Clear()
+	if len(owner.offers) != 2 {
+		t.Fatalf("owner got %d offers, want 2", len(owner.offers))
+	}
+	if cleared := owner.offers[1]; cleared.Text != "" || len(cleared.Hints) != 0 {
+		t.Errorf("clearing offered %+v, want empty text without hints", cleared)
+	}
+}
+
+func TestOwnerBackendPaste(t *testing.T) {
+	owner := &fakeOwner{}
+	fallback := &fakeClipboard{text: "from another client"}
+	backend := NewOwnerBackend("fake", func() (Owner, error) { return owner, nil }, fallback)
+
+	_ = backend.CopyText("hunter2")
+	if got, _ := backend.PasteText(); got != "hunter2" {
+		t.Errorf("PasteText() = %q, want the owned text", got)
+	}
+
+	// Once another client owns the selection it is read with the fallback
+	owner.takeOver()
+	if got, _ := backend.PasteText(); got != "from another client" {
+		t.Errorf("PasteText() = %q, want the fallback's text", got)
+	}
+}
+
+func TestOwnerBackendFallback(t *testing.T) {
+	fallback := &fakeClipboard{}
+	connects := 0
+	backend := NewOwnerBackend("fake", func() (Owner, error) {
+		connects++
+		return nil, errors.New("no display")
+	}, fallback)
+
+	_ = backend.CopyText("one")
+	_ = backend.CopyText("two")
+	if got := fallback.get(); got != "two" {
+		t.Errorf("fallback holds %q, want %q", got, "two")
+	}
+	if connects != 1 {
+		t.Errorf("connect called %d times, want once", connects)
+	}
+	if backend.FallbackError() == nil {
+		t.Error("FallbackError() = nil, want the connect error")
+	}
+	manager := NewManagerWithBackend(backend, 0)
+	if manager.SensitiveHintError() == nil {
+		t.Error("SensitiveHintError() = nil, want the connect error")
+	}
+
+	// An owner that breaks is dropped for the fallback
+	owner := &fakeOwner{ownErr: errors.New("connection closed")}
+	backend = NewOwnerBackend("fake", func() (Owner, error) { return owner, nil }, fallback)
+	if err := backend.CopyText("three"); err != nil {
+		t.Fatalf("CopyText() error = %v", err)
+	}
+	if got := fallback.get(); got != "three" {
+		t.Errorf("fallback holds %q, want %q", got, "three")
+	}
+	if backend.FallbackError() == nil {
+		t.Error("FallbackError() = nil, want the error of the broken owner")
+	}
+
+	// Content too large for the owner goes through the fallback, the owner stays
+	owner = &fakeOwner{ownErr: ErrTooLarge}
+	backend = NewOwnerBackend("fake", func() (Owner, error) { return owner, nil }, fallback)
+	if err := backend.CopyText("four"); err != nil {
+		t.Fatalf("CopyText() error = %v", err)
+	}
+	if got := fallback.get(); got != "four" {
+		t.Errorf("fallback holds %q, want %q", got, "four")
+	}
+	owner.ownErr = nil
+	if err := backend.CopyText("five"); err != nil || len(owner.offers) != 1 {
+		t.Errorf("CopyText() after a large copy: error = %v, owner got %d offers, want 1", err, len(owner.offers))
+	}
+
+	// Backends that never mark copies have nothing to report
+	if err := NewManagerWithBackend(fallback, 0).SensitiveHintError(); err != nil {
+		t.Errorf("SensitiveHintError() = %v for a plain backend, want nil", err)
+	}
+}
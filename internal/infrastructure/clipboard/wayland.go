@@ -0,0 +1,416 @@
+//go:build unix
+
+package clipboard
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Wayland object ids and opcodes used by the data control owner
+// The wlroots and ext data control protocols share their requests and events
+const (
+	wlDisplay  = 1
+	wlRegistry = 2
+	wlCallback = 3
+
+	wlDisplaySync        = 0
+	wlDisplayGetRegistry = 1
+	wlDisplayError       = 0
+	wlRegistryBind       = 0
+	wlRegistryGlobal     = 0
+	wlCallbackDone       = 0
+
+	dataControlCreateSource = 0
+	dataControlGetDevice    = 1
+	dataDeviceSetSelection  = 0
+	dataDeviceDataOffer     = 0
+	dataDeviceSelection     = 1
+	dataSourceOffer         = 0
+	dataSourceDestroy       = 1
+	dataSourceSend          = 0
+	dataSourceCancelled     = 1
+	dataOfferDestroy        = 1
+)
+
+// waylandSendTimeout bounds how long a paste target may take to read the data
+const waylandSendTimeout = 5 * time.Second
+
+// dataControlManagers are the manager interfaces, preferred first
+var dataControlManagers = []string{"ext_data_control_manager_v1", "zwlr_data_control_manager_v1"}
+
+// waylandOwner owns the clipboard through the data control protocol, which lets
+// clipboard tools set the selection without a window; compositors without it,
+// such as GNOME, are left to wl-copy
+type waylandOwner struct {
+	conn *net.UnixConn
+
+	mu      sync.Mutex
+	nextID  uint32
+	manager uint32
+	device  uint32
+	source  uint32
+	offer   Offer
+	owned   bool
+	err     error
+
+	// Every data source created, and whether it is not destroyed yet
+	sources map[uint32]bool
+
+	// Unparsed input and the file descriptors that came with it
+	buf []byte
+	fds []int
+}
+
+// connectWayland connects to the compositor in $WAYLAND_DISPLAY
+func connectWayland() (Owner, error) {
+	display := os.Getenv("WAYLAND_DISPLAY")
+	if display == "" {
+		return nil, errors.New("WAYLAND_DISPLAY is not set")
+	}
+	if !filepath.IsAbs(display) {
+		runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			return nil, errors.New("XDG_RUNTIME_DIR is not set")
+		}
+		display = filepath.Join(runtimeDir, display)
+	}
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: display, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Wayland display: %w", err)
+	}
+	owner, err := newWaylandOwner(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return owner, nil
+}
+
+// newWaylandOwner binds the data control manager and the seat, gets the seat's data
+// device, then serves the selection in the background
+func newWaylandOwner(conn *net.UnixConn) (*waylandOwner, error) {
+	o := &waylandOwner{conn: conn, nextID: wlCallback + 1, sources: make(map[uint32]bool)}
+
+	if err := o.request(wlDisplay, wlDisplayGetRegistry, wlUint(wlRegistry)); err != nil {
+		return nil, err
+	}
+	if err := o.request(wlDisplay, wlDisplaySync, wlUint(wlCallback)); err != nil {
+		return nil, err
+	}
+
+	// Collect the globals until the sync callback is done
+	type global struct {
+		name    uint32
+		version uint32
+	}
+	globals := make(map[string]global)
+	for {
+		object, opcode, args, err := o.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if object == wlCallback && opcode == wlCallbackDone {
+			break
+		}
+		if object == wlDisplay && opcode == wlDisplayError {
+			return nil, waylandError(args)
+		}
+		if object == wlRegistry && opcode == wlRegistryGlobal && len(args) >= 8 {
+			name := binary.LittleEndian.Uint32(args)
+			iface, rest := wlParseString(args[4:])
+			if len(rest) >= 4 {
+				globals[iface] = global{name, binary.LittleEndian.Uint32(rest)}
+			}
+		}
+	}
+
+	seat, ok := globals["wl_seat"]
+	if !ok {
+		return nil, errors.New("Wayland compositor has no seat")
+	}
+	var managerIface string
+	for _, iface := range dataControlManagers {
+		if _, ok := globals[iface]; ok {
+			managerIface = iface
+			break
+		}
+	}
+	if managerIface == "" {
+		return nil, errors.New("Wayland compositor does not support data control")
+	}
+
+	var err error
+	if o.manager, err = o.bind(globals[managerIface].name, managerIface); err != nil {
+		return nil, err
+	}
+	seatID, err := o.bind(seat.name, "wl_seat")
+	if err != nil {
+		return nil, err
+	}
+	o.device = o.newID()
+	if err := o.request(o.manager, dataControlGetDevice, wlUint(o.device), wlUint(seatID)); err != nil {
+		return nil, err
+	}
+
+	go o.serve()
+	return o, nil
+}
+
+// Own creates a data source offering every type and sets it as the selection
+func (o *waylandOwner) Own(offer Offer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.err != nil {
+		return o.err
+	}
+	previous := o.source
+
+	source := o.newID()
+	if err := o.request(o.manager, dataControlCreateSource, wlUint(source)); err != nil {
+		return err
+	}
+	o.sources[source] = true
+	for _, mime := range offer.Types() {
+		if err := o.request(source, dataSourceOffer, wlString(mime)); err != nil {
+			return err
+		}
+	}
+	if err := o.request(o.device, dataDeviceSetSelection, wlUint(source)); err != nil {
+		return err
+	}
+	o.source, o.offer, o.owned = source, offer, true
+
+	if previous != 0 {
+		return o.destroySource(previous)
+	}
+	return nil
+}
+
+// Current returns the offered text until another client sets the selection
+func (o *waylandOwner) Current() (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.offer.Text, o.owned && o.err == nil
+}
+
+// Close disconnects from the compositor, which also drops the selection
+func (o *waylandOwner) Close() error {
+	return o.conn.Close()
+}
+
+// serve writes the offer to clients that paste it until the connection is closed
+func (o *waylandOwner) serve() {
+	for {
+		object, opcode, args, err := o.readMessage()
+		if err != nil {
+			o.fail(err)
+			return
+		}
+
+		switch {
+		case object == wlDisplay && opcode == wlDisplayError:
+			o.fail(waylandError(args))
+			return
+		case object == o.device && opcode == dataDeviceDataOffer && len(args) >= 4:
+			// Offers of the new selection are not read, only released again
+			_ = o.locked(func() error {
+				return o.request(binary.LittleEndian.Uint32(args), dataOfferDestroy)
+			})
+		case !o.isSource(object):
+			// Events of the registry, the seat and released offers
+			continue
+		case opcode == dataSourceSend:
+			mime, _ := wlParseString(args)
+			o.send(object, mime)
+		case opcode == dataSourceCancelled:
+			_ = o.locked(func() error {
+				if object == o.source {
+					o.source, o.owned = 0, false
+				}
+				return o.destroySource(object)
+			})
+		}
+	}
+}
+
+// isSource reports whether object is a data source, including destroyed ones whose
+// last events may still arrive
+func (o *waylandOwner) isSource(object uint32) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.sources[object]
+	return ok
+}
+
+// destroySource destroys a data source once
+// Must be called with the lock held
+func (o *waylandOwner) destroySource(source uint32) error {
+	if !o.sources[source] {
+		return nil
+	}
+	o.sources[source] = false
+	return o.request(source, dataSourceDestroy)
+}
+
+// send writes the data for mime to the file descriptor that came with the request
+// The descriptor is consumed even for a replaced source, keeping the queue in order
+// The write runs in the background with a deadline, a paste target that does not
+// read would otherwise stall the event loop
+func (o *waylandOwner) send(source uint32, mime string) {
+	if len(o.fds) == 0 {
+		return
+	}
+	fd := o.fds[0]
+	o.fds = o.fds[1:]
+
+	o.mu.Lock()
+	data, ok := o.offer.Data(mime)
+	current := source == o.source
+	o.mu.Unlock()
+
+	if !ok || !current {
+		_ = syscall.Close(fd)
+		return
+	}
+	// A non-blocking descriptor goes through the runtime poller, which honors deadlines
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		_ = syscall.Close(fd)
+		return
+	}
+	file := os.NewFile(uintptr(fd), "wayland-paste")
+	go func() {
+		defer file.Close()
+		_ = file.SetWriteDeadline(time.Now().Add(waylandSendTimeout))
+		_, _ = file.WriteString(data)
+	}()
+}
+
+// fail records a broken connection and gives up the selection
+func (o *waylandOwner) fail(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.err = fmt.Errorf("Wayland connection closed: %w", err)
+	o.owned = false
+	for _, fd := range o.fds {
+		_ = syscall.Close(fd)
+	}
+	o.fds = nil
+}
+
+// locked runs fn with the lock held
+func (o *waylandOwner) locked(fn func() error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return fn()
+}
+
+// bind binds a global to a new object
+func (o *waylandOwner) bind(name uint32, iface string) (uint32, error) {
+	id := o.newID()
+	return id, o.request(wlRegistry, wlRegistryBind, wlUint(name), wlString(iface), wlUint(1), wlUint(id))
+}
+
+// newID allocates a client object id
+func (o *waylandOwner) newID() uint32 {
+	id := o.nextID
+	o.nextID++
+	return id
+}
+
+// request writes a message to object with the encoded arguments
+func (o *waylandOwner) request(object uint32, opcode uint16, args ...[]byte) error {
+	msg := binary.LittleEndian.AppendUint32(nil, object)
+	msg = append(msg, 0, 0, 0, 0)
+	for _, arg := range args {
+		msg = append(msg, arg...)
+	}
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(msg))<<16|uint32(opcode))
+	if _, err := o.conn.Write(msg); err != nil {
+		return fmt.Errorf("Wayland request failed: %w", err)
+	}
+	return nil
+}
+
+// readMessage reads the next event, queueing the file descriptors sent with it
+func (o *waylandOwner) readMessage() (uint32, uint16, []byte, error) {
+	for {
+		if len(o.buf) >= 8 {
+			size := int(binary.LittleEndian.Uint32(o.buf[4:]) >> 16)
+			if size < 8 {
+				return 0, 0, nil, errors.New("invalid Wayland message")
+			}
+			if len(o.buf) >= size {
+				object := binary.LittleEndian.Uint32(o.buf)
+				opcode := uint16(binary.LittleEndian.Uint32(o.buf[4:]))
+				args := append([]byte(nil), o.buf[8:size]...)
+				o.buf = o.buf[size:]
+				return object, opcode, args, nil
+			}
+		}
+
+		data := make([]byte, 4096)
+		oob := make([]byte, syscall.CmsgSpace(28*4))
+		n, oobn, _, _, err := o.conn.ReadMsgUnix(data, oob)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if oobn > 0 {
+			messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+			if err != nil {
+				return 0, 0, nil, err
+			}
+			for _, message := range messages {
+				fds, err := syscall.ParseUnixRights(&message)
+				if err == nil {
+					o.fds = append(o.fds, fds...)
+				}
+			}
+		}
+		o.buf = append(o.buf, data[:n]...)
+	}
+}
+
+// waylandError describes a fatal protocol error sent by the compositor
+func waylandError(args []byte) error {
+	if len(args) < 8 {
+		return errors.New("Wayland protocol error")
+	}
+	message, _ := wlParseString(args[8:])
+	return fmt.Errorf("Wayland protocol error %d: %s", binary.LittleEndian.Uint32(args[4:]), message)
+}
+
+// wlUint encodes a uint, object or new_id argument
+func wlUint(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// wlString encodes a string argument: length with the terminating NUL, then padded
+func wlString(s string) []byte {
+	arg := binary.LittleEndian.AppendUint32(nil, uint32(len(s)+1))
+	arg = append(arg, s...)
+	arg = append(arg, 0)
+	return x11Pad(arg)
+}
+
+// wlParseString decodes a string argument and returns the remaining arguments
+func wlParseString(args []byte) (string, []byte) {
+	if len(args) < 4 {
+		return "", nil
+	}
+	size := int(binary.LittleEndian.Uint32(args))
+	padded := (size + 3) &^ 3
+	if size == 0 || len(args) < 4+padded {
+		return "", args[min(len(args), 4+padded):]
+	}
+	return string(args[4 : 4+size-1]), args[4+padded:]
+}
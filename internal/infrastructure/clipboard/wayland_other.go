@@ -0,0 +1,10 @@
+//go:build !unix
+
+package clipboard
+
+import "errors"
+
+// connectWayland fails, Wayland is only available on Unix systems
+func connectWayland() (Owner, error) {
+	return nil, errors.New("Wayland is not supported on this platform")
+}
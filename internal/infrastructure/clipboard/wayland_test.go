@@ -0,0 +1,242 @@
+//go:build unix
+
+package clipboard
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeCompositor is the server side of a Wayland connection offering data control,
+// pasting from the owner like a clipboard manager does
+type fakeCompositor struct {
+	t    *testing.T
+	conn *net.UnixConn
+}
+
+const (
+	fakeManagerName = 2
+	fakeSeatName    = 1
+	fakeOfferID     = 0xff000000
+)
+
+// unixPair returns both ends of a connected Unix socket pair
+func unixPair(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("Socketpair() error = %v", err)
+	}
+	conns := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		file := os.NewFile(uintptr(fd), "wayland")
+		conn, err := net.FileConn(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("FileConn() error = %v", err)
+		}
+		conns[i] = conn.(*net.UnixConn)
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conns[0], conns[1]
+}
+
+// startFakeCompositor connects a new owner to a fake compositor and completes the setup
+func startFakeCompositor(t *testing.T) (*fakeCompositor, *waylandOwner) {
+	t.Helper()
+	client, server := unixPair(t)
+	c := &fakeCompositor{t: t, conn: server}
+
+	type result struct {
+		owner *waylandOwner
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		owner, err := newWaylandOwner(client)
+		done <- result{owner, err}
+	}()
+
+	c.expect(wlDisplay, wlDisplayGetRegistry)
+	c.expect(wlDisplay, wlDisplaySync)
+	c.event(wlRegistry, wlRegistryGlobal, wlUint(fakeSeatName), wlString("wl_seat"), wlUint(7))
+	c.event(wlRegistry, wlRegistryGlobal, wlUint(3), wlString("wl_compositor"), wlUint(6))
+	c.event(wlRegistry, wlRegistryGlobal, wlUint(fakeManagerName), wlString("zwlr_data_control_manager_v1"), wlUint(2))
+	c.event(wlCallback, wlCallbackDone, wlUint(1))
+
+	args := c.expect(wlRegistry, wlRegistryBind)
+	if iface, _ := wlParseString(args[4:]); binary.LittleEndian.Uint32(args) != fakeManagerName || iface != "zwlr_data_control_manager_v1" {
+		t.Fatalf("bound %s, want the data control manager", iface)
+	}
+	args = c.expect(wlRegistry, wlRegistryBind)
+	if iface, _ := wlParseString(args[4:]); iface != "wl_seat" {
+		t.Fatalf("bound %s, want the seat", iface)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("newWaylandOwner() error = %v", res.err)
+	}
+	owner := res.owner
+	c.expect(owner.manager, dataControlGetDevice)
+	return c, owner
+}
+
+// expect reads the next request and checks its target and opcode
+func (c *fakeCompositor) expect(object uint32, opcode uint16) []byte {
+	c.t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		c.t.Fatalf("read error = %v", err)
+	}
+	args := make([]byte, int(binary.LittleEndian.Uint32(header[4:])>>16)-8)
+	if _, err := io.ReadFull(c.conn, args); err != nil {
+		c.t.Fatalf("read error = %v", err)
+	}
+	gotObject, gotOpcode := binary.LittleEndian.Uint32(header), uint16(binary.LittleEndian.Uint32(header[4:]))
+	if gotObject != object || gotOpcode != opcode {
+		c.t.Fatalf("request %d on object %d, want %d on %d", gotOpcode, gotObject, opcode, object)
+	}
+	return args
+}
+
+// eventWithFD sends an event, with a file descriptor when fd is not negative
+func (c *fakeCompositor) eventWithFD(object uint32, opcode uint16, fd int, args ...[]byte) {
+	c.t.Helper()
+	msg := binary.LittleEndian.AppendUint32(nil, object)
+	msg = append(msg, 0, 0, 0, 0)
+	for _, arg := range args {
+		msg = append(msg, arg...)
+	}
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(msg))<<16|uint32(opcode))
+
+	var oob []byte
+	if fd >= 0 {
+		oob = syscall.UnixRights(fd)
+	}
+	if _, _, err := c.conn.WriteMsgUnix(msg, oob, nil); err != nil {
+		c.t.Fatalf("write error = %v", err)
+	}
+}
+
+func (c *fakeCompositor) event(object uint32, opcode uint16, args ...[]byte) {
+	c.t.Helper()
+	c.eventWithFD(object, opcode, -1, args...)
+}
+
+// paste asks a source for mime through a pipe and returns what it wrote
+func (c *fakeCompositor) paste(source uint32, mime string) string {
+	c.t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		c.t.Fatalf("Pipe() error = %v", err)
+	}
+	defer r.Close()
+	c.eventWithFD(source, dataSourceSend, int(w.Fd()), wlString(mime))
+	w.Close()
+
+	_ = r.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := io.ReadAll(r)
+	if err != nil {
+		c.t.Fatalf("reading %s error = %v", mime, err)
+	}
+	return string(data)
+}
+
+func TestWaylandOwnerOffersHint(t *testing.T) {
+	compositor, owner := startFakeCompositor(t)
+
+	// Selections of other clients are released, other events ignored
+	compositor.event(owner.device, dataDeviceDataOffer, wlUint(fakeOfferID))
+	compositor.event(fakeOfferID, 0, wlString("text/plain"))
+	compositor.event(owner.device, dataDeviceSelection, wlUint(fakeOfferID))
+	compositor.event(wlDisplay, 1, wlUint(99))
+	compositor.expect(fakeOfferID, dataOfferDestroy)
+
+	if err := owner.Own(Offer{Text: "hunter2", Hints: SensitiveHints}); err != nil {
+		t.Fatalf("Own() error = %v", err)
+	}
+	source := binary.LittleEndian.Uint32(compositor.expect(owner.manager, dataControlCreateSource))
+	var types []string
+	for range (Offer{Hints: SensitiveHints}).Types() {
+		mime, _ := wlParseString(compositor.expect(source, dataSourceOffer))
+		types = append(types, mime)
+	}
+	if got := binary.LittleEndian.Uint32(compositor.expect(owner.device, dataDeviceSetSelection)); got != source {
+		t.Fatalf("set_selection(%d), want source %d", got, source)
+	}
+	for _, want := range []string{"text/plain;charset=utf-8", "x-kde-passwordManagerHint"} {
+		if !slices.Contains(types, want) {
+			t.Errorf("offered types %v, missing %s", types, want)
+		}
+	}
+
+	if got := compositor.paste(source, "x-kde-passwordManagerHint"); got != "secret" {
+		t.Errorf("pasting the hint = %q, want %q", got, "secret")
+	}
+	if got := compositor.paste(source, "text/plain;charset=utf-8"); got != "hunter2" {
+		t.Errorf("pasting text = %q, want the text", got)
+	}
+
+	// Another client sets the selection
+	compositor.event(source, dataSourceCancelled)
+	compositor.expect(source, dataSourceDestroy)
+	if _, owned := owner.Current(); owned {
+		t.Error("Current() reports the selection as owned after cancellation")
+	}
+}
+
+func TestWaylandOwnerStalledPaste(t *testing.T) {
+	compositor, owner := startFakeCompositor(t)
+
+	// More than a pipe holds, so the write blocks until the target reads
+	text := strings.Repeat("x", 1<<20)
+	if err := owner.Own(Offer{Text: text, Hints: SensitiveHints}); err != nil {
+		t.Fatalf("Own() error = %v", err)
+	}
+	source := binary.LittleEndian.Uint32(compositor.expect(owner.manager, dataControlCreateSource))
+	for range (Offer{Hints: SensitiveHints}).Types() {
+		compositor.expect(source, dataSourceOffer)
+	}
+	compositor.expect(owner.device, dataDeviceSetSelection)
+
+	// A target that never reads must not hold up the next paste
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	defer r.Close()
+	compositor.eventWithFD(source, dataSourceSend, int(w.Fd()), wlString("text/plain"))
+	w.Close()
+
+	if got := compositor.paste(source, "x-kde-passwordManagerHint"); got != "secret" {
+		t.Errorf("pasting the hint after a stalled paste = %q, want %q", got, "secret")
+	}
+}
+
+func TestWaylandOwnerWithoutDataControl(t *testing.T) {
+	client, server := unixPair(t)
+	c := &fakeCompositor{t: t, conn: server}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := newWaylandOwner(client)
+		done <- err
+	}()
+	c.expect(wlDisplay, wlDisplayGetRegistry)
+	c.expect(wlDisplay, wlDisplaySync)
+	c.event(wlRegistry, wlRegistryGlobal, wlUint(fakeSeatName), wlString("wl_seat"), wlUint(7))
+	c.event(wlCallback, wlCallbackDone, wlUint(1))
+
+	if err := <-done; err == nil {
+		t.Error("newWaylandOwner() succeeded without data control, want error")
+	}
+}
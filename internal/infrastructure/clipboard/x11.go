@@ -0,0 +1,421 @@
+package clipboard
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// X11 core protocol opcodes, events and predefined atoms used by the selection owner
+const (
+	x11CreateWindow      = 1
+	x11ChangeProperty    = 18
+	x11SetSelectionOwner = 22
+	x11InternAtom        = 16
+	x11SendEvent         = 25
+
+	x11Error            = 0
+	x11Reply            = 1
+	x11SelectionClear   = 29
+	x11SelectionRequest = 30
+	x11SelectionNotify  = 31
+
+	x11AtomAtom   = 4
+	x11AtomString = 31
+
+	x11InputOnly = 2
+)
+
+// x11Owner owns the CLIPBOARD selection with an unmapped window, speaking the X11
+// wire protocol directly so every type of the offer can be served at once
+type x11Owner struct {
+	conn   net.Conn
+	window uint32
+	atoms  map[string]uint32
+	// maxRequest is the server's maximum request length in four-byte units
+	maxRequest int
+
+	mu    sync.Mutex
+	offer Offer
+	owned bool
+	err   error
+}
+
+// x11Atoms are interned on connect; hint types are added from SensitiveHints
+var x11Atoms = []string{"CLIPBOARD", "TARGETS", "UTF8_STRING", "TEXT", "text/plain;charset=utf-8", "text/plain"}
+
+// connectX11 connects to the display in $DISPLAY with the cookie from $XAUTHORITY
+func connectX11() (Owner, error) {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, errors.New("DISPLAY is not set")
+	}
+	network, address, number, err := x11Address(display)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X display %s: %w", display, err)
+	}
+
+	authName, authData := x11Cookie(xauthorityPath(), number)
+	owner, err := newX11Owner(conn, authName, authData)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return owner, nil
+}
+
+// x11Address parses a display name like ":0", ":1.0" or "host:10.0"
+func x11Address(display string) (network, address, number string, err error) {
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return "", "", "", fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	host, number := display[:colon], display[colon+1:]
+	if dot := strings.Index(number, "."); dot >= 0 {
+		number = number[:dot]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid DISPLAY %q", display)
+	}
+
+	if host == "" || host == "unix" {
+		return "unix", "/tmp/.X11-unix/X" + number, number, nil
+	}
+	return "tcp", net.JoinHostPort(host, strconv.Itoa(6000+n)), number, nil
+}
+
+// xauthorityPath returns $XAUTHORITY or ~/.Xauthority
+func xauthorityPath() string {
+	if path := os.Getenv("XAUTHORITY"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".Xauthority")
+}
+
+// x11Cookie finds the MIT-MAGIC-COOKIE-1 for the display in an Xauthority file
+// Without a cookie the connection is attempted unauthenticated
+func x11Cookie(path, number string) (string, []byte) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	hostname, _ := os.Hostname()
+	r := bufio.NewReader(file)
+	readField := func() ([]byte, error) {
+		var size uint16
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		field := make([]byte, size)
+		_, err := io.ReadFull(r, field)
+		return field, err
+	}
+
+	for {
+		var family uint16
+		if err := binary.Read(r, binary.BigEndian, &family); err != nil {
+			return "", nil
+		}
+		var fields [4][]byte
+		for i := range fields {
+			if fields[i], err = readField(); err != nil {
+				return "", nil
+			}
+		}
+		address, display, name, data := string(fields[0]), string(fields[1]), string(fields[2]), fields[3]
+
+		// FamilyLocal entries name the host, FamilyWild entries match any
+		local := family == 256 && address == hostname
+		if (local || family == 65535) && (display == "" || display == number) && name == "MIT-MAGIC-COOKIE-1" {
+			return name, data
+		}
+	}
+}
+
+// newX11Owner performs the connection setup on conn, creates the owner window and
+// interns the atoms, then serves selection requests in the background
+func newX11Owner(conn net.Conn, authName string, authData []byte) (*x11Owner, error) {
+	setup := []byte{'l', 0}
+	setup = binary.LittleEndian.AppendUint16(setup, 11)
+	setup = binary.LittleEndian.AppendUint16(setup, 0)
+	setup = binary.LittleEndian.AppendUint16(setup, uint16(len(authName)))
+	setup = binary.LittleEndian.AppendUint16(setup, uint16(len(authData)))
+	setup = append(setup, 0, 0)
+	setup = append(setup, x11Pad([]byte(authName))...)
+	setup = append(setup, x11Pad(authData)...)
+	if _, err := conn.Write(setup); err != nil {
+		return nil, fmt.Errorf("X11 setup failed: %w", err)
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("X11 setup failed: %w", err)
+	}
+	body := make([]byte, 4*int(binary.LittleEndian.Uint16(header[6:])))
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, fmt.Errorf("X11 setup failed: %w", err)
+	}
+	if header[0] != 1 {
+		reason := body[:min(int(header[1]), len(body))]
+		return nil, fmt.Errorf("X11 connection refused: %s", strings.TrimSpace(string(reason)))
+	}
+	if len(body) < 32 {
+		return nil, errors.New("X11 setup reply is too short")
+	}
+
+	idBase := binary.LittleEndian.Uint32(body[4:])
+	vendorLen := int(binary.LittleEndian.Uint16(body[16:]))
+	formats := int(body[21])
+	rootOffset := 32 + (vendorLen+3)&^3 + 8*formats
+	if len(body) < rootOffset+4 {
+		return nil, errors.New("X11 setup reply has no screen")
+	}
+	root := binary.LittleEndian.Uint32(body[rootOffset:])
+	maxRequest := int(binary.LittleEndian.Uint16(body[18:]))
+
+	o := &x11Owner{conn: conn, window: idBase | 1, atoms: make(map[string]uint32), maxRequest: maxRequest}
+
+	// An unmapped input-only window is enough to own a selection
+	window := binary.LittleEndian.AppendUint32(nil, o.window)
+	window = binary.LittleEndian.AppendUint32(window, root)
+	window = append(window, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0)
+	window = binary.LittleEndian.AppendUint16(window, x11InputOnly)
+	window = binary.LittleEndian.AppendUint32(window, 0)
+	window = binary.LittleEndian.AppendUint32(window, 0)
+	if err := o.request(x11CreateWindow, 0, window); err != nil {
+		return nil, err
+	}
+
+	names := append([]string(nil), x11Atoms...)
+	for mime := range SensitiveHints {
+		names = append(names, mime)
+	}
+	for _, name := range names {
+		atom := binary.LittleEndian.AppendUint16(nil, uint16(len(name)))
+		atom = append(atom, 0, 0)
+		atom = append(atom, x11Pad([]byte(name))...)
+		if err := o.request(x11InternAtom, 0, atom); err != nil {
+			return nil, err
+		}
+	}
+	// Replies arrive in request order; nothing else is expected before them
+	for _, name := range names {
+		reply, err := o.readReply()
+		if err != nil {
+			return nil, fmt.Errorf("failed to intern atom %s: %w", name, err)
+		}
+		o.atoms[name] = binary.LittleEndian.Uint32(reply[8:])
+	}
+	o.atoms["STRING"] = x11AtomString
+
+	go o.serve()
+	return o, nil
+}
+
+// Own takes the CLIPBOARD selection and serves offer from now on
+// Offers whose data does not fit in one ChangeProperty request return ErrTooLarge,
+// the owner does not implement the incremental transfer
+func (o *x11Owner) Own(offer Offer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.err != nil {
+		return o.err
+	}
+	for _, mime := range offer.Types() {
+		if data, _ := offer.Data(mime); !o.fits(len(data)) {
+			return fmt.Errorf("%w: %d bytes exceed the X server's request size", ErrTooLarge, len(data))
+		}
+	}
+	o.offer = offer
+	o.owned = true
+
+	args := binary.LittleEndian.AppendUint32(nil, o.window)
+	args = binary.LittleEndian.AppendUint32(args, o.atoms["CLIPBOARD"])
+	args = binary.LittleEndian.AppendUint32(args, 0)
+	return o.request(x11SetSelectionOwner, 0, args)
+}
+
+// Current returns the offered text until another client takes the selection
+func (o *x11Owner) Current() (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.offer.Text, o.owned && o.err == nil
+}
+
+// Close disconnects from the display, which also drops the selection
+func (o *x11Owner) Close() error {
+	return o.conn.Close()
+}
+
+// serve answers selection requests until the connection is closed
+func (o *x11Owner) serve() {
+	event := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(o.conn, event); err != nil {
+			o.mu.Lock()
+			o.err = fmt.Errorf("X11 connection closed: %w", err)
+			o.owned = false
+			o.mu.Unlock()
+			return
+		}
+
+		switch event[0] & 0x7f {
+		case x11Reply:
+			// Not expected after setup; skip the reply's extra data
+			extra := binary.LittleEndian.Uint32(event[4:])
+			if _, err := io.CopyN(io.Discard, o.conn, 4*int64(extra)); err != nil {
+				continue
+			}
+		case x11SelectionClear:
+			o.mu.Lock()
+			o.owned = false
+			o.mu.Unlock()
+		case x11SelectionRequest:
+			o.answer(event)
+		}
+	}
+}
+
+// answer converts the selection to the requested target, or refuses the request
+func (o *x11Owner) answer(event []byte) {
+	time := binary.LittleEndian.Uint32(event[4:])
+	requestor := binary.LittleEndian.Uint32(event[12:])
+	selection := binary.LittleEndian.Uint32(event[16:])
+	target := binary.LittleEndian.Uint32(event[20:])
+	property := binary.LittleEndian.Uint32(event[24:])
+	if property == 0 {
+		// Obsolete clients expect the target as property
+		property = target
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	typ, format, data, ok := o.convert(target)
+	if !ok || !o.owned || selection != o.atoms["CLIPBOARD"] || !o.fits(len(data)) {
+		property = 0
+	} else {
+		args := binary.LittleEndian.AppendUint32(nil, requestor)
+		args = binary.LittleEndian.AppendUint32(args, property)
+		args = binary.LittleEndian.AppendUint32(args, typ)
+		args = append(args, format, 0, 0, 0)
+		args = binary.LittleEndian.AppendUint32(args, uint32(len(data)*8/int(format)))
+		args = append(args, x11Pad(data)...)
+		if err := o.request(x11ChangeProperty, 0, args); err != nil {
+			return
+		}
+	}
+
+	notify := []byte{x11SelectionNotify, 0, 0, 0}
+	notify = binary.LittleEndian.AppendUint32(notify, time)
+	notify = binary.LittleEndian.AppendUint32(notify, requestor)
+	notify = binary.LittleEndian.AppendUint32(notify, selection)
+	notify = binary.LittleEndian.AppendUint32(notify, target)
+	notify = binary.LittleEndian.AppendUint32(notify, property)
+	notify = append(notify, make([]byte, 32-len(notify))...)
+
+	args := binary.LittleEndian.AppendUint32(nil, requestor)
+	args = binary.LittleEndian.AppendUint32(args, 0)
+	_ = o.request(x11SendEvent, 0, append(args, notify...))
+}
+
+// convert returns the property type, format and data for a target atom
+// Must be called with the lock held
+func (o *x11Owner) convert(target uint32) (uint32, byte, []byte, bool) {
+	if target == o.atoms["TARGETS"] {
+		var atoms []byte
+		atoms = binary.LittleEndian.AppendUint32(atoms, o.atoms["TARGETS"])
+		for _, name := range o.offer.Types() {
+			if atom, ok := o.atoms[name]; ok {
+				atoms = binary.LittleEndian.AppendUint32(atoms, atom)
+			}
+		}
+		return x11AtomAtom, 32, atoms, true
+	}
+	for name, atom := range o.atoms {
+		if atom != target {
+			continue
+		}
+		data, ok := o.offer.Data(name)
+		if !ok {
+			return 0, 0, nil, false
+		}
+		typ := target
+		if name == "TEXT" {
+			typ = o.atoms["UTF8_STRING"]
+		}
+		return typ, 8, []byte(data), true
+	}
+	return 0, 0, nil, false
+}
+
+// fits reports whether size bytes of property data fit in one ChangeProperty request,
+// which takes six four-byte units besides the data
+func (o *x11Owner) fits(size int) bool {
+	return 6+(size+3)/4 <= o.maxRequest
+}
+
+// readReply reads the next reply, failing on protocol errors
+func (o *x11Owner) readReply() ([]byte, error) {
+	reply := make([]byte, 32)
+	if _, err := io.ReadFull(o.conn, reply); err != nil {
+		return nil, err
+	}
+	switch reply[0] {
+	case x11Error:
+		return nil, fmt.Errorf("X11 error code %d", reply[1])
+	case x11Reply:
+		extra := make([]byte, 4*int(binary.LittleEndian.Uint32(reply[4:])))
+		if _, err := io.ReadFull(o.conn, extra); err != nil {
+			return nil, err
+		}
+		return append(reply, extra...), nil
+	default:
+		return nil, fmt.Errorf("unexpected X11 event %d", reply[0])
+	}
+}
+
+// request writes a request with the opcode, the data byte and the padded arguments
+// A request over the server's maximum length is not sent, the server would read its
+// truncated length and lose track of the following requests
+func (o *x11Owner) request(opcode, data byte, args []byte) error {
+	args = x11Pad(args)
+	length := 1 + len(args)/4
+	if length > o.maxRequest {
+		return fmt.Errorf("%w: X11 request of %d units exceeds the maximum of %d", ErrTooLarge, length, o.maxRequest)
+	}
+	req := []byte{opcode, data}
+	req = binary.LittleEndian.AppendUint16(req, uint16(length))
+	if _, err := o.conn.Write(append(req, args...)); err != nil {
+		return fmt.Errorf("X11 request failed: %w", err)
+	}
+	return nil
+}
+
+// x11Pad pads b to a multiple of four bytes
+func x11Pad(b []byte) []byte {
+	if n := len(b) % 4; n != 0 {
+		return append(b[:len(b):len(b)], make([]byte, 4-n)...)
+	}
+	return b
+}
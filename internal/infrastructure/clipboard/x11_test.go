@@ -0,0 +1,255 @@
+package clipboard
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeXServer is the server side of an X11 connection, asking the owner for the
+// selection like a clipboard manager does
+type fakeXServer struct {
+	t     *testing.T
+	conn  net.Conn
+	atoms map[string]uint32
+	names map[uint32]string
+}
+
+const (
+	fakeRoot      = 0x100
+	fakeRequestor = 0x400001
+	fakeProperty  = 0x500
+	// fakeMaxRequest is the maximum request length in four-byte units
+	fakeMaxRequest = 1024
+)
+
+// startFakeXServer connects a new owner to a fake server and completes the setup
+func startFakeXServer(t *testing.T) (*fakeXServer, *x11Owner) {
+	t.Helper()
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "X0"))
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	type result struct {
+		owner *x11Owner
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := net.Dial("unix", listener.Addr().String())
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+		owner, err := newX11Owner(conn, "", nil)
+		done <- result{owner, err}
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s := &fakeXServer{t: t, conn: conn, atoms: make(map[string]uint32), names: make(map[uint32]string)}
+
+	setup := make([]byte, 12)
+	s.read(setup)
+	if setup[0] != 'l' || binary.LittleEndian.Uint16(setup[2:]) != 11 {
+		t.Fatalf("setup request = %v, want little endian protocol 11", setup)
+	}
+
+	// Success with one screen and no vendor string or pixmap formats
+	body := make([]byte, 36)
+	binary.LittleEndian.PutUint32(body[4:], 0x400000)
+	binary.LittleEndian.PutUint16(body[18:], fakeMaxRequest)
+	binary.LittleEndian.PutUint32(body[32:], fakeRoot)
+	reply := []byte{1, 0, 11, 0, 0, 0, byte(len(body) / 4), 0}
+	s.write(append(reply, body...))
+
+	opcode, args := s.readRequest()
+	if opcode != x11CreateWindow || binary.LittleEndian.Uint32(args[4:]) != fakeRoot {
+		t.Fatalf("request %d, want CreateWindow on the root window", opcode)
+	}
+
+	for range len(x11Atoms) + len(SensitiveHints) {
+		opcode, args := s.readRequest()
+		if opcode != x11InternAtom {
+			t.Fatalf("request %d, want InternAtom", opcode)
+		}
+		name := string(args[4 : 4+binary.LittleEndian.Uint16(args)])
+		atom := uint32(100 + len(s.atoms))
+		s.atoms[name], s.names[atom] = atom, name
+
+		reply := make([]byte, 32)
+		reply[0] = x11Reply
+		binary.LittleEndian.PutUint32(reply[8:], atom)
+		s.write(reply)
+	}
+	s.atoms["STRING"], s.names[x11AtomString] = x11AtomString, "STRING"
+	s.names[x11AtomAtom] = "ATOM"
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("newX11Owner() error = %v", res.err)
+	}
+	t.Cleanup(func() { res.owner.Close() })
+	return s, res.owner
+}
+
+func (s *fakeXServer) read(b []byte) {
+	s.t.Helper()
+	_ = s.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(s.conn, b); err != nil {
+		s.t.Fatalf("read error = %v", err)
+	}
+}
+
+func (s *fakeXServer) write(b []byte) {
+	s.t.Helper()
+	if _, err := s.conn.Write(b); err != nil {
+		s.t.Fatalf("write error = %v", err)
+	}
+}
+
+// readRequest returns the next request's opcode and arguments
+func (s *fakeXServer) readRequest() (byte, []byte) {
+	s.t.Helper()
+	header := make([]byte, 4)
+	s.read(header)
+	args := make([]byte, 4*int(binary.LittleEndian.Uint16(header[2:]))-4)
+	s.read(args)
+	return header[0], args
+}
+
+// event sends an event with the given code and 32-bit fields after the sequence number
+func (s *fakeXServer) event(code byte, fields ...uint32) {
+	s.t.Helper()
+	event := []byte{code, 0, 0, 0}
+	for _, field := range fields {
+		event = binary.LittleEndian.AppendUint32(event, field)
+	}
+	s.write(append(event, make([]byte, 32-len(event))...))
+}
+
+// convert requests the CLIPBOARD selection as target and returns the property the
+// owner stored, or false when it refused
+func (s *fakeXServer) convert(target string) (typ string, format byte, data []byte, ok bool) {
+	s.t.Helper()
+	s.event(x11SelectionRequest, 0, 0, fakeRequestor, s.atoms["CLIPBOARD"], s.atoms[target], fakeProperty)
+
+	opcode, args := s.readRequest()
+	if opcode == x11ChangeProperty {
+		if binary.LittleEndian.Uint32(args) != fakeRequestor || binary.LittleEndian.Uint32(args[4:]) != fakeProperty {
+			s.t.Fatalf("ChangeProperty on window %#x property %#x", binary.LittleEndian.Uint32(args), binary.LittleEndian.Uint32(args[4:]))
+		}
+		typ, format = s.names[binary.LittleEndian.Uint32(args[8:])], args[12]
+		size := int(binary.LittleEndian.Uint32(args[16:])) * int(format) / 8
+		data = args[20 : 20+size]
+		opcode, args = s.readRequest()
+	}
+	if opcode != x11SendEvent || args[8] != x11SelectionNotify {
+		s.t.Fatalf("request %d, want SendEvent with SelectionNotify", opcode)
+	}
+	property := binary.LittleEndian.Uint32(args[8+20:])
+	return typ, format, data, property == fakeProperty
+}
+
+func TestX11OwnerOffersHint(t *testing.T) {
+	server, owner := startFakeXServer(t)
+
+	if err := owner.Own(Offer{Text: "hunter2", Hints: SensitiveHints}); err != nil {
+		t.Fatalf("Own() error = %v", err)
+	}
+	opcode, args := server.readRequest()
+	if opcode != x11SetSelectionOwner || binary.LittleEndian.Uint32(args[4:]) != server.atoms["CLIPBOARD"] {
+		t.Fatalf("request %d, want SetSelectionOwner on CLIPBOARD", opcode)
+	}
+
+	typ, format, data, ok := server.convert("TARGETS")
+	if !ok || format != 32 || typ != "ATOM" {
+		t.Fatalf("TARGETS = %s/%d ok=%v, want a list of atoms", typ, format, ok)
+	}
+	var targets []string
+	for i := 0; i+4 <= len(data); i += 4 {
+		targets = append(targets, server.names[binary.LittleEndian.Uint32(data[i:])])
+	}
+	for _, want := range []string{"UTF8_STRING", "text/plain;charset=utf-8", "x-kde-passwordManagerHint"} {
+		if !slices.Contains(targets, want) {
+			t.Errorf("TARGETS = %v, missing %s", targets, want)
+		}
+	}
+
+	if _, _, data, ok := server.convert("x-kde-passwordManagerHint"); !ok || string(data) != "secret" {
+		t.Errorf("x-kde-passwordManagerHint = %q ok=%v, want %q", data, ok, "secret")
+	}
+	if typ, _, data, ok := server.convert("UTF8_STRING"); !ok || typ != "UTF8_STRING" || string(data) != "hunter2" {
+		t.Errorf("UTF8_STRING = %s %q ok=%v, want the text", typ, data, ok)
+	}
+
+	// Another client takes the selection: requests are refused from then on
+	server.event(x11SelectionClear, 0, 0, server.atoms["CLIPBOARD"])
+	if _, _, _, ok := server.convert("UTF8_STRING"); ok {
+		t.Error("conversion after SelectionClear succeeded, want it refused")
+	}
+	if _, owned := owner.Current(); owned {
+		t.Error("Current() reports the selection as owned after SelectionClear")
+	}
+}
+
+func TestX11OwnerWithoutHints(t *testing.T) {
+	server, owner := startFakeXServer(t)
+
+	if err := owner.Own(Offer{Text: ""}); err != nil {
+		t.Fatalf("Own() error = %v", err)
+	}
+	server.readRequest()
+
+	if _, _, _, ok := server.convert("x-kde-passwordManagerHint"); ok {
+		t.Error("hint offered for content that is no secret")
+	}
+}
+
+func TestX11OwnerRefusesOversizedOffer(t *testing.T) {
+	server, owner := startFakeXServer(t)
+
+	if err := owner.Own(Offer{Text: "hunter2"}); err != nil {
+		t.Fatalf("Own() error = %v", err)
+	}
+	server.readRequest()
+
+	// Nothing is sent for content a single request cannot carry
+	err := owner.Own(Offer{Text: strings.Repeat("x", 4*fakeMaxRequest)})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Own() error = %v, want ErrTooLarge", err)
+	}
+	if typ, _, data, ok := server.convert("UTF8_STRING"); !ok || typ != "UTF8_STRING" || string(data) != "hunter2" {
+		t.Errorf("UTF8_STRING = %s %q ok=%v, want the previous text", typ, data, ok)
+	}
+}
+
+func TestX11Address(t *testing.T) {
+	tests := []struct {
+		display, network, address string
+	}{
+		{":0", "unix", "/tmp/.X11-unix/X0"},
+		{"unix:1.0", "unix", "/tmp/.X11-unix/X1"},
+		{"localhost:10.0", "tcp", "localhost:6010"},
+	}
+	for _, tt := range tests {
+		network, address, _, err := x11Address(tt.display)
+		if err != nil || network != tt.network || address != tt.address {
+			t.Errorf("x11Address(%q) = %s %s %v, want %s %s", tt.display, network, address, err, tt.network, tt.address)
+		}
+	}
+	if _, _, _, err := x11Address("nonsense"); err == nil {
+		t.Error("x11Address(nonsense) succeeded, want error")
+	}
+}
@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hambosto/passmanager/internal/infrastructure/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// aegisSlotPassword is the key slot type protected by the backup password
+const aegisSlotPassword = 1
+
+// aegisFile is the outer structure of an Aegis vault export
+type aegisFile struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	DB      json.RawMessage `json:"db"`
+}
+
+// aegisHeader holds the key slots and the parameters of the encrypted database
+// Both are null in plain exports
+type aegisHeader struct {
+	Slots  []aegisSlot     `json:"slots"`
+	Params *aegisGCMParams `json:"params"`
+}
+
+// aegisSlot wraps the vault master key with a key derived from a credential
+type aegisSlot struct {
+	Type      int            `json:"type"`
+	Key       string         `json:"key"`
+	KeyParams aegisGCMParams `json:"key_params"`
+	N         int            `json:"n"`
+	R         int            `json:"r"`
+	P         int            `json:"p"`
+	Salt      string         `json:"salt"`
+}
+
+// aegisGCMParams are the hex encoded nonce and tag of an AES-GCM ciphertext
+type aegisGCMParams struct {
+	Nonce string `json:"nonce"`
+	Tag   string `json:"tag"`
+}
+
+// aegisDB is the decrypted database
+type aegisDB struct {
+	Entries []aegisEntry `json:"entries"`
+}
+
+// aegisEntry is one token of the database
+type aegisEntry struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Issuer string `json:"issuer"`
+	Info   struct {
+		Secret  string `json:"secret"`
+		Algo    string `json:"algo"`
+		Digits  int    `json:"digits"`
+		Period  int    `json:"period"`
+		Counter uint64 `json:"counter"`
+		PIN     string `json:"pin"`
+	} `json:"info"`
+}
+
+// aegisImporter reads Aegis Authenticator vault exports, plain or encrypted
+type aegisImporter struct{}
+
+func (aegisImporter) Name() string { return "aegis" }
+
+func (aegisImporter) Detect(data []byte) bool {
+	var file map[string]json.RawMessage
+	if json.Unmarshal(data, &file) != nil {
+		return false
+	}
+	_, hasHeader := file["header"]
+	_, hasDB := file["db"]
+	return hasHeader && hasDB
+}
+
+func (aegisImporter) Encrypted(data []byte) bool {
+	var file aegisFile
+	if json.Unmarshal(data, &file) != nil {
+		return false
+	}
+	return len(file.Header.Slots) > 0
+}
+
+func (imp aegisImporter) Import(data []byte, password string) (*Result, error) {
+	var file aegisFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse Aegis export: %w", err)
+	}
+
+	dbJSON := []byte(file.DB)
+	if len(file.Header.Slots) > 0 {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		var err error
+		if dbJSON, err = imp.decrypt(&file, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var db aegisDB
+	if err := json.Unmarshal(dbJSON, &db); err != nil {
+		return nil, fmt.Errorf("failed to parse Aegis database: %w", err)
+	}
+
+	result := &Result{}
+	for _, entry := range db.Entries {
+		err := result.add(token{
+			kind:      entry.Type,
+			name:      entry.Name,
+			secret:    entry.Info.Secret,
+			algorithm: entry.Info.Algo,
+			digits:    entry.Info.Digits,
+			period:    entry.Info.Period,
+			counter:   entry.Info.Counter,
+			pin:       entry.Info.PIN,
+			issuer:    entry.Issuer,
+			account:   entry.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// decrypt unwraps the master key with the first password slot that accepts
+// the password and decrypts the database with it
+func (aegisImporter) decrypt(file *aegisFile, password string) ([]byte, error) {
+	if file.Header.Params == nil {
+		return nil, fmt.Errorf("invalid Aegis export: missing database parameters")
+	}
+
+	var masterKey []byte
+	for _, slot := range file.Header.Slots {
+		if slot.Type != aegisSlotPassword {
+			continue
+		}
+
+		salt, err := hex.DecodeString(slot.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Aegis key slot: %w", err)
+		}
+		slotKey, err := scrypt.Key([]byte(password), salt, slot.N, slot.R, slot.P, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Aegis key slot: %w", err)
+		}
+
+		wrapped, err := hex.DecodeString(slot.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Aegis key slot: %w", err)
+		}
+		key, err := decryptGCM(slotKey, slot.KeyParams, wrapped)
+		crypto.ZeroBytes(slotKey)
+		if err == nil {
+			masterKey = key
+			break
+		}
+	}
+	if masterKey == nil {
+		return nil, ErrWrongPassword
+	}
+	defer crypto.ZeroBytes(masterKey)
+
+	var encoded string
+	if err := json.Unmarshal(file.DB, &encoded); err != nil {
+		return nil, fmt.Errorf("invalid Aegis export: encrypted database is not a string")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Aegis export: %w", err)
+	}
+
+	plaintext, err := decryptGCM(masterKey, *file.Header.Params, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt Aegis database: %w", err)
+	}
+	return bytes.TrimSpace(plaintext), nil
+}
+
+// decryptGCM decrypts ciphertext whose nonce and tag are stored separately as hex
+func decryptGCM(key []byte, params aegisGCMParams, ciphertext []byte) ([]byte, error) {
+	nonce, err := hex.DecodeString(params.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	tag, err := hex.DecodeString(params.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+
+	// crypto.Decrypt expects nonce + ciphertext + tag
+	sealed := make([]byte, 0, len(nonce)+len(ciphertext)+len(tag))
+	sealed = append(sealed, nonce...)
+	sealed = append(sealed, ciphertext...)
+	sealed = append(sealed, tag...)
+	return crypto.Decrypt(sealed, key)
+}
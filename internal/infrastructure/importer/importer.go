@@ -0,0 +1,128 @@
+// Package importer reads one-time password backups exported by other authenticator apps
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hambosto/passmanager/pkg/totp"
+)
+
+// ErrPasswordRequired is returned when an encrypted backup is imported without a password
+var ErrPasswordRequired = errors.New("backup is encrypted, a password is required")
+
+// ErrWrongPassword is returned when a backup cannot be decrypted with the given password
+var ErrWrongPassword = errors.New("failed to decrypt backup (wrong password?)")
+
+// Result holds the tokens read from a backup
+type Result struct {
+	Configs     []*totp.Config
+	Unsupported []string // names of tokens whose type cannot be imported
+}
+
+// Importer reads the backup format of one authenticator app
+type Importer interface {
+	// Name returns the format name used on the command line
+	Name() string
+	// Detect reports whether data looks like a backup of this format
+	Detect(data []byte) bool
+	// Encrypted reports whether data needs a password to be read
+	Encrypted(data []byte) bool
+	// Import decrypts data if needed and returns its tokens
+	Import(data []byte, password string) (*Result, error)
+}
+
+// importers lists the supported formats in detection order
+var importers = []Importer{
+	aegisImporter{},
+	twoFASImporter{},
+	andOTPImporter{},
+}
+
+// Names returns the names of all supported formats
+func Names() []string {
+	names := make([]string, len(importers))
+	for i, imp := range importers {
+		names[i] = imp.Name()
+	}
+	return names
+}
+
+// Lookup returns the importer for a format name
+func Lookup(name string) (Importer, bool) {
+	for _, imp := range importers {
+		if strings.EqualFold(imp.Name(), name) {
+			return imp, true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the importer that recognizes data
+func Detect(data []byte) (Importer, bool) {
+	for _, imp := range importers {
+		if imp.Detect(data) {
+			return imp, true
+		}
+	}
+	return nil, false
+}
+
+// token holds the fields shared by all backup formats
+type token struct {
+	kind      string // totp, hotp, steam or motp
+	name      string // display name used in error messages
+	secret    string
+	algorithm string
+	digits    int
+	period    int
+	counter   uint64
+	pin       string
+	issuer    string
+	account   string
+}
+
+// add converts t to a generator configuration and appends it to the result
+// Tokens of unknown kinds are recorded as unsupported
+func (r *Result) add(t token) error {
+	var config *totp.Config
+	switch strings.ToLower(t.kind) {
+	case "totp", "":
+		config = totp.DefaultConfig(totp.NormalizeSecret(t.secret))
+	case "hotp":
+		config = totp.DefaultConfig(totp.NormalizeSecret(t.secret))
+		config.Type = totp.TypeHOTP
+		config.Counter = t.counter
+	case "steam":
+		config = totp.SteamConfig(totp.NormalizeSecret(t.secret))
+	case "motp":
+		config = totp.MOTPConfig(t.secret, t.pin)
+	default:
+		r.Unsupported = append(r.Unsupported, fmt.Sprintf("%s (%s)", t.name, t.kind))
+		return nil
+	}
+
+	if config.Encoder == totp.EncoderRFC {
+		if t.algorithm != "" {
+			config.Algorithm = strings.ToUpper(t.algorithm)
+		}
+		if t.digits > 0 {
+			config.Digits = t.digits
+		}
+	}
+	if t.period > 0 && !config.IsHOTP() {
+		config.Period = time.Duration(t.period) * time.Second
+	}
+	if t.issuer != "" {
+		config.Issuer = t.issuer
+	}
+	config.Account = t.account
+
+	if err := config.ValidateConfig(); err != nil {
+		return fmt.Errorf("token %q: %w", t.name, err)
+	}
+	r.Configs = append(r.Configs, config)
+	return nil
+}
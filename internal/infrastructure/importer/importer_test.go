@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/hambosto/passmanager/pkg/totp"
+)
+
+const testPassword = "backup password"
+
+// seal encrypts plaintext with AES-256-GCM and returns ciphertext and tag separately
+func seal(t *testing.T, key, nonce, plaintext []byte) (ciphertext, tag []byte) {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := gcm.Seal(nil, nonce, plaintext, nil)
+	return sealed[:len(sealed)-16], sealed[len(sealed)-16:]
+}
+
+const aegisDBJSON = `{
+	"version": 2,
+	"entries": [
+		{"type": "totp", "uuid": "1", "name": "alice@example.com", "issuer": "Example",
+		 "info": {"secret": "JBSWY3DPEHPK3PXP", "algo": "SHA256", "digits": 8, "period": 60}},
+		{"type": "hotp", "uuid": "2", "name": "bob", "issuer": "Bank",
+		 "info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA1", "digits": 6, "counter": 5}},
+		{"type": "steam", "uuid": "3", "name": "gaben", "issuer": "Steam",
+		 "info": {"secret": "ON2XAZLSMR2XAZLSONSWG4TFOQ", "algo": "SHA1", "digits": 5, "period": 30}},
+		{"type": "motp", "uuid": "4", "name": "carol", "issuer": "VPN",
+		 "info": {"secret": "e3152afee62599c8", "pin": "1234", "algo": "MD5", "digits": 6, "period": 10}},
+		{"type": "yandex", "uuid": "5", "name": "dave", "issuer": "Yandex",
+		 "info": {"secret": "JBSWY3DPEHPK3PXP", "pin": "1234", "algo": "SHA256", "digits": 8, "period": 30}}
+	]
+}`
+
+// encryptedAegis builds an Aegis export protected by testPassword
+func encryptedAegis(t *testing.T) []byte {
+	t.Helper()
+	masterKey := make([]byte, 32)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	salt := []byte("0123456789abcdef0123456789abcdef")
+	slotKey, err := scrypt.Key([]byte(testPassword), salt, 1024, 8, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slotNonce := []byte("slot nonce12")
+	wrapped, slotTag := seal(t, slotKey, slotNonce, masterKey)
+
+	dbNonce := []byte("db nonce 123")
+	db, dbTag := seal(t, masterKey, dbNonce, []byte(aegisDBJSON))
+
+	file := map[string]any{
+		"version": 1,
+		"header": map[string]any{
+			"slots": []map[string]any{
+				// A biometric slot the importer must skip
+				{"type": 2, "key": "00", "key_params": map[string]string{"nonce": "00", "tag": "00"}},
+				{
+					"type": 1, "key": hex.EncodeToString(wrapped),
+					"key_params": map[string]string{"nonce": hex.EncodeToString(slotNonce), "tag": hex.EncodeToString(slotTag)},
+					"n":          1024, "r": 8, "p": 1, "salt": hex.EncodeToString(salt),
+				},
+			},
+			"params": map[string]string{"nonce": hex.EncodeToString(dbNonce), "tag": hex.EncodeToString(dbTag)},
+		},
+		"db": base64.StdEncoding.EncodeToString(db),
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAegisImport(t *testing.T) {
+	data := encryptedAegis(t)
+
+	imp, ok := Detect(data)
+	if !ok || imp.Name() != "aegis" {
+		t.Fatalf("Detect() = %v, want aegis", imp)
+	}
+	if !imp.Encrypted(data) {
+		t.Fatal("Encrypted() = false, want true")
+	}
+
+	if _, err := imp.Import(data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("Import() without password error = %v, want ErrPasswordRequired", err)
+	}
+	if _, err := imp.Import(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Import() with wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	result, err := imp.Import(data, testPassword)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if len(result.Unsupported) != 1 || result.Unsupported[0] != "dave (yandex)" {
+		t.Errorf("Unsupported = %v, want [dave (yandex)]", result.Unsupported)
+	}
+	if len(result.Configs) != 4 {
+		t.Fatalf("configs = %d, want 4", len(result.Configs))
+	}
+
+	totpConfig, hotpConfig, steamConfig, motpConfig := result.Configs[0], result.Configs[1], result.Configs[2], result.Configs[3]
+	if totpConfig.Algorithm != "SHA256" || totpConfig.Digits != 8 || totpConfig.Period != 60*time.Second ||
+		totpConfig.Issuer != "Example" || totpConfig.Account != "alice@example.com" {
+		t.Errorf("totp = %+v", totpConfig)
+	}
+	if hotpConfig.Type != totp.TypeHOTP || hotpConfig.Counter != 5 {
+		t.Errorf("hotp = %+v", hotpConfig)
+	}
+	if steamConfig.Encoder != totp.EncoderSteam {
+		t.Errorf("steam encoder = %q", steamConfig.Encoder)
+	}
+	if motpConfig.Encoder != totp.EncoderMOTP || motpConfig.PIN != "1234" || motpConfig.Secret != "e3152afee62599c8" {
+		t.Errorf("motp = %+v", motpConfig)
+	}
+}
+
+func TestAegisPlainImport(t *testing.T) {
+	data := []byte(`{"version": 1, "header": {"slots": null, "params": null}, "db": ` + aegisDBJSON + `}`)
+
+	imp, ok := Detect(data)
+	if !ok || imp.Name() != "aegis" || imp.Encrypted(data) {
+		t.Fatalf("Detect() = %v, want plain aegis", imp)
+	}
+	result, err := imp.Import(data, "")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Configs) != 4 {
+		t.Errorf("configs = %d, want 4", len(result.Configs))
+	}
+}
+
+const twoFASServicesJSON = `[
+	{"name": "GitHub", "secret": "JBSWY3DPEHPK3PXP",
+	 "otp": {"account": "octocat", "issuer": "GitHub", "digits": 6, "period": 30, "algorithm": "SHA1", "tokenType": "TOTP"}},
+	{"name": "Steam", "secret": "ON2XAZLSMR2XAZLSONSWG4TFOQ",
+	 "otp": {"account": "gaben", "digits": 5, "period": 30, "algorithm": "SHA1", "tokenType": "STEAM"}}
+]`
+
+func TestTwoFASImport(t *testing.T) {
+	salt := []byte("2fas salt 0123456789")
+	iv := []byte("2fas iv 1234")
+	key, err := pbkdf2.Key(sha256.New, testPassword, salt, twoFASIterations, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, tag := seal(t, key, iv, []byte(twoFASServicesJSON))
+
+	encoded := base64.StdEncoding.EncodeToString(append(ciphertext, tag...)) + ":" +
+		base64.StdEncoding.EncodeToString(salt) + ":" + base64.StdEncoding.EncodeToString(iv)
+	data := []byte(`{"services": [], "groups": [], "schemaVersion": 4, "servicesEncrypted": "` + encoded + `"}`)
+
+	imp, ok := Detect(data)
+	if !ok || imp.Name() != "2fas" || !imp.Encrypted(data) {
+		t.Fatalf("Detect() = %v, want encrypted 2fas", imp)
+	}
+	if _, err := imp.Import(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Import() with wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	result, err := imp.Import(data, testPassword)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Configs) != 2 {
+		t.Fatalf("configs = %d, want 2", len(result.Configs))
+	}
+	if got := result.Configs[0]; got.Issuer != "GitHub" || got.Account != "octocat" || got.Secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("GitHub = %+v", got)
+	}
+	// The service name stands in for a missing issuer
+	if got := result.Configs[1]; got.Encoder != totp.EncoderSteam || got.Issuer != "Steam" {
+		t.Errorf("Steam = %+v", got)
+	}
+}
+
+const andOTPJSON = `[
+	{"secret": "JBSWY3DPEHPK3PXP", "issuer": "GitLab", "label": "dev@example.com", "digits": 6,
+	 "type": "TOTP", "algorithm": "SHA512", "period": 30, "thumbnail": "Default", "tags": []},
+	{"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "issuer": "", "label": "Bank - bob", "digits": 8,
+	 "type": "HOTP", "algorithm": "SHA1", "counter": 9, "tags": []}
+]`
+
+func TestAndOTPImport(t *testing.T) {
+	const iterations = 1000
+	salt := []byte("andotp salt!")
+	iv := []byte("andotp iv 12")
+	key, err := pbkdf2.Key(sha1.New, testPassword, salt, iterations, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, tag := seal(t, key, iv, []byte(andOTPJSON))
+
+	data := binary.BigEndian.AppendUint32(nil, iterations)
+	data = append(data, salt...)
+	data = append(data, iv...)
+	data = append(data, ciphertext...)
+	data = append(data, tag...)
+
+	imp, ok := Detect(data)
+	if !ok || imp.Name() != "andotp" || !imp.Encrypted(data) {
+		t.Fatalf("Detect() = %v, want encrypted andotp", imp)
+	}
+	if _, err := imp.Import(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Import() with wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	result, err := imp.Import(data, testPassword)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Configs) != 2 {
+		t.Fatalf("configs = %d, want 2", len(result.Configs))
+	}
+	if got := result.Configs[0]; got.Algorithm != "SHA512" || got.Issuer != "GitLab" || got.Account != "dev@example.com" {
+		t.Errorf("GitLab = %+v", got)
+	}
+	if got := result.Configs[1]; got.Type != totp.TypeHOTP || got.Counter != 9 || got.Digits != 8 ||
+		got.Issuer != "Bank" || got.Account != "bob" {
+		t.Errorf("Bank = %+v", got)
+	}
+
+	// Plain backups are detected too
+	plain, ok := Detect([]byte(andOTPJSON))
+	if !ok || plain.Name() != "andotp" || plain.Encrypted([]byte(andOTPJSON)) {
+		t.Errorf("Detect(plain) = %v, want plain andotp", plain)
+	}
+}
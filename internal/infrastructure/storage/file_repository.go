@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 
 	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/domain/repository"
 	"github.com/hambosto/passmanager/internal/infrastructure/crypto"
 )
 
@@ -33,13 +34,24 @@ func NewFileRepository(path string) *FileRepository {
 
 // Save encrypts and saves the vault to a file
 // File format: [Header: 8 bytes][Version: 4 bytes][KDF Params Length: 4 bytes][KDF Params][Encrypted Data]
-func (r *FileRepository) Save(vault *entity.Vault, key []byte, kdfParams *crypto.KeyDerivationParams) error {
+func (r *FileRepository) Save(vault *entity.Vault, key []byte, kdfParams *crypto.KeyDerivationParams) (err error) {
 	// Ensure directory exists
 	dir := filepath.Dir(r.path)
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	if err := r.checkRevision(vault, key); err != nil {
+		return err
+	}
+	revision := vault.Revision
+	vault.Revision++
+	defer func() {
+		if err != nil {
+			vault.Revision = revision
+		}
+	}()
+
 	// Serialize vault to JSON
 	vaultJSON, err := json.Marshal(vault)
 	if err != nil {
@@ -102,6 +114,20 @@ func (r *FileRepository) Save(vault *entity.Vault, key []byte, kdfParams *crypto
 	return nil
 }
 
+// checkRevision makes sure the file holds the revision vault was loaded at
+// A missing file, or one the key does not open such as before a password change, is
+// not compared
+func (r *FileRepository) checkRevision(vault *entity.Vault, key []byte) error {
+	stored, err := r.Load(key)
+	if err != nil {
+		return nil
+	}
+	if stored.Revision != vault.Revision {
+		return fmt.Errorf("%w (revision %d on disk, %d loaded)", repository.ErrVaultChanged, stored.Revision, vault.Revision)
+	}
+	return nil
+}
+
 // Load decrypts and loads the vault from a file
 // Returns: vault, kdfParams, error
 func (r *FileRepository) Load(key []byte) (*entity.Vault, error) {
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+	"github.com/hambosto/passmanager/internal/infrastructure/storage"
+)
+
+// runAgent starts the agent or controls a running one
+func (c *CLI) runAgent(args []string) error {
+	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	timeout := flags.Int("timeout", c.config.Security.AutoLockTimeout, "lock after this many minutes without requests (0 = never)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	action := "start"
+	if flags.NArg() > 0 {
+		action = flags.Arg(0)
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: passmanager agent [--timeout MINUTES] [start|status|unlock|lock|stop]")
+	}
+
+	path := agent.SocketPath()
+	client := agent.NewClient(path)
+	switch action {
+	case "start":
+		return c.startAgent(path, time.Duration(*timeout)*time.Minute)
+	case "status":
+		unlocked, lockIn, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("no agent running on %s: %w", path, err)
+		}
+		switch {
+		case !unlocked:
+			fmt.Fprintf(c.stdout, "Agent on %s is locked\n", path)
+		case lockIn > 0:
+			fmt.Fprintf(c.stdout, "Agent on %s is unlocked, locks in %s\n", path, lockIn)
+		default:
+			fmt.Fprintf(c.stdout, "Agent on %s is unlocked\n", path)
+		}
+		return nil
+	case "unlock":
+		password, err := c.readPassword("Master password: ")
+		if err != nil {
+			return err
+		}
+		return client.Unlock(password)
+	case "lock":
+		return client.Lock()
+	case "stop":
+		return client.Stop()
+	}
+	return fmt.Errorf("unknown agent action %q, use start, status, unlock, lock or stop", action)
+}
+
+// startAgent unlocks the vault and serves it until the agent is stopped or interrupted
+func (c *CLI) startAgent(path string, timeout time.Duration) error {
+	repo := storage.NewFileRepository(c.config.Storage.VaultPath)
+	if !repo.Exists() {
+		return fmt.Errorf("no vault found at %s, run passmanager to create one", repo.GetPath())
+	}
+	if _, _, err := agent.NewClient(path).Status(); err == nil {
+		return fmt.Errorf("an agent is already running on %s", path)
+	}
+
+	password, err := c.readPassword("Master password: ")
+	if err != nil {
+		return err
+	}
+	agentService := service.NewAgentService(repo, timeout)
+	if err := agentService.Unlock(password); err != nil {
+		return err
+	}
+	defer agentService.Lock()
+
+	server, err := agent.Listen(path, agentService)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-signals:
+				_ = server.Close()
+				return
+			case <-ticker.C:
+				if agentService.LockIfIdle() {
+					fmt.Fprintf(c.stderr, "Locked after %s without requests\n", timeout)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	fmt.Fprintf(c.stderr, "Agent listening on %s\n", path)
+	return server.Serve()
+}
+
+// openAgent returns a client of the running agent, unlocking it first when it is
+// locked, or without an agent a client serving the vault in this process
+func (c *CLI) openAgent() (*agent.Client, error) {
+	client := agent.NewClient(agent.SocketPath())
+	unlocked, _, err := client.Status()
+	if err == nil {
+		if !unlocked {
+			password, err := c.readPassword("Master password (agent is locked): ")
+			if err != nil {
+				return nil, err
+			}
+			if err := client.Unlock(password); err != nil {
+				return nil, err
+			}
+		}
+		return client, nil
+	}
+	if errors.Is(err, agent.ErrForbidden) || errors.Is(err, agent.ErrUntrusted) {
+		return nil, err
+	}
+
+	repo := storage.NewFileRepository(c.config.Storage.VaultPath)
+	if !repo.Exists() {
+		return nil, fmt.Errorf("no vault found at %s, run passmanager to create one", repo.GetPath())
+	}
+	password, err := c.readPassword("Master password: ")
+	if err != nil {
+		return nil, err
+	}
+	local := service.NewAgentService(repo, 0)
+	if err := local.Unlock(password); err != nil {
+		return nil, err
+	}
+	return agent.NewLocalClient(local), nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+)
+
+// runAudit prints a security report for the vault
+func (c *CLI) runAudit(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	breachPath := flags.String("breaches", c.config.Security.BreachCatalogPath, "path to a HIBP breaches.json catalogue")
+	directoryPath := flags.String("2fa-directory", c.config.Security.TwoFactorDirectoryPath, "path to a 2fa.directory JSON file")
+	showAll := flags.Bool("all", false, "include dismissed findings")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	security := service.NewSecurityService()
+	if *breachPath != "" {
+		if err := security.LoadBreachCatalog(*breachPath); err != nil {
+			return err
+		}
+	}
+	if *directoryPath != "" {
+		if err := security.LoadTwoFactorDirectory(*directoryPath); err != nil {
+			return err
+		}
+	}
+
+	vault, _, err := c.unlockVault()
+	if err != nil {
+		return err
+	}
+
+	score := security.CalculateSecurityScore(vault)
+
+	var findings []service.Finding
+	for _, finding := range security.Audit(vault) {
+		if finding.Dismissed && !*showAll {
+			continue
+		}
+		findings = append(findings, finding)
+	}
+
+	fmt.Fprintf(c.stdout, "Security score: %.0f/100\n", score)
+	if len(findings) == 0 {
+		fmt.Fprintln(c.stdout, "No issues found.")
+		return nil
+	}
+
+	for i, finding := range findings {
+		if i == 0 || findings[i-1].Kind != finding.Kind {
+			fmt.Fprintf(c.stdout, "\n%s:\n", finding.Kind)
+		}
+		name := finding.Entry.Name
+		if finding.Dismissed {
+			name += " (dismissed)"
+		}
+		fmt.Fprintf(c.stdout, "  - %s: %s\n", name, finding.Detail)
+	}
+
+	return nil
+}
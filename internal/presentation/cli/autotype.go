@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/infrastructure/autotype"
+)
+
+// runAutoType types an entry into the window focused after the start delay
+// Bound to a desktop shortcut it fills the window that was active when pressed
+func (c *CLI) runAutoType(args []string) error {
+	settings := c.config.AutoType
+
+	flags := flag.NewFlagSet("type", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	sequence := flags.String("sequence", "", "keystrokes to type instead of the entry's sequence")
+	backend := flags.String("backend", settings.Backend, "auto, xdotool, ydotool or wtype")
+	delay := flags.Int("delay", settings.StartDelay, "seconds to wait before typing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: passmanager type [--sequence SEQ] [--backend NAME] [--delay SECONDS] NAME")
+	}
+
+	typer, err := autotype.NewTyper(*backend)
+	if err != nil {
+		return err
+	}
+
+	vault, vaultService, err := c.unlockVault()
+	if err != nil {
+		return err
+	}
+	entry, err := service.FindEntryByName(vault, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *sequence == "" {
+		*sequence = service.AutoTypeSequence(entry, settings.DefaultSequence)
+	}
+	if _, err := service.ParseAutoTypeSequence(*sequence); err != nil {
+		return fmt.Errorf("invalid auto-type sequence: %w", err)
+	}
+
+	if *delay > 0 {
+		fmt.Fprintf(c.stderr, "Typing %s in %ds, focus the target window\n", entry.Name, *delay)
+		time.Sleep(time.Duration(*delay) * time.Second)
+	}
+
+	autoType := service.NewAutoTypeService(typer)
+	autoType.SetKeyDelay(time.Duration(settings.KeyDelay) * time.Millisecond)
+	job, err := autoType.Prepare(entry, *sequence)
+	if err != nil {
+		return err
+	}
+	typeErr := autoType.Run(job)
+
+	// Counter-based codes are used up even if typing failed, so persist the advanced counter
+	if job.CounterAdvanced {
+		if err := vaultService.SaveVault(vault); err != nil {
+			return err
+		}
+	}
+	return typeErr
+}
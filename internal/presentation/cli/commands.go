@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/hambosto/passmanager/config"
+)
+
+// CLI runs non-interactive commands against the vault
+type CLI struct {
+	config *config.Config
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command describes a CLI subcommand
+type command struct {
+	usage       string
+	description string
+	run         func(c *CLI, args []string) error
+}
+
+// commands lists all available subcommands by name
+var commands = map[string]command{
+	"agent": {
+		usage:       "agent [--timeout MINUTES] [start|status|unlock|lock|stop]",
+		description: "Keep the vault unlocked for other commands and the interface",
+		run:         (*CLI).runAgent,
+	},
+	"list": {
+		usage:       "list",
+		description: "List the names, usernames and websites of all entries",
+		run:         (*CLI).runList,
+	},
+	"get": {
+		usage:       "get [--field FIELD] NAME",
+		description: "Print the password or another field of an entry",
+		run:         (*CLI).runGet,
+	},
+	"add": {
+		usage:       "add [--username USER] [--uri URI] [--generate] NAME",
+		description: "Add a login entry",
+		run:         (*CLI).runAdd,
+	},
+	"audit": {
+		usage:       "audit [--breaches FILE] [--2fa-directory FILE] [--all]",
+		description: "Report weak, reused, breached and insecure entries",
+		run:         (*CLI).runAudit,
+	},
+	"git-credential": {
+		usage:       "git-credential get|store|erase",
+		description: "Give git HTTPS credentials from the vault (credential helper)",
+		run:         (*CLI).runGitCredential,
+	},
+	"import-otp": {
+		usage:       "import-otp [--format FORMAT] FILE",
+		description: "Add one-time passwords from Aegis, 2FAS or andOTP backups",
+		run:         (*CLI).runImportOTP,
+	},
+	"import-qr": {
+		usage:       "import-qr [--entry NAME] IMAGE|URI...",
+		description: "Add one-time passwords from QR codes or Google Authenticator exports",
+		run:         (*CLI).runImportQR,
+	},
+	"run": {
+		usage:       "run [--mask] -- COMMAND [ARGS...]",
+		description: "Run a command with pm:// references in its environment resolved",
+		run:         (*CLI).runRun,
+	},
+	"inject": {
+		usage:       "inject [-i TEMPLATE] [-o FILE]",
+		description: "Render the {{ pm://... }} references of a template",
+		run:         (*CLI).runInject,
+	},
+	"qr": {
+		usage:       "qr [--png FILE] [--size N] NAME",
+		description: "Show the provisioning QR code of an entry's one-time password",
+		run:         (*CLI).runQR,
+	},
+	"username": {
+		usage:       "username [--mode MODE] [--site SITE | --entry NAME [--save]]",
+		description: "Generate a username or email alias",
+		run:         (*CLI).runUsername,
+	},
+	"type": {
+		usage:       "type [--sequence SEQ] [--delay SECONDS] NAME",
+		description: "Auto-type an entry into the focused window",
+		run:         (*CLI).runAutoType,
+	},
+	"totp": {
+		usage:       "totp [--uri] NAME | --secret SECRET",
+		description: "Print the current one-time password of an entry",
+		run:         (*CLI).runTOTP,
+	},
+}
+
+// New creates a CLI bound to the process standard streams
+func New(cfg *config.Config) *CLI {
+	return &CLI{
+		config: cfg,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// IsCommand reports whether name is a known CLI subcommand
+func IsCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "--help" {
+		return true
+	}
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand named by args[0]
+func (c *CLI) Run(args []string) error {
+	if len(args) == 0 {
+		c.printUsage()
+		return nil
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		c.printUsage()
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		c.printUsage()
+		return fmt.Errorf("unknown command: %s", name)
+	}
+
+	return cmd.run(c, args[1:])
+}
+
+// printUsage prints the list of subcommands
+func (c *CLI) printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(c.stdout, "Usage: passmanager [command] [options]")
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Run without a command to start the interactive interface.")
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Commands:")
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(c.stdout, "  %-36s %s\n", cmd.usage, cmd.description)
+	}
+}
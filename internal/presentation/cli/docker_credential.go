@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/hambosto/passmanager/pkg/dockercredential"
+)
+
+// RunDockerCredential implements docker's credential helper protocol for the
+// docker-credential-passmanager entry point, keeping logins in the Docker folder
+func (c *CLI) RunDockerCredential(args []string) error {
+	if len(args) != 1 || !slices.Contains(dockercredential.Actions, args[0]) {
+		return fmt.Errorf("usage: docker-credential-passmanager %s", strings.Join(dockercredential.Actions, "|"))
+	}
+
+	// Read docker's input before a password prompt could consume it
+	input, err := io.ReadAll(io.LimitReader(c.stdin, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+	output, err := client.DockerCredential(args[0], string(input))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(c.stdout, output)
+	return err
+}
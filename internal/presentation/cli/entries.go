@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+)
+
+// runList prints the names, usernames and websites of all entries
+func (c *CLI) runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: passmanager list")
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+	entries, err := client.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Name, entry.Username, entry.URI)
+	}
+	return w.Flush()
+}
+
+// runGet prints a field of an entry, the password unless --field is given
+func (c *CLI) runGet(args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	field := flags.String("field", "password", "field to print: username, password, uri, notes or a custom field name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: passmanager get [--field FIELD] NAME")
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+	entry, err := client.Get(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	value, err := service.EntryField(entry, *field)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, value)
+	return nil
+}
+
+// runAdd adds a login entry, reading the password from the terminal unless one is generated
+func (c *CLI) runAdd(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	username := flags.String("username", "", "username of the login")
+	uri := flags.String("uri", "", "website of the login")
+	notes := flags.String("notes", "", "notes")
+	generate := flags.Bool("generate", false, "generate the password with the configured generator settings and print it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || strings.TrimSpace(flags.Arg(0)) == "" {
+		return fmt.Errorf("usage: passmanager add [--username USER] [--uri URI] [--notes TEXT] [--generate] NAME")
+	}
+
+	entry := entity.NewEntry(entity.EntryTypeLogin, strings.TrimSpace(flags.Arg(0)))
+	entry.Username = *username
+	entry.URI = *uri
+	entry.Notes = *notes
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if *generate {
+		password, err = service.GeneratePassword(c.passwordConfig())
+	} else {
+		password, err = c.readPassword(fmt.Sprintf("Password for %s: ", entry.Name))
+	}
+	if err != nil {
+		return err
+	}
+	entry.SetPassword(password)
+
+	if err := client.Add(entry); err != nil {
+		return err
+	}
+	if *generate {
+		fmt.Fprintln(c.stdout, password)
+	}
+	fmt.Fprintf(c.stderr, "Added %s\n", entry.Name)
+	return nil
+}
+
+// passwordConfig returns the configured password generator settings
+func (c *CLI) passwordConfig() service.PasswordConfig {
+	cfg := service.DefaultPasswordConfig()
+	generator := c.config.PasswordGenerator
+	if generator.Length > 0 {
+		cfg.Length = generator.Length
+	}
+	cfg.IncludeUpper = generator.IncludeUppercase
+	cfg.IncludeLower = generator.IncludeLowercase
+	cfg.IncludeNumbers = generator.IncludeNumbers
+	cfg.IncludeSymbols = generator.IncludeSymbols
+	cfg.ExcludeAmbiguous = generator.ExcludeAmbiguous
+	cfg.MinUpper = generator.MinUppercase
+	cfg.MinLower = generator.MinLowercase
+	cfg.MinNumbers = generator.MinNumbers
+	cfg.MinSymbols = generator.MinSymbols
+	return cfg
+}
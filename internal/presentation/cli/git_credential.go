@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// runGitCredential implements git's credential helper protocol on stdin and stdout
+// Configure it with: git config --global credential.helper "!passmanager git-credential"
+func (c *CLI) runGitCredential(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: passmanager git-credential get|store|erase")
+	}
+
+	operation := args[0]
+	switch operation {
+	case "get", "store", "erase":
+	default:
+		// Helpers ignore operations added to git later
+		return nil
+	}
+
+	// Read git's input before a password prompt could consume it
+	input, err := io.ReadAll(io.LimitReader(c.stdin, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+	output, messages, err := client.GitCredential(operation, string(input))
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		fmt.Fprintln(c.stderr, message)
+	}
+	_, err = io.WriteString(c.stdout, output)
+	return err
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/infrastructure/importer"
+)
+
+// runImportOTP adds the one-time passwords of an authenticator app backup to the vault
+// The format is detected from the file contents unless --format is given
+func (c *CLI) runImportOTP(args []string) error {
+	flags := flag.NewFlagSet("import-otp", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	format := flags.String("format", "", "backup format: "+strings.Join(importer.Names(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: passmanager import-otp [--format FORMAT] FILE")
+	}
+
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	var (
+		imp importer.Importer
+		ok  bool
+	)
+	if *format != "" {
+		imp, ok = importer.Lookup(*format)
+		if !ok {
+			return fmt.Errorf("unknown format %q (supported: %s)", *format, strings.Join(importer.Names(), ", "))
+		}
+	} else if imp, ok = importer.Detect(data); !ok {
+		return fmt.Errorf("%s: unrecognized backup format, use --format", path)
+	}
+
+	var password string
+	if imp.Encrypted(data) {
+		if password, err = c.readPassword("Backup password: "); err != nil {
+			return err
+		}
+	}
+
+	result, err := imp.Import(data, password)
+	if err != nil {
+		if errors.Is(err, importer.ErrWrongPassword) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	vault, vaultService, err := c.unlockVault()
+	if err != nil {
+		return err
+	}
+
+	imported := service.NewTOTPService().ImportAccounts(vault, result.Configs)
+	if len(imported.Created)+len(imported.Updated) > 0 {
+		if err := vaultService.SaveVault(vault); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range imported.Created {
+		fmt.Fprintf(c.stdout, "Created %s %q\n", entry.TOTP.Label(), entry.Name)
+	}
+	for _, entry := range imported.Updated {
+		fmt.Fprintf(c.stdout, "Updated %s %q\n", entry.TOTP.Label(), entry.Name)
+	}
+	for _, name := range result.Unsupported {
+		fmt.Fprintf(c.stderr, "Skipped unsupported token %s\n", name)
+	}
+	fmt.Fprintf(c.stdout, "Imported %d account(s) from %s backup, %d already in the vault\n",
+		len(imported.Created)+len(imported.Updated), imp.Name(), imported.Skipped)
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/pkg/qrcode"
+	"github.com/hambosto/passmanager/pkg/totp"
+)
+
+// runImportQR adds one-time passwords from QR code screenshots or URIs to the vault
+// Google Authenticator exports may span several images, which are merged before import
+func (c *CLI) runImportQR(args []string) error {
+	flags := flag.NewFlagSet("import-qr", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	entryName := flags.String("entry", "", "add the code to this existing entry instead of creating one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: passmanager import-qr [--entry NAME] IMAGE|URI...")
+	}
+
+	totpService := service.NewTOTPService()
+
+	var (
+		configs []*totp.Config
+		batches []*totp.MigrationBatch
+	)
+	for _, arg := range flags.Args() {
+		text := arg
+		if qrcode.IsImagePath(arg) {
+			var err error
+			if text, err = totpService.ReadQRImage(arg); err != nil {
+				return fmt.Errorf("%s: %w", arg, err)
+			}
+		}
+
+		if totp.IsMigrationURI(text) {
+			batch, err := totp.ParseMigrationURI(text)
+			if err != nil {
+				return fmt.Errorf("%s: %w", arg, err)
+			}
+			batches = append(batches, batch)
+			continue
+		}
+
+		config, err := totp.Parse(text)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		configs = append(configs, config)
+	}
+
+	exported, err := totp.MergeMigrationBatches(batches)
+	if err != nil {
+		return err
+	}
+	configs = append(configs, exported...)
+
+	if *entryName != "" && len(configs) != 1 {
+		return fmt.Errorf("--entry needs exactly one account, found %d", len(configs))
+	}
+
+	vault, vaultService, err := c.unlockVault()
+	if err != nil {
+		return err
+	}
+
+	if *entryName != "" {
+		entry, err := service.FindEntryByName(vault, *entryName)
+		if err != nil {
+			return err
+		}
+		cfg := totpService.ToEntryConfig(configs[0])
+		totpService.ApplyLabel(entry, cfg)
+		entry.TOTP = cfg
+		entry.TOTPSecret = ""
+		entry.Update()
+		vault.Update()
+		if err := vaultService.SaveVault(vault); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Added %s to %q\n", cfg.Label(), entry.Name)
+		return nil
+	}
+
+	result := totpService.ImportAccounts(vault, configs)
+	if len(result.Created)+len(result.Updated) > 0 {
+		if err := vaultService.SaveVault(vault); err != nil {
+			return err
+		}
+	}
+
+	printEntries := func(verb string, entries []*entity.Entry) {
+		for _, entry := range entries {
+			fmt.Fprintf(c.stdout, "%s %s %q\n", verb, entry.TOTP.Label(), entry.Name)
+		}
+	}
+	printEntries("Created", result.Created)
+	printEntries("Updated", result.Updated)
+	fmt.Fprintf(c.stdout, "Imported %d account(s), %d already in the vault\n",
+		len(result.Created)+len(result.Updated), result.Skipped)
+	return nil
+}
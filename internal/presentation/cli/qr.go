@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/pkg/qrcode"
+)
+
+// runQR shows the provisioning QR code of an entry's one-time password
+func (c *CLI) runQR(args []string) error {
+	flags := flag.NewFlagSet("qr", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	pngPath := flags.String("png", "", "write the QR code to this PNG file instead of the terminal")
+	size := flags.Int("size", 512, "PNG width in pixels")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: passmanager qr [--png FILE] [--size N] NAME")
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+	entry, err := client.Get(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !entry.HasTOTP() {
+		return fmt.Errorf("entry %q has no one-time password", entry.Name)
+	}
+
+	uri := service.NewTOTPService().ToGeneratorConfig(entry.TOTP).ToURI()
+
+	if *pngPath != "" {
+		// The image holds the secret, so keep it private to the user
+		file, err := os.OpenFile(*pngPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *pngPath, err)
+		}
+		if err := qrcode.WritePNG(file, uri, *size); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *pngPath, err)
+		}
+		fmt.Fprintf(c.stderr, "QR code written to %s\n", *pngPath)
+		return nil
+	}
+
+	// Fit the code to the terminal when stdout is one, otherwise print it unscaled
+	cols, rows := 0, 0
+	if width, height, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		cols, rows = width, height-1
+	}
+	code, err := qrcode.Terminal(uri, cols, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, code)
+	return nil
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+)
+
+// ExitError ends passmanager with the exit status of a command it ran
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// runRun runs a command with pm:// references in its environment replaced by the
+// values they point to, optionally masking those values in its output
+func (c *CLI) runRun(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	mask := flags.Bool("mask", false, "replace resolved values in the command's output with "+service.MaskedSecret)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: passmanager run [--mask] -- COMMAND [ARGS...]")
+	}
+
+	env := os.Environ()
+	var secrets []string
+	if service.HasSecretReferences(env) {
+		client, err := c.openAgent()
+		if err != nil {
+			return err
+		}
+		if env, secrets, err = service.NewAgentSecretResolver(client).ResolveEnv(env); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command(flags.Arg(0), flags.Args()[1:]...)
+	cmd.Env = env
+	cmd.Stdin = c.stdin
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+
+	var writers []*service.MaskingWriter
+	if *mask && len(secrets) > 0 {
+		stdout := service.NewMaskingWriter(c.stdout, secrets)
+		stderr := service.NewMaskingWriter(c.stderr, secrets)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		writers = append(writers, stdout, stderr)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Ctrl+C reaches the command from the terminal and it decides how to handle it,
+	// passmanager waits for it; termination requests are passed on
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGTERM {
+				_ = cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	for _, writer := range writers {
+		_ = writer.Flush()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// A command killed by a signal ends passmanager like a shell would, with 128+signal
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return &ExitError{Code: 128 + int(status.Signal())}
+		}
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// runInject renders the {{ pm://... }} references of a template into a file
+func (c *CLI) runInject(args []string) error {
+	flags := flag.NewFlagSet("inject", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	input := flags.String("i", "", "template to read, stdin if empty")
+	output := flags.String("o", "", "file to write with mode 0600, stdout if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: passmanager inject [-i TEMPLATE] [-o FILE]")
+	}
+
+	var template []byte
+	var err error
+	if *input != "" {
+		template, err = os.ReadFile(*input)
+	} else {
+		template, err = io.ReadAll(c.stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	rendered := string(template)
+	if service.HasTemplateReferences(rendered) {
+		client, err := c.openAgent()
+		if err != nil {
+			return err
+		}
+		if rendered, err = service.NewAgentSecretResolver(client).RenderTemplate(rendered); err != nil {
+			return err
+		}
+	}
+
+	if *output == "" {
+		_, err := io.WriteString(c.stdout, rendered)
+		return err
+	}
+	return writePrivateFile(*output, []byte(rendered))
+}
+
+// writePrivateFile replaces the file at path with data readable by its owner only
+// The data goes to a new 0600 file renamed over path, so an existing file with a
+// wider mode never holds it
+func writePrivateFile(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
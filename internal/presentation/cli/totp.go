@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+)
+
+// runTOTP prints the current one-time password of an entry or a given secret
+func (c *CLI) runTOTP(args []string) error {
+	flags := flag.NewFlagSet("totp", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	secret := flags.String("secret", "", "generate from an otpauth:// URI, steam:// secret or Base32 secret instead of an entry")
+	showURI := flags.Bool("uri", false, "print the otpauth:// URI instead of the code")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	totpService := service.NewTOTPService()
+
+	if *secret != "" {
+		cfg, err := totpService.ParseInput(*secret)
+		if err != nil {
+			return err
+		}
+		return c.printOTP(totpService, cfg, *showURI)
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: passmanager totp [--uri] NAME | --secret SECRET")
+	}
+
+	client, err := c.openAgent()
+	if err != nil {
+		return err
+	}
+
+	if *showURI {
+		entry, err := client.Get(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if !entry.HasTOTP() {
+			return fmt.Errorf("entry %q has no one-time password", entry.Name)
+		}
+		return c.printOTP(totpService, entry.TOTP, true)
+	}
+
+	// The agent advances and saves the counter of counter-based codes
+	code, expiresIn, err := client.TOTP(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, code)
+	if expiresIn > 0 {
+		fmt.Fprintf(c.stderr, "Code expires in %ds\n", int(expiresIn.Seconds()))
+	}
+	return nil
+}
+
+// printOTP prints the current code or the URI of a configuration
+func (c *CLI) printOTP(totpService *service.TOTPService, cfg *entity.TOTPConfig, showURI bool) error {
+	if showURI {
+		fmt.Fprintln(c.stdout, totpService.ToGeneratorConfig(cfg).ToURI())
+		return nil
+	}
+
+	if cfg.IsHOTP() {
+		code, err := totpService.ToGeneratorConfig(cfg).GenerateCodeAtCounter(cfg.Counter)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, code)
+		return nil
+	}
+
+	code, expiresIn, err := totpService.GenerateCode(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, code)
+	fmt.Fprintf(c.stderr, "%s code, expires in %ds\n", cfg.Label(), int(expiresIn.Seconds()))
+	return nil
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hambosto/passmanager/internal/application/service"
+)
+
+// runUsername prints a generated username or email alias
+// The site name for alias templates comes from --site or the URI of --entry
+func (c *CLI) runUsername(args []string) error {
+	settings := c.config.UsernameGenerator
+
+	flags := flag.NewFlagSet("username", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	mode := flags.String("mode", settings.Mode, "words, plus, catchall or uuid")
+	email := flags.String("email", settings.Email, "address to tag in plus mode")
+	domain := flags.String("domain", settings.CatchAllDomain, "catch-all domain in catchall mode")
+	template := flags.String("template", settings.Template, "plus tag or catch-all local part using {site}, {word} and {random}")
+	site := flags.String("site", "", "site name or URL used by {site}")
+	entryName := flags.String("entry", "", "take the site from this entry's website")
+	save := flags.Bool("save", false, "store the username in the --entry entry")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || (*save && *entryName == "") {
+		return fmt.Errorf("usage: passmanager username [--mode MODE] [--site SITE | --entry NAME [--save]]")
+	}
+
+	usernameMode, err := service.ParseUsernameMode(*mode)
+	if err != nil {
+		return err
+	}
+
+	config := service.UsernameConfig{
+		Mode:          usernameMode,
+		WordCount:     settings.WordCount,
+		Separator:     settings.Separator,
+		IncludeNumber: settings.IncludeNumber,
+		Email:         *email,
+		Domain:        *domain,
+		Template:      *template,
+		Site:          service.SiteName(*site),
+	}
+
+	if *entryName == "" {
+		username, err := service.GenerateUsername(config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, username)
+		return nil
+	}
+
+	vault, vaultService, err := c.unlockVault()
+	if err != nil {
+		return err
+	}
+	entry, err := service.FindEntryByName(vault, *entryName)
+	if err != nil {
+		return err
+	}
+	if config.Site == "" {
+		config.Site = service.SiteName(entry.URI)
+	}
+
+	username, err := service.GenerateUsername(config)
+	if err != nil {
+		return err
+	}
+
+	if *save {
+		entry.Username = username
+		entry.Update()
+		vault.Update()
+		if err := vaultService.SaveVault(vault); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.stdout, username)
+	return nil
+}
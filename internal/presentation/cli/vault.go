@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/storage"
+)
+
+// unlockVault prompts for the master password and opens the configured vault
+// Commands that only need entries use openAgent, the agent never hands out the key
+func (c *CLI) unlockVault() (*entity.Vault, *service.VaultServiceImpl, error) {
+	repo := storage.NewFileRepository(c.config.Storage.VaultPath)
+	if !repo.Exists() {
+		return nil, nil, fmt.Errorf("no vault found at %s, run passmanager to create one", repo.GetPath())
+	}
+
+	password, err := c.readPassword("Master password: ")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vaultService := service.NewVaultService(repo)
+	vault, err := vaultService.UnlockVault(password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unlock vault (wrong password?): %w", err)
+	}
+
+	// Upgrade raw TOTP secrets from older vaults
+	if service.NewTOTPService().MigrateVault(vault) > 0 {
+		if err := vaultService.SaveVault(vault); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return vault, vaultService, nil
+}
+
+// readPassword reads a secret from the controlling terminal without echo
+// Falls back to a plain line from stdin when no terminal is available
+func (c *CLI) readPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err == nil {
+		defer tty.Close()
+
+		fmt.Fprint(tty, prompt)
+		password, err := term.ReadPassword(tty.Fd())
+		fmt.Fprintln(tty)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	fmt.Fprint(c.stderr, prompt)
+	line, err := readLine(c.stdin)
+	fmt.Fprintln(c.stderr)
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r"), nil
+}
+
+// readLine reads a line one byte at a time, so the input after it is left for the
+// command, e.g. the program run passes stdin to
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
@@ -1,18 +1,26 @@
 package tui
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/hambosto/passmanager/config"
+	"github.com/hambosto/passmanager/internal/application/service"
 	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/domain/repository"
 	"github.com/hambosto/passmanager/internal/infrastructure"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
 	"github.com/hambosto/passmanager/internal/infrastructure/clipboard"
 	"github.com/hambosto/passmanager/internal/infrastructure/crypto"
 	"github.com/hambosto/passmanager/internal/infrastructure/storage"
 	"github.com/hambosto/passmanager/internal/presentation/tui/components"
 	"github.com/hambosto/passmanager/internal/presentation/tui/screens"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
 )
 
 // Screen represents different screens in the app
@@ -24,6 +32,8 @@ const (
 	ScreenEntryDetail
 	ScreenEntryEditor
 	ScreenSettings
+	ScreenHealth
+	ScreenAuthenticator
 )
 
 // App is the main TUI application model
@@ -38,21 +48,43 @@ type App struct {
 	entryEditor    *screens.EntryEditorScreen
 	settingsScreen *screens.SettingsScreen
 	helpScreen     *screens.HelpScreen
+	healthScreen   *screens.HealthScreen
+	authenticator  *screens.AuthenticatorScreen
 
 	// Components
 	passwordGenerator *components.PasswordGeneratorModal
+	usernameGenerator *components.UsernameGeneratorModal
 
 	// Infrastructure
 	autoLocker *infrastructure.AutoLocker
 
+	// Services
+	security *service.SecurityService
+
+	// SSH agent serving SSH key entries while the vault is open
+	sshAgent    *service.SSHAgentService
+	sshServer   *agent.Server
+	sshRequests chan sshConfirmMsg
+	sshConfirm  *sshConfirmMsg
+	sshStatus   string
+
 	// Vault state
-	vault      *entity.Vault
-	vaultPath  string
-	masterKey  []byte
+	vault     *entity.Vault
+	vaultPath string
+	masterKey []byte
+	// agent is the unlocked agent the vault was loaded from, it saves the vault
+	// with its key and masterKey stays empty
+	agent      *agent.Client
 	repository *storage.FileRepository
 	clipboard  *clipboard.Manager
 	config     *config.Config
 
+	// clipboardStatus explains why copying is disabled or degraded
+	clipboardStatus string
+
+	// vaultStatus tells the user the vault was reloaded after another process changed it
+	vaultStatus string
+
 	// Window size
 	width  int
 	height int
@@ -69,13 +101,18 @@ func NewApp(vaultPath string, clipboardTimeout int) *App {
 
 	cfg := config.DefaultConfig()
 
+	clipboardMgr, clipboardStatus := newClipboardManager(cfg, clipboardTimeout)
+
 	return &App{
 		currentScreen:     ScreenLogin,
 		loginScreen:       screens.NewLoginScreen(vaultExists),
 		vaultPath:         vaultPath,
 		repository:        repo,
-		clipboard:         clipboard.NewManager(time.Duration(clipboardTimeout) * time.Second),
-		passwordGenerator: components.NewPasswordGeneratorModal(),
+		clipboard:         clipboardMgr,
+		clipboardStatus:   clipboardStatus,
+		passwordGenerator: newPasswordGenerator(cfg),
+		usernameGenerator: newUsernameGenerator(cfg),
+		security:          newSecurityService(cfg),
 		config:            cfg,
 	}
 }
@@ -85,26 +122,132 @@ func NewAppWithConfig(vaultPath string, cfg *config.Config) *App {
 	repo := storage.NewFileRepository(vaultPath)
 	vaultExists := repo.Exists()
 
+	clipboardMgr, clipboardStatus := newClipboardManager(cfg, cfg.Security.ClipboardTimeout)
+
 	return &App{
 		currentScreen:     ScreenLogin,
 		loginScreen:       screens.NewLoginScreen(vaultExists),
 		vaultPath:         vaultPath,
 		repository:        repo,
-		clipboard:         clipboard.NewManager(time.Duration(cfg.Security.ClipboardTimeout) * time.Second),
-		passwordGenerator: components.NewPasswordGeneratorModal(),
+		clipboard:         clipboardMgr,
+		clipboardStatus:   clipboardStatus,
+		passwordGenerator: newPasswordGenerator(cfg),
+		usernameGenerator: newUsernameGenerator(cfg),
+		security:          newSecurityService(cfg),
 		config:            cfg,
 	}
 }
 
+// newClipboardManager creates the clipboard manager clearing secrets after timeout seconds
+// An unknown backend name disables copying rather than picking another clipboard; the
+// returned status explains why
+func newClipboardManager(cfg *config.Config, timeout int) (*clipboard.Manager, string) {
+	var status string
+	backend, err := clipboard.NewBackend(cfg.Security.ClipboardBackend)
+	if err != nil {
+		backend = clipboard.NewDisabledBackend(err)
+		status = "Copying disabled, fix security.clipboard_backend: " + err.Error()
+	}
+	manager := clipboard.NewManagerWithBackend(backend, time.Duration(timeout)*time.Second)
+	manager.SetRestorePrevious(cfg.Security.RestoreClipboard)
+	return manager, status
+}
+
+// newPasswordGenerator creates the password generator with the configured defaults
+func newPasswordGenerator(cfg *config.Config) *components.PasswordGeneratorModal {
+	generator := components.NewPasswordGeneratorModal()
+	generator.ApplyConfig(cfg)
+	return generator
+}
+
+// newUsernameGenerator creates the username generator with the configured defaults
+func newUsernameGenerator(cfg *config.Config) *components.UsernameGeneratorModal {
+	generator := components.NewUsernameGeneratorModal()
+	generator.ApplyConfig(cfg)
+	return generator
+}
+
+// newSecurityService creates the security service with the configured local catalogues
+// A broken catalogue only disables its check, it never blocks the app
+func newSecurityService(cfg *config.Config) *service.SecurityService {
+	security := service.NewSecurityService()
+	if cfg.Security.BreachCatalogPath != "" {
+		_ = security.LoadBreachCatalog(cfg.Security.BreachCatalogPath)
+	}
+	if cfg.Security.TwoFactorDirectoryPath != "" {
+		_ = security.LoadTwoFactorDirectory(cfg.Security.TwoFactorDirectoryPath)
+	}
+	return security
+}
+
 // Init initializes the application
 func (a *App) Init() tea.Cmd {
-	return a.loginScreen.Init()
+	return tea.Batch(a.loginScreen.Init(), clipboardTick(), a.attachAgent())
 }
 
+// attachAgent loads the vault from a running, unlocked agent so the login can be
+// skipped; the vault key stays in the agent
+func (a *App) attachAgent() tea.Cmd {
+	if !a.repository.Exists() {
+		return nil
+	}
+	return func() tea.Msg {
+		client := agent.NewClient(agent.SocketPath())
+		vault, path, err := client.Load()
+		if err != nil || !samePath(path, a.vaultPath) {
+			return nil
+		}
+		return agentVaultMsg{client: client, vault: vault}
+	}
+}
+
+// agentVaultMsg carries the vault loaded from the agent
+type agentVaultMsg struct {
+	client *agent.Client
+	vault  *entity.Vault
+}
+
+// samePath reports whether two paths name the same file
+func samePath(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// clipboardTick refreshes the clipboard countdown every second
+func clipboardTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return clipboardTickMsg{}
+	})
+}
+
+// clipboardTickMsg re-renders the status bar while a clipboard clear is pending
+type clipboardTickMsg struct{}
+
 // Update handles messages
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Keep the countdown ticking whichever screen or modal is shown
+	if _, ok := msg.(clipboardTickMsg); ok {
+		// The agent refused an unanswered signature on its own
+		if a.sshConfirm != nil && time.Now().After(a.sshConfirm.deadline) {
+			return a, tea.Batch(clipboardTick(), a.answerSSHConfirm(false))
+		}
+		return a, clipboardTick()
+	}
+
+	// A signature waiting for confirmation takes all keys
+	switch msg := msg.(type) {
+	case sshConfirmMsg:
+		a.sshConfirm = &msg
+		return a, nil
+	case tea.KeyMsg:
+		if a.sshConfirm != nil {
+			return a, a.handleSSHConfirmKey(msg)
+		}
+	}
+
 	// Handle password generator first if visible
 	if a.passwordGenerator.IsVisible() {
 		cmd := a.passwordGenerator.Update(msg)
@@ -114,7 +257,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Don't pass through most messages if modal is open
 		switch msg.(type) {
-		case components.UsePasswordMsg, components.CopyPasswordMsg:
+		case components.UsePasswordMsg, components.CopyPasswordMsg, components.NewEntryWithPasswordMsg:
 			// Pass through these messages
 		case tea.WindowSizeMsg:
 			// Pass through window size
@@ -123,12 +266,29 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Handle username generator the same way
+	if a.usernameGenerator.IsVisible() {
+		cmd := a.usernameGenerator.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		switch msg.(type) {
+		case components.UseUsernameMsg, components.CopyUsernameMsg, tea.WindowSizeMsg:
+			// Pass through these messages
+		default:
+			return a, tea.Batch(cmds...)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
 
 	case tea.KeyMsg:
+		a.vaultStatus = ""
+
 		// Global shortcuts
 		switch msg.String() {
 		case "ctrl+c":
@@ -144,9 +304,17 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle vault unlock
 		return a.handleUnlock(msg)
 
+	case agentVaultMsg:
+		// Stay on the login screen if the user was faster
+		if a.currentScreen != ScreenLogin || a.vault != nil {
+			return a, nil
+		}
+		a.agent = msg.client
+		return a.openVault(msg.vault, nil)
+
 	case screens.BackMsg:
 		// Go back to previous screen
-		if a.currentScreen == ScreenEntryDetail {
+		if a.currentScreen == ScreenEntryDetail || a.currentScreen == ScreenHealth || a.currentScreen == ScreenAuthenticator {
 			a.currentScreen = ScreenVaultList
 			return a, nil
 		}
@@ -167,6 +335,25 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Save entry
 		return a.handleSaveEntry(msg)
 
+	case screens.VaultChangedMsg:
+		// Persist changes made in place by the current screen
+		if err := a.saveVault(); err != nil {
+			return a.handleSaveError(err)
+		}
+		return a, nil
+
+	case screens.AutoTypeDoneMsg:
+		// Save a used-up HOTP code here, the screen may have been left while typing
+		if msg.CounterAdvanced {
+			if err := a.saveVault(); err != nil {
+				return a.handleSaveError(err)
+			}
+		}
+
+	case screens.ReauthenticateMsg:
+		// Verify the master password off the UI thread, key derivation is slow
+		return a, a.verifyPassword(msg.Password)
+
 	case screens.SaveSettingsMsg:
 		// Save settings to config file
 		if err := msg.Config.Save(config.GetConfigPath()); err != nil {
@@ -174,7 +361,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			a.message = "Settings saved!"
 			// Update clipboard timeout
-			a.clipboard = clipboard.NewManager(time.Duration(msg.Config.Security.ClipboardTimeout) * time.Second)
+			a.clipboard.SetTimeout(time.Duration(msg.Config.Security.ClipboardTimeout) * time.Second)
+			a.clipboard.SetRestorePrevious(msg.Config.Security.RestoreClipboard)
+			a.passwordGenerator.ApplyConfig(msg.Config)
+			a.usernameGenerator.ApplyConfig(msg.Config)
 		}
 		a.currentScreen = ScreenVaultList
 		return a, nil
@@ -187,13 +377,38 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.currentScreen = ScreenEntryEditor
 		return a, a.entryEditor.Init()
 
+	case screens.OpenUsernameGeneratorMsg:
+		// Show username generator modal for the entry's site
+		a.usernameGenerator.Show(msg.Site)
+		a.usernameGenerator.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
+		return a, nil
+
 	case screens.OpenPasswordGeneratorMsg:
-		// Show password generator modal
-		a.passwordGenerator.Show()
+		// Show password generator modal, defaulting to the site's rules
+		if a.vault != nil {
+			a.passwordGenerator.SetHistory(a.vault.GeneratorHistory)
+		}
+		a.passwordGenerator.ShowWithRules(msg.Rules)
+		a.passwordGenerator.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
 		return a, nil
 
+	case components.NewEntryWithPasswordMsg:
+		// Fill the open editor, or start a new entry with the password
+		if a.entryEditor != nil && a.currentScreen == ScreenEntryEditor {
+			a.entryEditor.SetPassword(msg.Password)
+			a.message = "Password set!"
+			return a, nil
+		}
+		newEntry := entity.NewEntry(entity.EntryTypeLogin, "")
+		newEntry.SetPassword(msg.Password)
+		a.entryEditor = screens.NewEntryEditorScreen(newEntry, true)
+		a.previousScreen = a.currentScreen
+		a.currentScreen = ScreenEntryEditor
+		return a, a.entryEditor.Init()
+
 	case components.UsePasswordMsg:
 		// Use generated password in editor
+		a.recordGenerated(msg.Password, msg.Mode)
 		if a.entryEditor != nil && a.currentScreen == ScreenEntryEditor {
 			// Set the password in the editor
 			a.entryEditor.SetPassword(msg.Password)
@@ -201,8 +416,23 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 
+	case components.UseUsernameMsg:
+		// Use generated username in editor
+		if a.entryEditor != nil && a.currentScreen == ScreenEntryEditor {
+			a.entryEditor.SetUsername(msg.Username)
+			a.message = "Username set!"
+		}
+		return a, nil
+
+	case components.CopyUsernameMsg:
+		// Copy generated username
+		a.clipboard.CopyWithTimeout(msg.Username)
+		a.message = "Username copied!"
+		return a, nil
+
 	case components.CopyPasswordMsg:
 		// Copy generated password
+		a.recordGenerated(msg.Password, msg.Mode)
 		a.clipboard.CopyWithTimeout(msg.Password)
 		a.message = "Password copied!"
 		return a, nil
@@ -228,6 +458,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					selectedEntry := a.vaultList.GetSelectedEntry()
 					if selectedEntry != nil {
 						a.entryDetail = screens.NewEntryDetailScreen(selectedEntry, a.clipboard)
+						a.entryDetail.SetAutoTypeConfig(a.config.AutoType)
+						a.entryDetail.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
 						a.currentScreen = ScreenEntryDetail
 						return a, a.entryDetail.Init()
 					}
@@ -243,6 +475,20 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					a.previousScreen = a.currentScreen
 					a.currentScreen = ScreenSettings
 					return a, a.helpScreen.Init()
+				case "ctrl+w":
+					// Open vault health
+					a.healthScreen = screens.NewHealthScreen(a.vault, a.security)
+					a.healthScreen.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
+					a.previousScreen = a.currentScreen
+					a.currentScreen = ScreenHealth
+					return a, a.healthScreen.Init()
+				case "ctrl+t":
+					// Open the authenticator overview
+					a.authenticator = screens.NewAuthenticatorScreen(a.vault, a.clipboard)
+					a.authenticator.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
+					a.previousScreen = a.currentScreen
+					a.currentScreen = ScreenAuthenticator
+					return a, a.authenticator.Init()
 				}
 			}
 			_, cmd = a.vaultList.Update(msg)
@@ -269,6 +515,18 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_, cmd = a.helpScreen.Update(msg)
 			cmds = append(cmds, cmd)
 		}
+
+	case ScreenHealth:
+		if a.healthScreen != nil {
+			_, cmd = a.healthScreen.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
+	case ScreenAuthenticator:
+		if a.authenticator != nil {
+			_, cmd = a.authenticator.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return a, tea.Batch(cmds...)
@@ -309,16 +567,56 @@ func (a *App) View() string {
 			view = a.helpScreen.View()
 		}
 
+	case ScreenHealth:
+		if a.healthScreen != nil {
+			view = a.healthScreen.View()
+		}
+
+	case ScreenAuthenticator:
+		if a.authenticator != nil {
+			view = a.authenticator.View()
+		}
+
 	default:
 		view = "Loading..."
 	}
 
-	// Overlay password generator if visible
+	// Overlay generators if visible
 	if a.passwordGenerator.IsVisible() {
 		view = a.passwordGenerator.View()
 	}
+	if a.usernameGenerator.IsVisible() {
+		view = a.usernameGenerator.View()
+	}
+	if a.sshConfirm != nil {
+		view = a.renderSSHConfirm()
+	}
 
-	return view
+	return view + a.renderStatusBar()
+}
+
+// renderStatusBar renders the clipboard countdown while a secret is on the clipboard
+// and SSH agent or clipboard problems
+func (a *App) renderStatusBar() string {
+	for _, status := range []string{a.vaultStatus, a.sshStatus, a.clipboardStatus} {
+		if status != "" {
+			return "\n" + lipgloss.NewStyle().Foreground(styles.Warning).Render(styles.IconWarning+" "+status)
+		}
+	}
+	remaining := a.clipboard.Remaining()
+	if remaining <= 0 {
+		return ""
+	}
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	status := "\n" + lipgloss.NewStyle().Foreground(styles.Subtle).Render(
+		fmt.Sprintf("📋 Clipboard clears in %ds", seconds))
+
+	// Tell the user when clipboard history managers were not asked to skip the secret
+	if err := a.clipboard.SensitiveHintError(); err != nil {
+		status += "\n" + lipgloss.NewStyle().Foreground(styles.Warning).Render(
+			styles.IconWarning+" Not hidden from clipboard history: "+err.Error())
+	}
+	return status
 }
 
 // handleUnlock handles the unlock message
@@ -357,7 +655,7 @@ func (a *App) createVault(password string) (tea.Model, tea.Cmd) {
 	a.currentScreen = ScreenVaultList
 	a.vaultList = screens.NewVaultListScreen(vault, a.clipboard)
 
-	return a, a.vaultList.Init()
+	return a, tea.Batch(a.vaultList.Init(), a.startSSHAgent())
 }
 
 // unlockVault unlocks an existing vault
@@ -381,9 +679,21 @@ func (a *App) unlockVault(password string) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	return a.openVault(vault, key)
+}
+
+// openVault shows a vault unlocked with key
+func (a *App) openVault(vault *entity.Vault, key []byte) (tea.Model, tea.Cmd) {
 	a.masterKey = key
 	a.vault = vault
 
+	// Upgrade raw TOTP secrets from older vaults
+	if service.NewTOTPService().MigrateVault(vault) > 0 {
+		if err := a.saveVault(); err != nil {
+			return a, func() tea.Msg { return errMsg{err: err} }
+		}
+	}
+
 	// Initialize auto-locker if configured
 	if a.config != nil && a.config.Security.AutoLockTimeout > 0 {
 		timeout := time.Duration(a.config.Security.AutoLockTimeout) * time.Minute
@@ -396,7 +706,12 @@ func (a *App) unlockVault(password string) (tea.Model, tea.Cmd) {
 	a.currentScreen = ScreenVaultList
 	a.vaultList = screens.NewVaultListScreen(vault, a.clipboard)
 
-	return a, a.vaultList.Init()
+	return a, tea.Batch(a.vaultList.Init(), a.startSSHAgent())
+}
+
+// Close stops serving the vault when the program exits
+func (a *App) Close() {
+	a.stopSSHAgent()
 }
 
 // handleSaveEntry handles saving an entry
@@ -409,27 +724,122 @@ func (a *App) handleSaveEntry(msg screens.SaveEntryMsg) (tea.Model, tea.Cmd) {
 		a.vault.Update()
 	}
 
-	// Load existing KDF params from file
+	err := a.saveVault()
+	if errors.Is(err, repository.ErrVaultChanged) {
+		// Keep the changes of the other process and put the edited entry on top of them
+		err = a.reloadVault()
+		if err == nil {
+			a.vault.PutEntry(msg.Entry)
+			err = a.saveVault()
+		}
+	}
+	if err != nil {
+		return a.handleSaveError(err)
+	}
+
+	// Go back to vault list
+	a.currentScreen = ScreenVaultList
+	a.vaultList = screens.NewVaultListScreen(a.vault, a.clipboard)
+	a.message = "Entry saved!"
+
+	return a, a.vaultList.Init()
+}
+
+// saveVault writes the vault to disk with its existing KDF params, or through the
+// agent it was loaded from
+func (a *App) saveVault() error {
+	if a.agent != nil {
+		if err := a.agent.Save(a.vault); err != nil {
+			return fmt.Errorf("failed to save vault through the agent: %w", err)
+		}
+		a.refreshSSHKeys()
+		return nil
+	}
+
 	params, err := a.repository.LoadParams()
 	if err != nil {
-		a.err = fmt.Errorf("failed to load vault params: %w", err)
-		return a, nil
+		return fmt.Errorf("failed to load vault params: %w", err)
 	}
 
-	// Save vault to disk with the existing KDF params
 	if err := a.repository.Save(a.vault, a.masterKey, params); err != nil {
-		a.err = fmt.Errorf("failed to save vault: %w", err)
-		return a, nil
+		return fmt.Errorf("failed to save vault: %w", err)
 	}
 
-	// Go back to vault list
+	a.refreshSSHKeys()
+	return nil
+}
+
+// handleSaveError shows why the vault was not saved
+// A vault another process saved in the meantime is reloaded instead of overwritten,
+// and the user is asked to repeat the change
+func (a *App) handleSaveError(err error) (tea.Model, tea.Cmd) {
+	if !errors.Is(err, repository.ErrVaultChanged) {
+		a.err = err
+		return a, nil
+	}
+	if err := a.reloadVault(); err != nil {
+		a.err = err
+		return a, nil
+	}
+	a.vaultStatus = "The vault was changed elsewhere and has been reloaded, repeat your last change"
 	a.currentScreen = ScreenVaultList
 	a.vaultList = screens.NewVaultListScreen(a.vault, a.clipboard)
-	a.message = "Entry saved!"
-
+	a.vaultList.Update(tea.WindowSizeMsg{Width: a.width, Height: a.height})
 	return a, a.vaultList.Init()
 }
 
+// reloadVault reads the vault again with the key it was unlocked with, or from the agent
+func (a *App) reloadVault() error {
+	var vault *entity.Vault
+	var err error
+	if a.agent != nil {
+		vault, _, err = a.agent.Load()
+	} else {
+		vault, err = a.repository.Load(a.masterKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload vault: %w", err)
+	}
+	a.vault = vault
+	a.refreshSSHKeys()
+	return nil
+}
+
+// recordGenerated keeps a copied or used password in the vault's generator history
+// so it is not lost when a sign-up fails before the entry is saved
+func (a *App) recordGenerated(password, mode string) {
+	if a.vault == nil {
+		return
+	}
+	if !a.vault.AddGeneratedValue(password, mode, a.config.PasswordGenerator.HistorySize) {
+		return
+	}
+	if err := a.saveVault(); err != nil {
+		a.handleSaveError(err)
+		return
+	}
+	a.passwordGenerator.SetHistory(a.vault.GeneratorHistory)
+}
+
+// verifyPassword checks a re-entered master password against the unlocked vault key
+// Without the key, when the agent holds it, the password has to open the vault file
+func (a *App) verifyPassword(password string) tea.Cmd {
+	masterKey := a.masterKey
+	return func() tea.Msg {
+		params, err := a.repository.LoadParams()
+		if err != nil {
+			return screens.ReauthenticatedMsg{OK: false}
+		}
+		key := crypto.DeriveKey(password, params)
+		defer crypto.ZeroBytes(key)
+		if masterKey == nil {
+			_, err := a.repository.Load(key)
+			return screens.ReauthenticatedMsg{OK: err == nil}
+		}
+		return screens.ReauthenticatedMsg{OK: subtle.ConstantTimeCompare(key, masterKey) == 1}
+	}
+}
+
 // errMsg represents an error message
 type errMsg struct {
 	err error
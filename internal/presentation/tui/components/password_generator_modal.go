@@ -2,16 +2,56 @@ package components
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/config"
 	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
 	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
 	"github.com/hambosto/passmanager/internal/presentation/tui/util"
+	"github.com/hambosto/passmanager/pkg/passwordrules"
 	"github.com/hambosto/passmanager/pkg/validator"
 )
 
+// generatorMode is a kind of secret the modal generates
+type generatorMode int
+
+const (
+	modeRandom generatorMode = iota
+	modePattern
+	modePronounceable
+	modePassphrase
+	modeCount
+)
+
+// String returns the display name of the mode
+func (g generatorMode) String() string {
+	switch g {
+	case modePattern:
+		return "Pattern"
+	case modePronounceable:
+		return "Pronounceable"
+	case modePassphrase:
+		return "Passphrase"
+	default:
+		return "Random"
+	}
+}
+
+// parseGeneratorMode parses a mode name from the configuration, defaulting to random
+func parseGeneratorMode(name string) generatorMode {
+	for mode := modeRandom; mode < modeCount; mode++ {
+		if strings.EqualFold(name, mode.String()) {
+			return mode
+		}
+	}
+	return modeRandom
+}
+
 // PasswordGeneratorModal is a modal for generating passwords
 type PasswordGeneratorModal struct {
 	visible bool
@@ -19,18 +59,39 @@ type PasswordGeneratorModal struct {
 	height  int
 
 	// Generation mode
-	usePassphrase bool
+	mode generatorMode
 
 	// Password config
 	passwordConfig service.PasswordConfig
+	patternInput   textinput.Model
+	symbolsInput   textinput.Model
+	allowedInput   textinput.Model
+	excludedInput  textinput.Model
 
 	// Passphrase config
 	passphraseConfig service.PassphraseConfig
 
+	// Word lists available in the word list directory
+	wordListDir   string
+	wordLists     []string
+	wordListIndex int
+	wordListError string
+
+	// Site password rules and whether they are applied
+	rules      *passwordrules.Rules
+	rulesError string
+	useRules   bool
+
 	// Generated password
 	password string
 	entropy  float64
 	strength validator.PasswordStrength
+	failed   bool
+
+	// Recently copied or used passwords, newest first
+	history      []entity.GeneratedValue
+	showHistory  bool
+	historyIndex int
 
 	// UI state
 	focusedOption int
@@ -38,17 +99,120 @@ type PasswordGeneratorModal struct {
 
 // NewPasswordGeneratorModal creates a new password generator modal
 func NewPasswordGeneratorModal() *PasswordGeneratorModal {
+	passwordConfig := service.DefaultPasswordConfig()
+
 	return &PasswordGeneratorModal{
 		visible:          false,
-		passwordConfig:   service.DefaultPasswordConfig(),
+		passwordConfig:   passwordConfig,
+		patternInput:     newOptionInput(passwordConfig.Pattern, ""),
+		symbolsInput:     newOptionInput(passwordConfig.Symbols, "default"),
+		allowedInput:     newOptionInput(passwordConfig.AllowedChars, "none"),
+		excludedInput:    newOptionInput(passwordConfig.ExcludedChars, "none"),
 		passphraseConfig: service.DefaultPassphraseConfig(),
-		usePassphrase:    false,
+		wordLists:        []string{service.BuiltinWordList},
+		mode:             modeRandom,
+	}
+}
+
+// newOptionInput creates a borderless text input for an option
+func newOptionInput(value, placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = placeholder
+	input.CharLimit = 128
+	input.Width = 30
+	input.SetValue(value)
+	return input
+}
+
+// ApplyConfig sets the generator defaults from the application configuration
+func (m *PasswordGeneratorModal) ApplyConfig(cfg *config.Config) {
+	m.passwordConfig.Length = cfg.PasswordGenerator.Length
+	m.passwordConfig.IncludeUpper = cfg.PasswordGenerator.IncludeUppercase
+	m.passwordConfig.IncludeLower = cfg.PasswordGenerator.IncludeLowercase
+	m.passwordConfig.IncludeNumbers = cfg.PasswordGenerator.IncludeNumbers
+	m.passwordConfig.IncludeSymbols = cfg.PasswordGenerator.IncludeSymbols
+	m.passwordConfig.ExcludeAmbiguous = cfg.PasswordGenerator.ExcludeAmbiguous
+	m.passwordConfig.MinUpper = cfg.PasswordGenerator.MinUppercase
+	m.passwordConfig.MinLower = cfg.PasswordGenerator.MinLowercase
+	m.passwordConfig.MinNumbers = cfg.PasswordGenerator.MinNumbers
+	m.passwordConfig.MinSymbols = cfg.PasswordGenerator.MinSymbols
+	m.symbolsInput.SetValue(cfg.PasswordGenerator.Symbols)
+	m.allowedInput.SetValue(cfg.PasswordGenerator.AllowedChars)
+	m.excludedInput.SetValue(cfg.PasswordGenerator.ExcludedChars)
+	if pattern := cfg.PasswordGenerator.Pattern; pattern != "" {
+		m.patternInput.SetValue(pattern)
+	}
+	m.syncInputs()
+	m.mode = parseGeneratorMode(cfg.PasswordGenerator.Mode)
+
+	passphrase := cfg.PassphraseGenerator
+	m.passphraseConfig.WordCount = passphrase.WordCount
+	m.passphraseConfig.Separator = passphrase.Separator
+	m.passphraseConfig.Capitalize = passphrase.Capitalize
+	m.passphraseConfig.IncludeNumber = passphrase.IncludeNumber
+	m.passphraseConfig.InsertDigit = passphrase.InsertDigit
+	m.passphraseConfig.InsertSymbol = passphrase.InsertSymbol
+
+	m.wordListDir = passphrase.WordListDir
+	names, _ := service.FindWordLists(m.wordListDir)
+	m.wordLists = append([]string{service.BuiltinWordList}, names...)
+
+	// A list configured by path stays selectable next to the directory's lists
+	configured := passphrase.WordList
+	if configured == "" {
+		configured = service.BuiltinWordList
+	}
+	index := slices.Index(m.wordLists, configured)
+	if index < 0 {
+		m.wordLists = append(m.wordLists, configured)
+		index = len(m.wordLists) - 1
+	}
+	m.wordListIndex = 0
+	m.passphraseConfig.WordList = service.DefaultWordList()
+	m.selectWordList(index)
+}
+
+// selectWordList loads the word list at index, keeping the current one if it fails
+func (m *PasswordGeneratorModal) selectWordList(index int) {
+	list, err := service.ResolveWordList(m.wordListDir, m.wordLists[index])
+	if err != nil {
+		m.wordListError = err.Error()
+		return
 	}
+	m.wordListError = ""
+	m.wordListIndex = index
+	m.passphraseConfig.WordList = list
 }
 
 // Show shows the modal
 func (m *PasswordGeneratorModal) Show() {
+	m.ShowWithRules("")
+}
+
+// ShowWithRules shows the modal with site password rules applied by default
+// Rules that fail to parse are reported and ignored
+func (m *PasswordGeneratorModal) ShowWithRules(rules string) {
+	m.rules = nil
+	m.rulesError = ""
+	if strings.TrimSpace(rules) != "" {
+		parsed, err := passwordrules.Parse(rules)
+		if err != nil {
+			m.rulesError = err.Error()
+		} else {
+			m.rules = parsed
+		}
+	}
+	m.useRules = m.rules != nil
+	if m.useRules {
+		m.mode = modeRandom
+		m.passwordConfig.Length = m.clampLength(m.passwordConfig.Length)
+	}
+
 	m.visible = true
+	m.showHistory = false
+	m.focusedOption = 0
+	m.updateInputFocus()
 	m.generatePassword()
 }
 
@@ -80,6 +244,26 @@ func (m *PasswordGeneratorModal) Update(msg tea.Msg) tea.Cmd {
 		return nil
 
 	case tea.KeyMsg:
+		if m.showHistory {
+			return m.updateHistory(msg)
+		}
+
+		if input := m.focusedInput(); input != nil {
+			switch msg.String() {
+			case "esc", "enter", "ctrl+r", "ctrl+c", "ctrl+y", "tab", "shift+tab", "up", "down":
+			default:
+				// Everything else edits the focused input
+				old := input.Value()
+				var cmd tea.Cmd
+				*input, cmd = input.Update(msg)
+				if input.Value() != old {
+					m.syncInputs()
+					m.generatePassword()
+				}
+				return cmd
+			}
+		}
+
 		switch msg.String() {
 		case "esc":
 			m.Hide()
@@ -87,9 +271,13 @@ func (m *PasswordGeneratorModal) Update(msg tea.Msg) tea.Cmd {
 
 		case "enter":
 			// Use the generated password
+			if m.failed {
+				return nil
+			}
 			m.Hide()
+			password, mode := m.password, m.modeName()
 			return func() tea.Msg {
-				return UsePasswordMsg{Password: m.password}
+				return UsePasswordMsg{Password: password, Mode: mode}
 			}
 
 		case "ctrl+r":
@@ -99,13 +287,30 @@ func (m *PasswordGeneratorModal) Update(msg tea.Msg) tea.Cmd {
 
 		case "ctrl+c":
 			// Copy to clipboard (handled by parent)
+			if m.failed {
+				return nil
+			}
+			password, mode := m.password, m.modeName()
 			return func() tea.Msg {
-				return CopyPasswordMsg{Password: m.password}
+				return CopyPasswordMsg{Password: password, Mode: mode}
 			}
 
-		case "tab":
-			// Toggle between password/passphrase
-			m.usePassphrase = !m.usePassphrase
+		case "ctrl+y":
+			// Show recently used passwords
+			m.showHistory = true
+			m.historyIndex = 0
+			m.updateInputFocus()
+			return nil
+
+		case "tab", "shift+tab":
+			// Cycle through the modes
+			delta := generatorMode(1)
+			if msg.String() == "shift+tab" {
+				delta = modeCount - 1
+			}
+			m.mode = (m.mode + delta) % modeCount
+			m.focusedOption = 0
+			m.updateInputFocus()
 			m.generatePassword()
 			return nil
 
@@ -113,16 +318,14 @@ func (m *PasswordGeneratorModal) Update(msg tea.Msg) tea.Cmd {
 			if m.focusedOption > 0 {
 				m.focusedOption--
 			}
+			m.updateInputFocus()
 			return nil
 
 		case "down", "j":
-			maxOptions := 4
-			if m.usePassphrase {
-				maxOptions = 2
-			}
-			if m.focusedOption < maxOptions {
+			if m.focusedOption < m.optionCount()-1 {
 				m.focusedOption++
 			}
+			m.updateInputFocus()
 			return nil
 
 		case "left", "h":
@@ -152,14 +355,42 @@ func (m *PasswordGeneratorModal) View() string {
 	content.WriteString(styles.TitleStyle.Render(styles.IconKey + " Password Generator"))
 	content.WriteString("\n\n")
 
+	if m.showHistory {
+		content.WriteString(m.renderHistory())
+		return m.frame(content.String())
+	}
+
 	// Mode selector
-	modeText := "● Random Password    ○ Passphrase"
-	if m.usePassphrase {
-		modeText = "○ Random Password    ● Passphrase"
+	var modes []string
+	for mode := modeRandom; mode < modeCount; mode++ {
+		marker := "○"
+		if mode == m.mode {
+			marker = "●"
+		}
+		modes = append(modes, marker+" "+mode.String())
 	}
-	content.WriteString(lipgloss.NewStyle().Foreground(styles.Primary).Render(modeText))
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Primary).Render(strings.Join(modes, "  ")))
 	content.WriteString("\n\n")
 
+	if m.mode == modePassphrase && m.wordListError != "" {
+		content.WriteString(styles.ErrorStyle.Render(m.wordListError))
+		content.WriteString("\n\n")
+	}
+
+	// Site rules
+	if m.rulesError != "" {
+		content.WriteString(styles.ErrorStyle.Render("Invalid site rules: " + m.rulesError))
+		content.WriteString("\n\n")
+	} else if m.rules != nil && m.mode == modeRandom {
+		status := "ignored"
+		if m.useRules {
+			status = "applied"
+		}
+		content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render(
+			fmt.Sprintf("Site rules (%s): %s", status, m.rules.String())))
+		content.WriteString("\n\n")
+	}
+
 	// Generated password box
 	passwordBox := styles.BoxStyle.
 		Width(util.MinInt(60, m.width-10)).
@@ -184,16 +415,20 @@ func (m *PasswordGeneratorModal) View() string {
 	content.WriteString("\n\n")
 
 	// Help
-	helpText := "[Ctrl+R] Regenerate  •  [Ctrl+C] Copy  •  [Enter] Use  •  [Tab] Switch Mode  •  [Esc] Cancel"
+	helpText := "[Ctrl+R] Regenerate  •  [Ctrl+C] Copy  •  [Enter] Use  •  [Tab] Switch Mode  •  [Ctrl+Y] History  •  [Esc] Cancel"
 	content.WriteString(styles.HelpStyle.Render(helpText))
 
-	// Create modal box
+	return m.frame(content.String())
+}
+
+// frame draws the modal border around content and centers it
+func (m *PasswordGeneratorModal) frame(content string) string {
 	modalBox := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(styles.Primary).
 		Padding(2, 4).
 		Width(util.MinInt(70, m.width-4)).
-		Render(content.String())
+		Render(content)
 
 	// Center the modal
 	centered := lipgloss.Place(
@@ -256,7 +491,8 @@ func (m *PasswordGeneratorModal) renderOptions() string {
 	content.WriteString(lipgloss.NewStyle().Bold(true).Render("Options"))
 	content.WriteString("\n\n")
 
-	if m.usePassphrase {
+	switch m.mode {
+	case modePassphrase:
 		// Passphrase options
 		content.WriteString(m.renderOption(0, fmt.Sprintf("Word Count: %d", m.passphraseConfig.WordCount)))
 		content.WriteString("\n")
@@ -267,38 +503,75 @@ func (m *PasswordGeneratorModal) renderOptions() string {
 		if m.passphraseConfig.Capitalize {
 			capIcon = "☑"
 		}
-		content.WriteString(m.renderOption(2, capIcon+" Capitalize"))
-	} else {
-		// Password options
-		content.WriteString(m.renderOption(0, fmt.Sprintf("Length: %d", m.passwordConfig.Length)))
+		content.WriteString(m.renderOption(2, capIcon+" Capitalize Each Word"))
 		content.WriteString("\n")
 
-		upperIcon := "☐"
-		if m.passwordConfig.IncludeUpper {
-			upperIcon = "☑"
+		numberIcon := "☐"
+		if m.passphraseConfig.IncludeNumber {
+			numberIcon = "☑"
 		}
-		content.WriteString(m.renderOption(1, upperIcon+" Uppercase"))
+		content.WriteString(m.renderOption(3, numberIcon+" Include Number"))
 		content.WriteString("\n")
 
-		numbersIcon := "☐"
-		if m.passwordConfig.IncludeNumbers {
-			numbersIcon = "☑"
+		list := m.passphraseConfig.WordList
+		content.WriteString(m.renderOption(4, fmt.Sprintf("Word List: %s (%d words, %.1f bits/word)",
+			list.Name, len(list.Words), list.Entropy())))
+		content.WriteString("\n")
+
+		digitIcon := "☐"
+		if m.passphraseConfig.InsertDigit {
+			digitIcon = "☑"
 		}
-		content.WriteString(m.renderOption(2, numbersIcon+" Numbers"))
+		content.WriteString(m.renderOption(5, digitIcon+" Digit In Each Word"))
 		content.WriteString("\n")
 
-		symbolsIcon := "☐"
-		if m.passwordConfig.IncludeSymbols {
-			symbolsIcon = "☑"
+		symbolIcon := "☐"
+		if m.passphraseConfig.InsertSymbol {
+			symbolIcon = "☑"
 		}
-		content.WriteString(m.renderOption(3, symbolsIcon+" Symbols"))
+		content.WriteString(m.renderOption(6, symbolIcon+" Symbol In Each Word"))
+
+	case modePattern:
+		content.WriteString(m.renderOption(0, "Pattern: "+m.patternInput.View()))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(1, checkbox(m.passwordConfig.ExcludeAmbiguous)+" Exclude Ambiguous"))
+		content.WriteString("\n")
+		content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render(service.PatternHelp))
+
+	case modePronounceable:
+		content.WriteString(m.renderOption(0, fmt.Sprintf("Length: %d", m.passwordConfig.Length)))
 		content.WriteString("\n")
+		content.WriteString(m.renderOption(1, checkbox(m.passwordConfig.IncludeUpper)+" Capitalize"))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(2, checkbox(m.passwordConfig.IncludeNumbers)+" End With Numbers"))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(3, checkbox(m.passwordConfig.ExcludeAmbiguous)+" Exclude Ambiguous"))
+
+	default:
+		// Password options
+		config := m.passwordConfig
+		content.WriteString(m.renderOption(0, fmt.Sprintf("Length: %d", config.Length)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(1, classOption("Uppercase", config.IncludeUpper, config.MinUpper)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(2, classOption("Lowercase", config.IncludeLower, config.MinLower)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(3, classOption("Numbers", config.IncludeNumbers, config.MinNumbers)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(4, classOption("Symbols", config.IncludeSymbols, config.MinSymbols)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(5, "Symbol Set: "+m.symbolsInput.View()))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(6, "Also Allow: "+m.allowedInput.View()))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(7, "Never Use:  "+m.excludedInput.View()))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(8, checkbox(config.ExcludeAmbiguous)+" Exclude Ambiguous"))
 
-		ambigIcon := "☐"
-		if m.passwordConfig.ExcludeAmbiguous {
-			ambigIcon = "☑"
+		if m.rules != nil {
+			content.WriteString("\n")
+			content.WriteString(m.renderOption(9, checkbox(m.useRules)+" Apply Site Rules"))
 		}
-		content.WriteString(m.renderOption(4, ambigIcon+" Exclude Ambiguous"))
 	}
 
 	content.WriteString("\n")
@@ -307,6 +580,36 @@ func (m *PasswordGeneratorModal) renderOptions() string {
 	return content.String()
 }
 
+// classOption renders a character set option with its minimum count
+func classOption(name string, enabled bool, minimum int) string {
+	if !enabled {
+		return checkbox(false) + " " + name
+	}
+	return fmt.Sprintf("%s %s (at least %d)", checkbox(true), name, minimum)
+}
+
+// adjustClass raises or lowers the minimum count of a character set
+// Lowering it below zero disables the set and raising it enables the set again
+func adjustClass(enabled *bool, minimum *int, delta, length int) {
+	switch {
+	case !*enabled && delta > 0:
+		*enabled = true
+		*minimum = 0
+	case *enabled && *minimum+delta < 0:
+		*enabled = false
+	case *enabled:
+		*minimum = min(length, *minimum+delta)
+	}
+}
+
+// checkbox renders a checkbox icon
+func checkbox(checked bool) string {
+	if checked {
+		return "☑"
+	}
+	return "☐"
+}
+
 // renderOption renders a single option
 func (m *PasswordGeneratorModal) renderOption(index int, text string) string {
 	style := lipgloss.NewStyle()
@@ -321,7 +624,8 @@ func (m *PasswordGeneratorModal) renderOption(index int, text string) string {
 
 // adjustOption adjusts the focused option's value
 func (m *PasswordGeneratorModal) adjustOption(delta int) {
-	if m.usePassphrase {
+	switch m.mode {
+	case modePassphrase:
 		switch m.focusedOption {
 		case 0: // Word count
 			m.passphraseConfig.WordCount += delta
@@ -350,8 +654,37 @@ func (m *PasswordGeneratorModal) adjustOption(delta int) {
 			m.passphraseConfig.Separator = separators[currentIndex]
 		case 2: // Capitalize
 			m.passphraseConfig.Capitalize = !m.passphraseConfig.Capitalize
+		case 3: // Include number
+			m.passphraseConfig.IncludeNumber = !m.passphraseConfig.IncludeNumber
+		case 4: // Word list
+			if len(m.wordLists) < 2 {
+				return
+			}
+			m.selectWordList((m.wordListIndex + delta + len(m.wordLists)) % len(m.wordLists))
+		case 5: // Digit in each word
+			m.passphraseConfig.InsertDigit = !m.passphraseConfig.InsertDigit
+		case 6: // Symbol in each word
+			m.passphraseConfig.InsertSymbol = !m.passphraseConfig.InsertSymbol
 		}
-	} else {
+
+	case modePattern:
+		if m.focusedOption == 1 {
+			m.passwordConfig.ExcludeAmbiguous = !m.passwordConfig.ExcludeAmbiguous
+		}
+
+	case modePronounceable:
+		switch m.focusedOption {
+		case 0: // Length
+			m.passwordConfig.Length = max(8, min(128, m.passwordConfig.Length+delta))
+		case 1: // Capitalize
+			m.passwordConfig.IncludeUpper = !m.passwordConfig.IncludeUpper
+		case 2: // Numbers
+			m.passwordConfig.IncludeNumbers = !m.passwordConfig.IncludeNumbers
+		case 3: // Exclude ambiguous
+			m.passwordConfig.ExcludeAmbiguous = !m.passwordConfig.ExcludeAmbiguous
+		}
+
+	default:
 		switch m.focusedOption {
 		case 0: // Length
 			m.passwordConfig.Length += delta
@@ -361,14 +694,22 @@ func (m *PasswordGeneratorModal) adjustOption(delta int) {
 			if m.passwordConfig.Length > 128 {
 				m.passwordConfig.Length = 128
 			}
+			m.passwordConfig.Length = m.clampLength(m.passwordConfig.Length)
 		case 1: // Uppercase
-			m.passwordConfig.IncludeUpper = !m.passwordConfig.IncludeUpper
-		case 2: // Numbers
-			m.passwordConfig.IncludeNumbers = !m.passwordConfig.IncludeNumbers
-		case 3: // Symbols
-			m.passwordConfig.IncludeSymbols = !m.passwordConfig.IncludeSymbols
-		case 4: // Exclude ambiguous
+			adjustClass(&m.passwordConfig.IncludeUpper, &m.passwordConfig.MinUpper, delta, m.passwordConfig.Length)
+		case 2: // Lowercase
+			adjustClass(&m.passwordConfig.IncludeLower, &m.passwordConfig.MinLower, delta, m.passwordConfig.Length)
+		case 3: // Numbers
+			adjustClass(&m.passwordConfig.IncludeNumbers, &m.passwordConfig.MinNumbers, delta, m.passwordConfig.Length)
+		case 4: // Symbols
+			adjustClass(&m.passwordConfig.IncludeSymbols, &m.passwordConfig.MinSymbols, delta, m.passwordConfig.Length)
+		case 8: // Exclude ambiguous
 			m.passwordConfig.ExcludeAmbiguous = !m.passwordConfig.ExcludeAmbiguous
+		case 9: // Site rules
+			if m.rules != nil {
+				m.useRules = !m.useRules
+				m.passwordConfig.Length = m.clampLength(m.passwordConfig.Length)
+			}
 		}
 	}
 }
@@ -378,27 +719,127 @@ func (m *PasswordGeneratorModal) generatePassword() {
 	var password string
 	var err error
 
-	if m.usePassphrase {
+	config := m.passwordConfig
+	switch m.mode {
+	case modePassphrase:
 		password, err = service.GeneratePassphrase(m.passphraseConfig)
-	} else {
-		password, err = service.GeneratePassword(m.passwordConfig)
+	case modePattern:
+		config.Mode = service.PasswordModePattern
+		password, err = service.GeneratePassword(config)
+	case modePronounceable:
+		config.Mode = service.PasswordModePronounceable
+		password, err = service.GeneratePassword(config)
+	default:
+		if m.useRules {
+			config.Rules = m.rules.String()
+		}
+		password, err = service.GeneratePassword(config)
 	}
 
+	m.failed = err != nil
 	if err != nil {
-		password = "Error generating password"
+		m.password = "Error: " + err.Error()
+		m.entropy = 0
+		m.strength = validator.StrengthWeak
+		return
 	}
 
 	m.password = password
-	m.entropy = service.CalculatePasswordEntropy(password)
-	m.strength = service.GetPasswordStrength(password)
+	switch m.mode {
+	case modePassphrase:
+		// Passphrases are rated by the size of their word list, not their characters
+		m.entropy = service.PassphraseEntropy(m.passphraseConfig)
+		m.strength = validator.GetStrengthFromEntropy(m.entropy)
+	case modePattern, modePronounceable:
+		// Structured passwords are rated by the choices made at each position
+		m.entropy, _ = service.PasswordEntropy(config)
+		m.strength = validator.GetStrengthFromEntropy(m.entropy)
+	default:
+		m.entropy = service.CalculatePasswordEntropy(password)
+		m.strength = service.GetPasswordStrength(password)
+	}
+}
+
+// optionCount returns the number of options of the current mode
+func (m *PasswordGeneratorModal) optionCount() int {
+	switch m.mode {
+	case modePassphrase:
+		return 7
+	case modePattern:
+		return 2
+	case modePronounceable:
+		return 4
+	default:
+		if m.rules != nil {
+			return 10
+		}
+		return 9
+	}
+}
+
+// focusedInput returns the text input of the selected option, or nil
+// Key presses go to it instead of navigating
+func (m *PasswordGeneratorModal) focusedInput() *textinput.Model {
+	switch {
+	case m.mode == modePattern && m.focusedOption == 0:
+		return &m.patternInput
+	case m.mode == modeRandom && m.focusedOption == 5:
+		return &m.symbolsInput
+	case m.mode == modeRandom && m.focusedOption == 6:
+		return &m.allowedInput
+	case m.mode == modeRandom && m.focusedOption == 7:
+		return &m.excludedInput
+	}
+	return nil
+}
+
+// updateInputFocus focuses the text input of the selected option
+func (m *PasswordGeneratorModal) updateInputFocus() {
+	focused := m.focusedInput()
+	for _, input := range []*textinput.Model{&m.patternInput, &m.symbolsInput, &m.allowedInput, &m.excludedInput} {
+		if input == focused {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+}
+
+// syncInputs copies the text inputs into the password configuration
+func (m *PasswordGeneratorModal) syncInputs() {
+	m.passwordConfig.Pattern = m.patternInput.Value()
+	m.passwordConfig.Symbols = m.symbolsInput.Value()
+	m.passwordConfig.AllowedChars = m.allowedInput.Value()
+	m.passwordConfig.ExcludedChars = m.excludedInput.Value()
+}
+
+// clampLength limits a length to the site rules when they are applied
+func (m *PasswordGeneratorModal) clampLength(length int) int {
+	if !m.useRules {
+		return length
+	}
+	if m.rules.MinLength > 0 && length < m.rules.MinLength {
+		length = m.rules.MinLength
+	}
+	if m.rules.MaxLength > 0 && length > m.rules.MaxLength {
+		length = m.rules.MaxLength
+	}
+	return length
+}
+
+// modeName returns the name of the current mode as used in the configuration
+func (m *PasswordGeneratorModal) modeName() string {
+	return strings.ToLower(m.mode.String())
 }
 
 // UsePasswordMsg signals to use the generated password
 type UsePasswordMsg struct {
 	Password string
+	Mode     string
 }
 
 // CopyPasswordMsg signals to copy the password
 type CopyPasswordMsg struct {
 	Password string
+	Mode     string
 }
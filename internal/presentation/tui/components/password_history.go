@@ -0,0 +1,122 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+)
+
+// historyPageSize is the number of history items shown at once
+const historyPageSize = 10
+
+// SetHistory sets the recently generated passwords shown in the history view
+func (m *PasswordGeneratorModal) SetHistory(history []entity.GeneratedValue) {
+	m.history = history
+	if m.historyIndex >= len(history) {
+		m.historyIndex = max(0, len(history)-1)
+	}
+}
+
+// updateHistory handles keys while the history view is shown
+func (m *PasswordGeneratorModal) updateHistory(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "ctrl+y":
+		m.showHistory = false
+		m.updateInputFocus()
+		return nil
+
+	case "up", "k":
+		if m.historyIndex > 0 {
+			m.historyIndex--
+		}
+		return nil
+
+	case "down", "j":
+		if m.historyIndex < len(m.history)-1 {
+			m.historyIndex++
+		}
+		return nil
+	}
+
+	if len(m.history) == 0 {
+		return nil
+	}
+	item := m.history[m.historyIndex]
+
+	switch msg.String() {
+	case "enter":
+		m.Hide()
+		return func() tea.Msg {
+			return UsePasswordMsg{Password: item.Value, Mode: item.Mode}
+		}
+
+	case "ctrl+c":
+		return func() tea.Msg {
+			return CopyPasswordMsg{Password: item.Value, Mode: item.Mode}
+		}
+
+	case "ctrl+n":
+		m.Hide()
+		return func() tea.Msg {
+			return NewEntryWithPasswordMsg{Password: item.Value}
+		}
+	}
+
+	return nil
+}
+
+// renderHistory renders the list of recently generated passwords
+// Only the selected password is shown in clear text
+func (m *PasswordGeneratorModal) renderHistory() string {
+	var content strings.Builder
+	subtle := lipgloss.NewStyle().Foreground(styles.Subtle)
+
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("History (%d)", len(m.history))))
+	content.WriteString("\n\n")
+
+	if len(m.history) == 0 {
+		content.WriteString(subtle.Render("No passwords yet. Copied and used passwords are kept here."))
+		content.WriteString("\n\n")
+		content.WriteString(styles.HelpStyle.Render("[Ctrl+Y] Back  •  [Esc] Back"))
+		return content.String()
+	}
+
+	// Keep the selection on screen
+	start := max(0, min(m.historyIndex-historyPageSize/2, len(m.history)-historyPageSize))
+	end := min(len(m.history), start+historyPageSize)
+
+	for i := start; i < end; i++ {
+		item := m.history[i]
+		value := strings.Repeat("•", min(16, len(item.Value)))
+		style := lipgloss.NewStyle()
+		prefix := "  "
+		if i == m.historyIndex {
+			value = item.Value
+			style = style.Foreground(styles.Primary).Bold(true)
+			prefix = "> "
+		}
+
+		content.WriteString(style.Render(fmt.Sprintf("%s%s  %-13s  %s",
+			prefix, item.CreatedAt.Format("2006-01-02 15:04"), item.Mode, value)))
+		content.WriteString("\n")
+	}
+	if len(m.history) > historyPageSize {
+		content.WriteString(subtle.Render(fmt.Sprintf("  %d-%d of %d", start+1, end, len(m.history))))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	helpText := "[↑↓] Select  •  [Enter] Use  •  [Ctrl+C] Copy  •  [Ctrl+N] New Entry  •  [Ctrl+Y] Back"
+	content.WriteString(styles.HelpStyle.Render(helpText))
+
+	return content.String()
+}
+
+// NewEntryWithPasswordMsg signals to create an entry with a password from the history
+type NewEntryWithPasswordMsg struct {
+	Password string
+}
@@ -0,0 +1,316 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/config"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+	"github.com/hambosto/passmanager/internal/presentation/tui/util"
+)
+
+// UsernameGeneratorModal is a modal for generating usernames and email aliases
+type UsernameGeneratorModal struct {
+	visible bool
+	width   int
+	height  int
+
+	config service.UsernameConfig
+
+	// Generated username or the reason it could not be generated
+	username string
+	err      string
+
+	// UI state
+	focusedOption int
+}
+
+// NewUsernameGeneratorModal creates a new username generator modal
+func NewUsernameGeneratorModal() *UsernameGeneratorModal {
+	return &UsernameGeneratorModal{
+		config: service.DefaultUsernameConfig(),
+	}
+}
+
+// ApplyConfig sets the generator defaults from the application configuration
+func (m *UsernameGeneratorModal) ApplyConfig(cfg *config.Config) {
+	settings := cfg.UsernameGenerator
+	if mode, err := service.ParseUsernameMode(settings.Mode); err == nil {
+		m.config.Mode = mode
+	}
+	m.config.WordCount = settings.WordCount
+	m.config.Separator = settings.Separator
+	m.config.IncludeNumber = settings.IncludeNumber
+	m.config.Email = settings.Email
+	m.config.Domain = settings.CatchAllDomain
+	m.config.Template = settings.Template
+}
+
+// Show shows the modal, using site in the templates of the email modes
+func (m *UsernameGeneratorModal) Show(site string) {
+	m.config.Site = site
+	m.focusedOption = 0
+	m.visible = true
+	m.generateUsername()
+}
+
+// Hide hides the modal
+func (m *UsernameGeneratorModal) Hide() {
+	m.visible = false
+}
+
+// IsVisible returns whether the modal is visible
+func (m *UsernameGeneratorModal) IsVisible() bool {
+	return m.visible
+}
+
+// Update handles messages
+func (m *UsernameGeneratorModal) Update(msg tea.Msg) tea.Cmd {
+	if !m.visible {
+		return nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.Hide()
+			return nil
+
+		case "enter":
+			if m.err != "" {
+				return nil
+			}
+			m.Hide()
+			username := m.username
+			return func() tea.Msg {
+				return UseUsernameMsg{Username: username}
+			}
+
+		case "ctrl+r":
+			m.generateUsername()
+			return nil
+
+		case "ctrl+c":
+			if m.err != "" {
+				return nil
+			}
+			username := m.username
+			return func() tea.Msg {
+				return CopyUsernameMsg{Username: username}
+			}
+
+		case "tab", "shift+tab":
+			// Cycle through the modes
+			delta := 1
+			if msg.String() == "shift+tab" {
+				delta = -1
+			}
+			modes := service.UsernameModes
+			index := max(0, slices.Index(modes, m.config.Mode))
+			m.config.Mode = modes[(index+delta+len(modes))%len(modes)]
+			m.focusedOption = 0
+			m.generateUsername()
+			return nil
+
+		case "up", "k":
+			if m.focusedOption > 0 {
+				m.focusedOption--
+			}
+			return nil
+
+		case "down", "j":
+			if m.focusedOption < m.optionCount()-1 {
+				m.focusedOption++
+			}
+			return nil
+
+		case "left", "h":
+			m.adjustOption(-1)
+			m.generateUsername()
+			return nil
+
+		case "right", "l":
+			m.adjustOption(1)
+			m.generateUsername()
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// View renders the modal
+func (m *UsernameGeneratorModal) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	var content strings.Builder
+
+	content.WriteString(styles.TitleStyle.Render(styles.IconIdentity + " Username Generator"))
+	content.WriteString("\n\n")
+
+	// Mode selector
+	var modes []string
+	for _, mode := range service.UsernameModes {
+		marker := "○"
+		if mode == m.config.Mode {
+			marker = "●"
+		}
+		modes = append(modes, marker+" "+mode.Label())
+	}
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Primary).Render(strings.Join(modes, "  ")))
+	content.WriteString("\n\n")
+
+	// Generated username
+	result := lipgloss.NewStyle().Bold(true).Foreground(styles.Success).Render(m.username)
+	if m.err != "" {
+		result = styles.ErrorStyle.Render(m.err)
+	}
+	content.WriteString(styles.BoxStyle.
+		Width(util.MinInt(60, m.width-10)).
+		Align(lipgloss.Center).
+		Render(result))
+	content.WriteString("\n\n")
+
+	content.WriteString(m.renderOptions())
+	content.WriteString("\n\n")
+
+	helpText := "[Ctrl+R] Regenerate  •  [Ctrl+C] Copy  •  [Enter] Use  •  [Tab] Switch Mode  •  [Esc] Cancel"
+	content.WriteString(styles.HelpStyle.Render(helpText))
+
+	modalBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Primary).
+		Padding(2, 4).
+		Width(util.MinInt(70, m.width-4)).
+		Render(content.String())
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modalBox)
+}
+
+// renderOptions renders the options of the current mode
+func (m *UsernameGeneratorModal) renderOptions() string {
+	var content strings.Builder
+	subtle := lipgloss.NewStyle().Foreground(styles.Subtle)
+
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render("Options"))
+	content.WriteString("\n\n")
+
+	site := m.config.Site
+	if site == "" {
+		site = "(none, a random word is used)"
+	}
+
+	switch m.config.Mode {
+	case service.UsernameRandomWords:
+		content.WriteString(m.renderOption(0, fmt.Sprintf("Word Count: %d", m.config.WordCount)))
+		content.WriteString("\n")
+		content.WriteString(m.renderOption(1, fmt.Sprintf("Separator: %q", m.config.Separator)))
+		content.WriteString("\n")
+		numberIcon := "☐"
+		if m.config.IncludeNumber {
+			numberIcon = "☑"
+		}
+		content.WriteString(m.renderOption(2, numberIcon+" Include Number"))
+		content.WriteString("\n\n")
+		content.WriteString(styles.HelpStyle.Render("[↑↓] Navigate  [←→] Adjust"))
+
+	case service.UsernamePlusAddress:
+		content.WriteString(subtle.Render("Email: " + valueOr(m.config.Email, "not set (username_generator.email)")))
+		content.WriteString("\n")
+		content.WriteString(subtle.Render("Tag: " + valueOr(m.config.Template, service.DefaultPlusTemplate)))
+		content.WriteString("\n")
+		content.WriteString(subtle.Render("Site: " + site))
+
+	case service.UsernameCatchAll:
+		content.WriteString(subtle.Render("Domain: " + valueOr(m.config.Domain, "not set (username_generator.catch_all_domain)")))
+		content.WriteString("\n")
+		content.WriteString(subtle.Render("Address: " + valueOr(m.config.Template, service.DefaultCatchAllTemplate)))
+		content.WriteString("\n")
+		content.WriteString(subtle.Render("Site: " + site))
+
+	case service.UsernameUUID:
+		content.WriteString(subtle.Render("Random version 4 UUID"))
+	}
+
+	return content.String()
+}
+
+// valueOr returns value, or fallback when value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// renderOption renders a single option
+func (m *UsernameGeneratorModal) renderOption(index int, text string) string {
+	style := lipgloss.NewStyle()
+	if index == m.focusedOption {
+		style = style.Foreground(styles.Primary).Bold(true)
+		text = "> " + text
+	} else {
+		text = "  " + text
+	}
+	return style.Render(text)
+}
+
+// optionCount returns the number of adjustable options of the current mode
+func (m *UsernameGeneratorModal) optionCount() int {
+	if m.config.Mode == service.UsernameRandomWords {
+		return 3
+	}
+	return 0
+}
+
+// adjustOption adjusts the focused option's value
+func (m *UsernameGeneratorModal) adjustOption(delta int) {
+	if m.config.Mode != service.UsernameRandomWords {
+		return
+	}
+
+	switch m.focusedOption {
+	case 0: // Word count
+		m.config.WordCount = max(1, min(5, m.config.WordCount+delta))
+	case 1: // Separator
+		separators := []string{"_", ".", "-", ""}
+		index := max(0, slices.Index(separators, m.config.Separator))
+		m.config.Separator = separators[(index+delta+len(separators))%len(separators)]
+	case 2: // Include number
+		m.config.IncludeNumber = !m.config.IncludeNumber
+	}
+}
+
+// generateUsername generates a new username based on the current config
+func (m *UsernameGeneratorModal) generateUsername() {
+	username, err := service.GenerateUsername(m.config)
+	if err != nil {
+		m.username = ""
+		m.err = err.Error()
+		return
+	}
+	m.username = username
+	m.err = ""
+}
+
+// UseUsernameMsg signals to use the generated username
+type UseUsernameMsg struct {
+	Username string
+}
+
+// CopyUsernameMsg signals to copy the username
+type CopyUsernameMsg struct {
+	Username string
+}
@@ -0,0 +1,306 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/clipboard"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+	"github.com/hambosto/passmanager/internal/presentation/tui/util"
+	"github.com/sahilm/fuzzy"
+)
+
+// defaultTOTPPeriod is the period shown by the shared countdown bar
+const defaultTOTPPeriod = 30
+
+// AuthenticatorScreen lists the live one-time passwords of all entries
+type AuthenticatorScreen struct {
+	vault     *entity.Vault
+	clipboard *clipboard.Manager
+	totp      *service.TOTPService
+	width     int
+	height    int
+
+	// Entries with one-time passwords and the subset matching the filter
+	entries  []*entity.Entry
+	filtered []*entity.Entry
+	filter   textinput.Model
+
+	// Current codes by entry ID
+	codes     map[string]string
+	expiresIn map[string]time.Duration
+	ticker    *time.Ticker
+
+	// UI state
+	cursor      int
+	copyMessage string
+}
+
+// NewAuthenticatorScreen creates a new authenticator overview
+func NewAuthenticatorScreen(vault *entity.Vault, clipboardMgr *clipboard.Manager) *AuthenticatorScreen {
+	filter := textinput.New()
+	filter.Placeholder = "Type to filter"
+	filter.Prompt = "/ "
+	filter.Width = 40
+	filter.Focus()
+
+	s := &AuthenticatorScreen{
+		vault:     vault,
+		clipboard: clipboardMgr,
+		totp:      service.NewTOTPService(),
+		filter:    filter,
+		codes:     make(map[string]string),
+		expiresIn: make(map[string]time.Duration),
+		ticker:    time.NewTicker(1 * time.Second),
+	}
+
+	for _, entry := range vault.Entries {
+		if entry.HasTOTP() {
+			s.entries = append(s.entries, entry)
+		}
+	}
+	s.applyFilter()
+	s.updateCodes()
+	return s
+}
+
+// Init initializes the screen
+func (s *AuthenticatorScreen) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, s.tickCmd())
+}
+
+// Update handles messages
+func (s *AuthenticatorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		return s, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			// Clear the filter first, then go back
+			if s.filter.Value() != "" {
+				s.filter.SetValue("")
+				s.applyFilter()
+				return s, nil
+			}
+			return s, func() tea.Msg { return BackMsg{} }
+
+		case "ctrl+c", "ctrl+q":
+			return s, tea.Quit
+
+		case "up", "ctrl+k":
+			if s.cursor > 0 {
+				s.cursor--
+			}
+			return s, nil
+
+		case "down", "ctrl+j":
+			if s.cursor < len(s.filtered)-1 {
+				s.cursor++
+			}
+			return s, nil
+
+		case "enter":
+			return s, s.copySelected()
+		}
+
+		// Everything else edits the filter
+		var cmd tea.Cmd
+		before := s.filter.Value()
+		s.filter, cmd = s.filter.Update(msg)
+		if s.filter.Value() != before {
+			s.applyFilter()
+		}
+		return s, cmd
+
+	case tickMsg:
+		s.updateCodes()
+		return s, s.tickCmd()
+
+	case clearCopyMsgMsg:
+		s.copyMessage = ""
+		return s, nil
+	}
+
+	return s, nil
+}
+
+// View renders the screen
+func (s *AuthenticatorScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(styles.IconClock + " Authenticator"))
+	b.WriteString("\n\n")
+
+	b.WriteString(s.filter.View())
+	b.WriteString("\n\n")
+
+	// Shared countdown for the standard 30 second period
+	remaining := defaultTOTPPeriod - int(time.Now().Unix()%defaultTOTPPeriod)
+	b.WriteString(styles.RenderProgressBar(float64(remaining)/defaultTOTPPeriod, 40))
+	b.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render(fmt.Sprintf(" %2ds", remaining)))
+	b.WriteString("\n\n")
+
+	switch {
+	case len(s.entries) == 0:
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render("No entries with one-time passwords"))
+	case len(s.filtered) == 0:
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render("No matches"))
+	default:
+		b.WriteString(s.renderCodes())
+	}
+	b.WriteString("\n\n")
+
+	if s.copyMessage != "" {
+		b.WriteString(styles.SuccessStyle.Render(styles.IconSuccess + " " + s.copyMessage))
+		b.WriteString("\n\n")
+	}
+
+	helpText := "[Esc] Back  •  [↑↓] Navigate  •  [Enter] Copy code  •  Type to filter"
+	b.WriteString(styles.HelpStyle.Render(helpText))
+
+	return b.String()
+}
+
+// renderCodes renders the visible part of the code list
+func (s *AuthenticatorScreen) renderCodes() string {
+	// Title, filter, countdown and help take about 12 lines
+	visible := util.MaxInt(3, s.height-12)
+	start := 0
+	if s.cursor >= visible {
+		start = s.cursor - visible + 1
+	}
+	end := util.MinInt(len(s.filtered), start+visible)
+
+	nameWidth := util.MaxInt(20, util.MinInt(40, s.width-30))
+	codeStyle := lipgloss.NewStyle().Foreground(styles.Success).Bold(true)
+	subtle := lipgloss.NewStyle().Foreground(styles.Subtle)
+
+	var lines []string
+	for i := start; i < end; i++ {
+		entry := s.filtered[i]
+
+		name := entry.Name
+		if entry.TOTP.Account != "" && entry.TOTP.Account != entry.Name {
+			name += " (" + entry.TOTP.Account + ")"
+		}
+		name = truncate(name, nameWidth)
+
+		var code, detail string
+		switch {
+		case entry.TOTP.IsHOTP() && s.codes[entry.ID] == "":
+			code = strings.Repeat("•", 6)
+			detail = "Enter for next code"
+		case s.codes[entry.ID] == "":
+			code = "error"
+		default:
+			code = formatTOTPCode(s.codes[entry.ID])
+			if period := entry.TOTP.Period; period > 0 && period != defaultTOTPPeriod {
+				detail = fmt.Sprintf("%ds", int(s.expiresIn[entry.ID].Seconds()))
+			}
+		}
+
+		line := fmt.Sprintf("%-*s  %s  %s", nameWidth, name, codeStyle.Render(fmt.Sprintf("%-11s", code)), subtle.Render(detail))
+		if i == s.cursor {
+			lines = append(lines, styles.SelectedItemStyle.Render("> ")+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// applyFilter fuzzy-matches entries by name, best matches first
+func (s *AuthenticatorScreen) applyFilter() {
+	pattern := strings.TrimSpace(s.filter.Value())
+	if pattern == "" {
+		s.filtered = s.entries
+	} else {
+		names := make([]string, len(s.entries))
+		for i, entry := range s.entries {
+			names[i] = entry.Name + " " + entry.TOTP.Issuer + " " + entry.TOTP.Account
+		}
+
+		s.filtered = nil
+		for _, match := range fuzzy.Find(pattern, names) {
+			s.filtered = append(s.filtered, s.entries[match.Index])
+		}
+	}
+
+	if s.cursor >= len(s.filtered) {
+		s.cursor = util.MaxInt(0, len(s.filtered)-1)
+	}
+}
+
+// updateCodes regenerates the time-based codes of all entries
+func (s *AuthenticatorScreen) updateCodes() {
+	for _, entry := range s.entries {
+		if entry.TOTP.IsHOTP() {
+			continue
+		}
+		code, expiresIn, err := s.totp.GenerateCode(entry.TOTP)
+		if err != nil {
+			code = ""
+		}
+		s.codes[entry.ID] = code
+		s.expiresIn[entry.ID] = expiresIn
+	}
+}
+
+// copySelected copies the code of the selected entry
+// HOTP entries generate their next code, which advances the stored counter
+func (s *AuthenticatorScreen) copySelected() tea.Cmd {
+	if s.cursor >= len(s.filtered) {
+		return nil
+	}
+	entry := s.filtered[s.cursor]
+
+	var cmds []tea.Cmd
+	code := s.codes[entry.ID]
+	if entry.TOTP.IsHOTP() {
+		next, err := s.totp.NextHOTPCode(entry.TOTP)
+		if err != nil {
+			return nil
+		}
+		code = next
+		s.codes[entry.ID] = next
+		entry.Update()
+		cmds = append(cmds, func() tea.Msg { return VaultChangedMsg{} })
+	}
+	if code == "" {
+		return nil
+	}
+
+	s.clipboard.CopyWithTimeout(code)
+	entry.UpdateAccessTime()
+	s.copyMessage = "Code for " + entry.Name + " copied!"
+	cmds = append(cmds, tea.Tick(2*time.Second, func(time.Time) tea.Msg { return clearCopyMsgMsg{} }))
+	return tea.Batch(cmds...)
+}
+
+// tickCmd returns a command that waits for the next tick
+func (s *AuthenticatorScreen) tickCmd() tea.Cmd {
+	return func() tea.Msg {
+		<-s.ticker.C
+		return tickMsg{}
+	}
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
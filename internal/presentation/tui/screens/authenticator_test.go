@@ -0,0 +1,178 @@
+package screens
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/clipboard"
+)
+
+// rfc4226Secret is the Base32 form of the RFC 4226 test secret "12345678901234567890"
+const rfc4226Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// newTestAuthenticator returns an authenticator over two TOTP entries, an HOTP entry and a plain login
+func newTestAuthenticator(t *testing.T) (*AuthenticatorScreen, *clipboard.MemoryBackend, *entity.Vault) {
+	t.Helper()
+	vault := entity.NewVault()
+
+	github := entity.NewEntry(entity.EntryTypeLogin, "GitHub")
+	github.TOTP = &entity.TOTPConfig{Secret: rfc4226Secret, Algorithm: "SHA1", Digits: 6, Period: 30}
+	vault.AddEntry(github)
+
+	gitlab := entity.NewEntry(entity.EntryTypeLogin, "GitLab")
+	gitlab.TOTP = &entity.TOTPConfig{Secret: rfc4226Secret, Algorithm: "SHA1", Digits: 6, Period: 30, Account: "alice"}
+	vault.AddEntry(gitlab)
+
+	vpn := entity.NewEntry(entity.EntryTypeLogin, "VPN")
+	vpn.TOTP = &entity.TOTPConfig{Type: "hotp", Secret: rfc4226Secret, Algorithm: "SHA1", Digits: 6}
+	vault.AddEntry(vpn)
+
+	vault.AddEntry(entity.NewEntry(entity.EntryTypeLogin, "Plain"))
+
+	backend := &clipboard.MemoryBackend{}
+	screen := NewAuthenticatorScreen(vault, clipboard.NewManagerWithBackend(backend, 0))
+	t.Cleanup(screen.ticker.Stop)
+	return screen, backend, vault
+}
+
+// typeText sends each rune of text to the screen as a key press
+func typeText(screen *AuthenticatorScreen, text string) {
+	for _, r := range text {
+		screen.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// collectMsgs runs cmd and any batched commands, returning the messages produced within wait
+// Long-running commands such as ticks are abandoned
+func collectMsgs(cmd tea.Cmd, wait time.Duration) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	results := make(chan tea.Msg, 16)
+	var run func(tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		go func() {
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, sub := range batch {
+					if sub != nil {
+						run(sub)
+					}
+				}
+				return
+			}
+			results <- msg
+		}()
+	}
+	run(cmd)
+
+	var msgs []tea.Msg
+	deadline := time.After(wait)
+	for {
+		select {
+		case msg := <-results:
+			msgs = append(msgs, msg)
+		case <-deadline:
+			return msgs
+		}
+	}
+}
+
+func TestAuthenticatorListsOTPEntries(t *testing.T) {
+	screen, _, _ := newTestAuthenticator(t)
+
+	if len(screen.filtered) != 3 {
+		t.Fatalf("filtered = %d entries, want the 3 with one-time passwords", len(screen.filtered))
+	}
+	if screen.codes[screen.entries[0].ID] == "" {
+		t.Error("TOTP code was not generated")
+	}
+	if screen.codes[screen.entries[2].ID] != "" {
+		t.Error("HOTP code was generated without a key press")
+	}
+}
+
+func TestAuthenticatorFilter(t *testing.T) {
+	screen, _, _ := newTestAuthenticator(t)
+
+	typeText(screen, "lab")
+	if len(screen.filtered) != 1 || screen.filtered[0].Name != "GitLab" {
+		t.Fatalf("filter %q matched %d entries, want GitLab", screen.filter.Value(), len(screen.filtered))
+	}
+
+	// The filter also matches the account name
+	screen.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	typeText(screen, "alice")
+	if len(screen.filtered) != 1 || screen.filtered[0].Name != "GitLab" {
+		t.Errorf("filter %q matched %d entries, want GitLab", screen.filter.Value(), len(screen.filtered))
+	}
+
+	screen.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	typeText(screen, "zzz")
+	if len(screen.filtered) != 0 || screen.cursor != 0 {
+		t.Errorf("filter %q matched %d entries with cursor %d, want none", screen.filter.Value(), len(screen.filtered), screen.cursor)
+	}
+
+	// Esc clears the filter before leaving the screen
+	_, cmd := screen.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if screen.filter.Value() != "" || len(screen.filtered) != 3 {
+		t.Errorf("Esc left filter %q with %d entries, want it cleared", screen.filter.Value(), len(screen.filtered))
+	}
+	if cmd != nil {
+		t.Error("first Esc left the screen")
+	}
+	_, cmd = screen.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if msgs := collectMsgs(cmd, 50*time.Millisecond); len(msgs) != 1 || msgs[0] != (BackMsg{}) {
+		t.Errorf("second Esc = %v, want BackMsg", msgs)
+	}
+}
+
+func TestAuthenticatorCopy(t *testing.T) {
+	screen, backend, _ := newTestAuthenticator(t)
+
+	screen.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := screen.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	want := screen.codes[screen.filtered[1].ID]
+	if got, _ := backend.PasteText(); got != want || got == "" {
+		t.Errorf("clipboard = %q, want the GitLab code %q", got, want)
+	}
+	if screen.copyMessage == "" {
+		t.Error("copy was not confirmed")
+	}
+	for _, msg := range collectMsgs(cmd, 50*time.Millisecond) {
+		if _, ok := msg.(VaultChangedMsg); ok {
+			t.Error("copying a TOTP code reported a vault change")
+		}
+	}
+}
+
+func TestAuthenticatorAdvancesHOTP(t *testing.T) {
+	screen, backend, vault := newTestAuthenticator(t)
+	vpn := vault.Entries[2]
+
+	typeText(screen, "vpn")
+	for i, want := range []string{"755224", "287082"} {
+		_, cmd := screen.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		if got, _ := backend.PasteText(); got != want {
+			t.Errorf("press %d: clipboard = %q, want %q", i+1, got, want)
+		}
+		if vpn.TOTP.Counter != uint64(i+1) {
+			t.Errorf("press %d: counter = %d, want %d", i+1, vpn.TOTP.Counter, i+1)
+		}
+
+		changed := false
+		for _, msg := range collectMsgs(cmd, 50*time.Millisecond) {
+			if _, ok := msg.(VaultChangedMsg); ok {
+				changed = true
+			}
+		}
+		if !changed {
+			t.Errorf("press %d: advancing the counter did not report a vault change", i+1)
+		}
+	}
+}
@@ -5,27 +5,65 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/config"
+	"github.com/hambosto/passmanager/internal/application/service"
 	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/infrastructure/autotype"
 	"github.com/hambosto/passmanager/internal/infrastructure/clipboard"
 	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
 	"github.com/hambosto/passmanager/internal/presentation/tui/util"
-	"github.com/hambosto/passmanager/pkg/totp"
+	"github.com/hambosto/passmanager/pkg/qrcode"
+)
+
+// qrState tracks the steps of revealing the provisioning QR code
+type qrState int
+
+const (
+	qrHidden qrState = iota
+	qrConfirm
+	qrVerifying
+	qrShown
+)
+
+// autoTypeState tracks an auto-type from the countdown to the last keystroke
+type autoTypeState int
+
+const (
+	autoTypeIdle autoTypeState = iota
+	autoTypePending
+	autoTypeTyping
 )
 
 // EntryDetailScreen shows the details of a single entry
 type EntryDetailScreen struct {
 	entry     *entity.Entry
 	clipboard *clipboard.Manager
+	totp      *service.TOTPService
 	width     int
 	height    int
 
 	// TOTP state
 	totpCode      string
 	totpExpiresIn time.Duration
+	totpError     string
 	ticker        *time.Ticker
 
+	// QR code state
+	qrState    qrState
+	qrPassword textinput.Model
+	qrError    string
+
+	// Auto-type state
+	autoTypeConfig config.AutoTypeConfig
+	autoType       *service.AutoTypeService
+	autoTypeState  autoTypeState
+	autoTypeAt     time.Time
+	autoTypeID     int
+	autoTypeError  string
+
 	// UI state
 	showPassword bool
 	copyMessage  string
@@ -34,21 +72,36 @@ type EntryDetailScreen struct {
 
 // NewEntryDetailScreen creates a new entry detail screen
 func NewEntryDetailScreen(entry *entity.Entry, clipboardMgr *clipboard.Manager) *EntryDetailScreen {
+	qrPassword := textinput.New()
+	qrPassword.Placeholder = "Master password"
+	qrPassword.EchoMode = textinput.EchoPassword
+	qrPassword.EchoCharacter = '•'
+	qrPassword.Width = 40
+
 	return &EntryDetailScreen{
-		entry:        entry,
-		clipboard:    clipboardMgr,
-		ticker:       time.NewTicker(1 * time.Second),
-		showPassword: false,
+		entry:          entry,
+		clipboard:      clipboardMgr,
+		totp:           service.NewTOTPService(),
+		ticker:         time.NewTicker(1 * time.Second),
+		qrPassword:     qrPassword,
+		autoTypeConfig: config.DefaultConfig().AutoType,
+		showPassword:   false,
 	}
 }
 
+// SetAutoTypeConfig sets the typing backend, default sequence and delays of auto-type
+func (s *EntryDetailScreen) SetAutoTypeConfig(cfg config.AutoTypeConfig) {
+	s.autoTypeConfig = cfg
+}
+
 // Init initializes the screen
 func (s *EntryDetailScreen) Init() tea.Cmd {
 	// Update access time
 	s.entry.UpdateAccessTime()
 
 	// Generate initial TOTP code if available
-	if s.entry.TOTPSecret != "" {
+	// HOTP codes are only generated on request since that consumes a counter value
+	if s.entry.HasTOTP() && !s.entry.TOTP.IsHOTP() {
 		s.updateTOTP()
 	}
 
@@ -64,11 +117,34 @@ func (s *EntryDetailScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return s, nil
 
 	case tea.KeyMsg:
+		if s.qrState != qrHidden {
+			return s, s.updateQR(msg)
+		}
+
 		switch msg.String() {
 		case "esc":
+			// Cancel a pending auto-type before leaving the screen
+			if s.autoTypeState == autoTypePending {
+				s.autoTypeState = autoTypeIdle
+				return s, nil
+			}
+
 			// Go back to vault list
 			return s, func() tea.Msg { return BackMsg{} }
 
+		case "ctrl+a":
+			// Type the entry into the window focused when the countdown ends
+			return s, s.startAutoType()
+
+		case "ctrl+r":
+			// Ask for confirmation before revealing the provisioning QR code
+			if s.entry.HasTOTP() {
+				s.qrState = qrConfirm
+				s.qrError = ""
+				s.qrPassword.Reset()
+				return s, s.qrPassword.Focus()
+			}
+
 		case "ctrl+c", "ctrl+q":
 			return s, tea.Quit
 
@@ -101,6 +177,32 @@ func (s *EntryDetailScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return s, s.clearCopyMessageCmd()
 			}
 
+		case "ctrl+k":
+			// Copy the public key of an SSH key
+			if s.entry.HasSSHKey() {
+				s.clipboard.CopyWithTimeout(s.entry.SSHKey.PublicKey)
+				s.showCopyMessage("Public key copied!")
+				return s, s.clearCopyMessageCmd()
+			}
+
+		case "ctrl+n":
+			// Generate the next HOTP code and persist the advanced counter
+			if s.entry.HasTOTP() && s.entry.TOTP.IsHOTP() {
+				code, err := s.totp.NextHOTPCode(s.entry.TOTP)
+				if err != nil {
+					s.totpError = err.Error()
+					return s, nil
+				}
+				s.totpCode = code
+				s.totpError = ""
+				s.entry.Update()
+				s.showCopyMessage("Counter advanced")
+				return s, tea.Batch(
+					func() tea.Msg { return VaultChangedMsg{} },
+					s.clearCopyMessageCmd(),
+				)
+			}
+
 		case "ctrl+e":
 			// Edit entry
 			return s, func() tea.Msg { return EditEntryMsg{Entry: s.entry} }
@@ -117,11 +219,40 @@ func (s *EntryDetailScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tickMsg:
 		// Update TOTP code
-		if s.entry.TOTPSecret != "" {
+		if s.entry.HasTOTP() && !s.entry.TOTP.IsHOTP() {
 			s.updateTOTP()
 		}
 		return s, s.tickCmd()
 
+	case ReauthenticatedMsg:
+		if s.qrState != qrVerifying {
+			return s, nil
+		}
+		s.qrPassword.Reset()
+		if !msg.OK {
+			s.qrState = qrConfirm
+			s.qrError = "Wrong master password"
+			return s, s.qrPassword.Focus()
+		}
+		s.qrState = qrShown
+		return s, nil
+
+	case autoTypeStartMsg:
+		if msg.id != s.autoTypeID || s.autoTypeState != autoTypePending {
+			return s, nil
+		}
+		return s, s.typeEntry()
+
+	case AutoTypeDoneMsg:
+		// The app saves an advanced HOTP counter, even after the screen was left
+		s.autoTypeState = autoTypeIdle
+		if msg.Err != nil {
+			s.autoTypeError = "Auto-type failed: " + msg.Err.Error()
+			return s, nil
+		}
+		s.showCopyMessage("Auto-type finished")
+		return s, s.clearCopyMessageCmd()
+
 	case clearCopyMsgMsg:
 		s.copyMessage = ""
 		return s, nil
@@ -130,8 +261,101 @@ func (s *EntryDetailScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return s, nil
 }
 
+// startAutoType checks the sequence and the typing tool, then starts the countdown
+// that gives the user time to focus the target window
+func (s *EntryDetailScreen) startAutoType() tea.Cmd {
+	if s.autoTypeState != autoTypeIdle {
+		return nil
+	}
+
+	sequence := service.AutoTypeSequence(s.entry, s.autoTypeConfig.DefaultSequence)
+	if _, err := service.ParseAutoTypeSequence(sequence); err != nil {
+		s.autoTypeError = "Invalid auto-type sequence: " + err.Error()
+		return nil
+	}
+	typer, err := autotype.NewTyper(s.autoTypeConfig.Backend)
+	if err != nil {
+		s.autoTypeError = err.Error()
+		return nil
+	}
+
+	s.autoType = service.NewAutoTypeService(typer)
+	s.autoType.SetKeyDelay(time.Duration(s.autoTypeConfig.KeyDelay) * time.Millisecond)
+	s.autoTypeError = ""
+	s.autoTypeState = autoTypePending
+	s.autoTypeID++
+
+	id := s.autoTypeID
+	delay := time.Duration(max(0, s.autoTypeConfig.StartDelay)) * time.Second
+	s.autoTypeAt = time.Now().Add(delay)
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return autoTypeStartMsg{id: id}
+	})
+}
+
+// typeEntry resolves the entry fields, then types them in the background
+// Fields and the HOTP counter are handled here so the command never touches the entry
+func (s *EntryDetailScreen) typeEntry() tea.Cmd {
+	sequence := service.AutoTypeSequence(s.entry, s.autoTypeConfig.DefaultSequence)
+	job, err := s.autoType.Prepare(s.entry, sequence)
+	if err != nil {
+		s.autoTypeState = autoTypeIdle
+		s.autoTypeError = "Auto-type failed: " + err.Error()
+		return nil
+	}
+
+	s.autoTypeState = autoTypeTyping
+	autoType := s.autoType
+	return func() tea.Msg {
+		return AutoTypeDoneMsg{Err: autoType.Run(job), CounterAdvanced: job.CounterAdvanced}
+	}
+}
+
+// updateQR handles keys while the QR code is being revealed or shown
+func (s *EntryDetailScreen) updateQR(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c", "ctrl+q":
+		return tea.Quit
+
+	case "esc":
+		s.hideQR()
+		return nil
+	}
+
+	if s.qrState != qrConfirm {
+		return nil
+	}
+
+	if msg.String() == "enter" {
+		password := s.qrPassword.Value()
+		if password == "" {
+			return nil
+		}
+		s.qrState = qrVerifying
+		s.qrError = ""
+		s.qrPassword.Blur()
+		return func() tea.Msg { return ReauthenticateMsg{Password: password} }
+	}
+
+	var cmd tea.Cmd
+	s.qrPassword, cmd = s.qrPassword.Update(msg)
+	return cmd
+}
+
+// hideQR hides the QR code and discards any typed password
+func (s *EntryDetailScreen) hideQR() {
+	s.qrState = qrHidden
+	s.qrError = ""
+	s.qrPassword.Reset()
+	s.qrPassword.Blur()
+}
+
 // View renders the screen
 func (s *EntryDetailScreen) View() string {
+	if s.qrState != qrHidden {
+		return s.renderQR()
+	}
+
 	var b strings.Builder
 
 	// Title
@@ -156,8 +380,14 @@ func (s *EntryDetailScreen) View() string {
 		b.WriteString("\n\n")
 	}
 
+	// SSH key box
+	if s.entry.HasSSHKey() {
+		b.WriteString(s.renderSSHKey())
+		b.WriteString("\n\n")
+	}
+
 	// TOTP box (if available)
-	if s.entry.TOTPSecret != "" {
+	if s.entry.HasTOTP() {
 		totpBox := s.renderTOTP()
 		b.WriteString(totpBox)
 		b.WriteString("\n\n")
@@ -189,10 +419,32 @@ func (s *EntryDetailScreen) View() string {
 		b.WriteString("\n\n")
 	}
 
+	// Auto-type countdown or error
+	switch {
+	case s.autoTypeState == autoTypePending:
+		seconds := int(time.Until(s.autoTypeAt).Round(time.Second).Seconds())
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Warning).Render(fmt.Sprintf(
+			"%s Auto-type in %ds, switch to the target window  •  [Esc] Cancel", styles.IconClock, max(0, seconds))))
+		b.WriteString("\n\n")
+	case s.autoTypeState == autoTypeTyping:
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Warning).Render("Typing..."))
+		b.WriteString("\n\n")
+	case s.autoTypeError != "":
+		b.WriteString(styles.ErrorStyle.Render(styles.IconError + " " + s.autoTypeError))
+		b.WriteString("\n\n")
+	}
+
 	// Help text
-	helpText := "[Esc] Back  •  [Ctrl+U] Copy Username  •  [Ctrl+P] Copy Password"
-	if s.entry.TOTPSecret != "" {
+	helpText := "[Esc] Back  •  [Ctrl+U] Copy Username  •  [Ctrl+P] Copy Password  •  [Ctrl+A] Auto-Type"
+	if s.entry.HasTOTP() {
 		helpText += "  •  [Ctrl+T] Copy TOTP"
+		if s.entry.TOTP.IsHOTP() {
+			helpText += "  •  [Ctrl+N] Next Code"
+		}
+		helpText += "  •  [Ctrl+R] Show QR"
+	}
+	if s.entry.HasSSHKey() {
+		helpText += "  •  [Ctrl+K] Copy Public Key"
 	}
 	helpText += "  •  [Ctrl+H] Show/Hide  •  [Ctrl+E] Edit"
 	b.WriteString(styles.HelpStyle.Render(helpText))
@@ -237,14 +489,49 @@ func (s *EntryDetailScreen) renderCredentials() string {
 		Render(content.String())
 }
 
+// renderSSHKey renders the public half of an SSH key, the private key stays hidden
+func (s *EntryDetailScreen) renderSSHKey() string {
+	var content strings.Builder
+	key := s.entry.SSHKey
+
+	if key.Comment != "" {
+		content.WriteString(lipgloss.NewStyle().Bold(true).Render("Comment:"))
+		content.WriteString("\n")
+		content.WriteString(key.Comment)
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render("Fingerprint:"))
+	content.WriteString("\n")
+	content.WriteString(key.Fingerprint)
+	content.WriteString("\n\n")
+
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render("Public Key:"))
+	content.WriteString("\n")
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Info).Render(key.PublicKey))
+
+	if key.Passphrase != "" {
+		content.WriteString("\n\n")
+		content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render("Private key encrypted with a passphrase"))
+	}
+
+	return styles.BoxStyle.
+		Width(util.MinInt(60, s.width-4)).
+		Render(content.String())
+}
+
 // renderTOTP renders the TOTP code box
 func (s *EntryDetailScreen) renderTOTP() string {
 	var content strings.Builder
 
 	// Format code with space in middle (123 456)
-	formattedCode := s.totpCode
-	if len(s.totpCode) == 6 {
-		formattedCode = s.totpCode[:3] + " " + s.totpCode[3:]
+	formattedCode := formatTOTPCode(s.totpCode)
+	if s.totpError != "" {
+		formattedCode = s.totpError
+	}
+
+	if formattedCode == "" && s.entry.TOTP.IsHOTP() {
+		formattedCode = "Press Ctrl+N for the next code"
 	}
 
 	// TOTP code (large)
@@ -256,29 +543,78 @@ func (s *EntryDetailScreen) renderTOTP() string {
 	content.WriteString(codeStyle.Render(formattedCode))
 	content.WriteString("\n\n")
 
+	if s.entry.TOTP.IsHOTP() {
+		return s.renderOTPBox(s.entry.TOTP.Label()+" Code", content.String()+
+			lipgloss.NewStyle().Foreground(styles.Subtle).Render(fmt.Sprintf("Counter: %d", s.entry.TOTP.Counter)))
+	}
+
 	// Expiry info
 	expiryText := fmt.Sprintf("%s Expires in: %ds", styles.IconClock, int(s.totpExpiresIn.Seconds()))
 	content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render(expiryText))
 	content.WriteString("\n")
 
 	// Progress bar
-	percentage := float64(s.totpExpiresIn.Seconds()) / 30.0
+	percentage := s.totpExpiresIn.Seconds() / float64(s.totpPeriod())
 	progressBar := styles.RenderProgressBar(percentage, 40)
 	content.WriteString(progressBar)
 
-	title := lipgloss.NewStyle().
+	return s.renderOTPBox(s.entry.TOTP.Label()+" Code", content.String())
+}
+
+// renderQR renders the re-authentication prompt or the provisioning QR code
+func (s *EntryDetailScreen) renderQR() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(styles.IconLock + " " + s.entry.TOTP.Label() + " QR Code: " + s.entry.Name))
+	b.WriteString("\n\n")
+
+	if s.qrState != qrShown {
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Warning).Render(styles.IconWarning +
+			" The QR code contains the secret. Anyone who sees it can generate your codes."))
+		b.WriteString("\n\n")
+		b.WriteString("Enter your master password to continue:\n")
+		b.WriteString(s.qrPassword.View())
+		b.WriteString("\n\n")
+		if s.qrState == qrVerifying {
+			b.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render("Verifying..."))
+			b.WriteString("\n\n")
+		}
+		if s.qrError != "" {
+			b.WriteString(styles.ErrorStyle.Render(styles.IconError + " " + s.qrError))
+			b.WriteString("\n\n")
+		}
+		b.WriteString(styles.HelpStyle.Render("[Enter] Show QR code  •  [Esc] Cancel"))
+		return b.String()
+	}
+
+	uri := s.totp.ToGeneratorConfig(s.entry.TOTP).ToURI()
+	code, err := qrcode.Terminal(uri, s.width, s.height-6)
+	if err != nil {
+		b.WriteString(styles.ErrorStyle.Render(styles.IconError + " " + err.Error()))
+	} else {
+		b.WriteString(code)
+	}
+	b.WriteString("\n\n")
+	b.WriteString(styles.HelpStyle.Render("Scan with your authenticator app  •  [Esc] Hide"))
+
+	return b.String()
+}
+
+// renderOTPBox renders one-time password content in a titled box
+func (s *EntryDetailScreen) renderOTPBox(title, content string) string {
+	titleText := lipgloss.NewStyle().
 		Foreground(styles.Success).
 		Bold(true).
-		Render("TOTP Code")
+		Render(title)
 
 	boxContent := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(styles.Success).
 		Padding(1, 2).
 		Width(util.MinInt(50, s.width-4)).
-		Render(content.String())
+		Render(content)
 
-	return title + "\n" + boxContent
+	return titleText + "\n" + boxContent
 }
 
 // renderNotes renders the notes box
@@ -334,12 +670,41 @@ func (s *EntryDetailScreen) renderTimestamps() string {
 
 // updateTOTP updates the TOTP code
 func (s *EntryDetailScreen) updateTOTP() {
-	config := totp.DefaultConfig(s.entry.TOTPSecret)
-	code, expiresIn, err := config.GenerateCode()
-	if err == nil {
-		s.totpCode = code
-		s.totpExpiresIn = expiresIn
+	code, expiresIn, err := s.totp.GenerateCode(s.entry.TOTP)
+	if err != nil {
+		s.totpCode = ""
+		s.totpError = err.Error()
+		return
+	}
+	s.totpCode = code
+	s.totpExpiresIn = expiresIn
+	s.totpError = ""
+}
+
+// totpPeriod returns the TOTP period of the entry in seconds
+func (s *EntryDetailScreen) totpPeriod() int {
+	if s.entry.TOTP == nil || s.entry.TOTP.Period <= 0 {
+		return 30
 	}
+	return s.entry.TOTP.Period
+}
+
+// formatTOTPCode splits a code in two halves for readability (123 456)
+func formatTOTPCode(code string) string {
+	if len(code) < 6 || len(code)%2 != 0 {
+		return code
+	}
+	return code[:len(code)/2] + " " + code[len(code)/2:]
+}
+
+// ReauthenticateMsg asks the app to verify the master password before revealing a secret
+type ReauthenticateMsg struct {
+	Password string
+}
+
+// ReauthenticatedMsg reports whether the password of a ReauthenticateMsg was correct
+type ReauthenticatedMsg struct {
+	OK bool
 }
 
 // getEntryIcon returns the icon for the entry type
@@ -353,6 +718,8 @@ func (s *EntryDetailScreen) getEntryIcon() string {
 		return styles.IconCard
 	case entity.EntryTypeIdentity:
 		return styles.IconIdentity
+	case entity.EntryTypeSSHKey:
+		return styles.IconKey
 	default:
 		return styles.IconKey
 	}
@@ -391,3 +758,15 @@ type EditEntryMsg struct {
 
 // clearCopyMsgMsg signals to clear the copy message
 type clearCopyMsgMsg struct{}
+
+// autoTypeStartMsg ends the auto-type countdown with the given id
+type autoTypeStartMsg struct {
+	id int
+}
+
+// AutoTypeDoneMsg reports the end of auto-type
+// CounterAdvanced means an HOTP code was used up and the vault must be saved
+type AutoTypeDoneMsg struct {
+	Err             error
+	CounterAdvanced bool
+}
@@ -7,14 +7,18 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/internal/application/service"
 	"github.com/hambosto/passmanager/internal/domain/entity"
 	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+	"github.com/hambosto/passmanager/pkg/passwordrules"
+	"github.com/hambosto/passmanager/pkg/qrcode"
 )
 
 // EntryEditorScreen allows creating/editing entries
 type EntryEditorScreen struct {
 	entry  *entity.Entry
 	isNew  bool
+	totp   *service.TOTPService
 	width  int
 	height int
 
@@ -25,12 +29,19 @@ type EntryEditorScreen struct {
 	uriInput      textinput.Model
 	totpInput     textinput.Model
 	notesArea     textarea.Model
+	rulesInput    textinput.Model
+	autoTypeInput textinput.Model
+
+	// SSH key inputs, the passphrase uses passwordInput
+	commentInput textinput.Model
+	keyArea      textarea.Model
 
 	// State
 	focusIndex   int
 	showPassword bool
 	isFavorite   bool
 	entryType    entity.EntryType
+	errorMessage string
 }
 
 // NewEntryEditorScreen creates a new entry editor screen
@@ -56,7 +67,7 @@ func NewEntryEditorScreen(entry *entity.Entry, isNew bool) *EntryEditorScreen {
 	uriInput.Width = 40
 
 	totpInput := textinput.New()
-	totpInput.Placeholder = "otpauth://totp/... or secret"
+	totpInput.Placeholder = "otpauth://totp/..., secret or QR image path"
 	totpInput.Width = 40
 
 	notesArea := textarea.New()
@@ -64,14 +75,45 @@ func NewEntryEditorScreen(entry *entity.Entry, isNew bool) *EntryEditorScreen {
 	notesArea.SetWidth(60)
 	notesArea.SetHeight(4)
 
+	rulesInput := textinput.New()
+	rulesInput.Placeholder = "required: upper; maxlength: 20;"
+	rulesInput.Width = 60
+
+	autoTypeInput := textinput.New()
+	autoTypeInput.Placeholder = entity.DefaultAutoTypeSequence
+	autoTypeInput.Width = 60
+
+	commentInput := textinput.New()
+	commentInput.Placeholder = "user@host"
+	commentInput.Width = 40
+
+	keyArea := textarea.New()
+	keyArea.Placeholder = "Paste a private key, or leave empty to generate an Ed25519 key"
+	keyArea.SetWidth(60)
+	keyArea.SetHeight(6)
+
+	totpService := service.NewTOTPService()
+
 	// Populate if editing existing entry
 	if !isNew && entry != nil {
 		nameInput.SetValue(entry.Name)
 		usernameInput.SetValue(entry.Username)
 		passwordInput.SetValue(entry.Password)
 		uriInput.SetValue(entry.URI)
-		totpInput.SetValue(entry.TOTPSecret)
+		if entry.HasTOTP() {
+			totpInput.SetValue(totpService.FormatInput(entry.TOTP))
+		} else {
+			// Show an unmigrated legacy value so it can be fixed
+			totpInput.SetValue(entry.TOTPSecret)
+		}
 		notesArea.SetValue(entry.Notes)
+		rulesInput.SetValue(entry.PasswordRules)
+		autoTypeInput.SetValue(entry.AutoTypeSequence)
+		if entry.HasSSHKey() {
+			commentInput.SetValue(entry.SSHKey.Comment)
+			passwordInput.SetValue(entry.SSHKey.Passphrase)
+			keyArea.SetValue(entry.SSHKey.PrivateKey)
+		}
 	}
 
 	entryType := entity.EntryTypeLogin
@@ -80,16 +122,24 @@ func NewEntryEditorScreen(entry *entity.Entry, isNew bool) *EntryEditorScreen {
 		entryType = entry.Type
 		isFavorite = entry.IsFavorite
 	}
+	if entryType == entity.EntryTypeSSHKey {
+		passwordInput.Placeholder = "Passphrase"
+	}
 
 	return &EntryEditorScreen{
 		entry:         entry,
 		isNew:         isNew,
+		totp:          totpService,
 		nameInput:     nameInput,
 		usernameInput: usernameInput,
 		passwordInput: passwordInput,
 		uriInput:      uriInput,
 		totpInput:     totpInput,
 		notesArea:     notesArea,
+		rulesInput:    rulesInput,
+		autoTypeInput: autoTypeInput,
+		commentInput:  commentInput,
+		keyArea:       keyArea,
 		focusIndex:    0,
 		showPassword:  false,
 		isFavorite:    isFavorite,
@@ -126,9 +176,32 @@ func (s *EntryEditorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Save entry
 			return s, s.saveEntry()
 
+		case "ctrl+t":
+			// Switch a new entry between a login and an SSH key
+			if s.isNew {
+				if s.isSSHKey() {
+					s.entryType = entity.EntryTypeLogin
+					s.passwordInput.Placeholder = "Password"
+				} else {
+					s.entryType = entity.EntryTypeSSHKey
+					s.passwordInput.Placeholder = "Passphrase"
+				}
+				s.focusIndex = 0
+				s.errorMessage = ""
+				s.updateFocus()
+			}
+			return s, nil
+
 		case "ctrl+g":
-			// Open password generator
-			return s, func() tea.Msg { return OpenPasswordGeneratorMsg{} }
+			// Open the username generator from the username field
+			if s.focusIndex == 1 && !s.isSSHKey() {
+				site := service.SiteName(s.uriInput.Value())
+				return s, func() tea.Msg { return OpenUsernameGeneratorMsg{Site: site} }
+			}
+
+			// Open password generator with the site's rules
+			rules := s.passwordRules()
+			return s, func() tea.Msg { return OpenPasswordGeneratorMsg{Rules: rules} }
 
 		case "ctrl+h":
 			// Toggle password visibility
@@ -141,6 +214,11 @@ func (s *EntryEditorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, nil
 
 		case "tab", "shift+tab":
+			// Decode a QR screenshot when leaving the TOTP field
+			if s.focusIndex == 4 && !s.isSSHKey() && !s.importTOTPImage() {
+				return s, nil
+			}
+
 			// Navigate between inputs
 			if msg.String() == "tab" {
 				s.focusIndex++
@@ -148,10 +226,14 @@ func (s *EntryEditorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.focusIndex--
 			}
 
-			if s.focusIndex > 6 {
+			last := 7
+			if s.isSSHKey() {
+				last = 4
+			}
+			if s.focusIndex > last {
 				s.focusIndex = 0
 			} else if s.focusIndex < 0 {
-				s.focusIndex = 6
+				s.focusIndex = last
 			}
 
 			s.updateFocus()
@@ -165,6 +247,22 @@ func (s *EntryEditorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update the focused input
+	if s.isSSHKey() {
+		switch s.focusIndex {
+		case 0:
+			s.nameInput, cmd = s.nameInput.Update(msg)
+		case 1:
+			s.commentInput, cmd = s.commentInput.Update(msg)
+		case 2:
+			s.passwordInput, cmd = s.passwordInput.Update(msg)
+		case 3:
+			s.keyArea, cmd = s.keyArea.Update(msg)
+		case 4:
+			s.notesArea, cmd = s.notesArea.Update(msg)
+		}
+		return s, cmd
+	}
+
 	switch s.focusIndex {
 	case 0:
 		s.nameInput, cmd = s.nameInput.Update(msg)
@@ -184,6 +282,12 @@ func (s *EntryEditorScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case 5:
 		s.notesArea, cmd = s.notesArea.Update(msg)
 		cmds = append(cmds, cmd)
+	case 6:
+		s.rulesInput, cmd = s.rulesInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case 7:
+		s.autoTypeInput, cmd = s.autoTypeInput.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return s, tea.Batch(cmds...)
@@ -202,7 +306,7 @@ func (s *EntryEditorScreen) View() string {
 	b.WriteString("\n\n")
 
 	// Entry type
-	typeStr := lipgloss.NewStyle().Foreground(styles.Subtle).Render("Type: Login")
+	typeStr := lipgloss.NewStyle().Foreground(styles.Subtle).Render("Type: " + s.entryType.String())
 	b.WriteString(typeStr)
 	b.WriteString("  ")
 
@@ -217,13 +321,47 @@ func (s *EntryEditorScreen) View() string {
 
 	// Form
 	var formContent strings.Builder
+	if s.isSSHKey() {
+		s.renderSSHKeyForm(&formContent)
+	} else {
+		s.renderLoginForm(&formContent)
+	}
 
+	// Render form box
+	box := styles.BoxStyle.
+		Width(min(70, s.width-4)).
+		Render(formContent.String())
+	b.WriteString(box)
+	b.WriteString("\n\n")
+
+	// Validation error
+	if s.errorMessage != "" {
+		b.WriteString(styles.ErrorStyle.Render(styles.IconError + " " + s.errorMessage))
+		b.WriteString("\n")
+	}
+
+	// Help text
+	helpText := "[Ctrl+S] Save  •  [Esc] Cancel  •  [Tab] Next Field  •  [Ctrl+F] Toggle Favorite"
+	if s.isNew {
+		helpText += "  •  [Ctrl+T] Login/SSH Key"
+	}
+	b.WriteString(styles.HelpStyle.Render(helpText))
+
+	return b.String()
+}
+
+// renderLoginForm renders the fields of a login
+func (s *EntryEditorScreen) renderLoginForm(formContent *strings.Builder) {
 	// Name
 	formContent.WriteString(s.renderField("Name:", s.nameInput.View(), s.focusIndex == 0))
 	formContent.WriteString("\n\n")
 
 	// Username
-	formContent.WriteString(s.renderField("Username:", s.usernameInput.View(), s.focusIndex == 1))
+	usernameView := s.usernameInput.View()
+	if s.focusIndex == 1 {
+		usernameView += "\n" + styles.HelpStyle.Render("[Ctrl+G] Generate username or email alias")
+	}
+	formContent.WriteString(s.renderField("Username:", usernameView, s.focusIndex == 1))
 	formContent.WriteString("\n\n")
 
 	// Password
@@ -241,26 +379,54 @@ func (s *EntryEditorScreen) View() string {
 	// TOTP
 	totpView := s.totpInput.View()
 	if s.focusIndex == 4 {
-		totpView += "\n" + styles.HelpStyle.Render("Enter otpauth:// URI or Base32 secret")
+		totpView += "\n" + styles.HelpStyle.Render("Enter otpauth:// URI, Base32 secret or path to a QR code PNG/JPEG")
 	}
 	formContent.WriteString(s.renderField("TOTP:", totpView, s.focusIndex == 4))
 	formContent.WriteString("\n\n")
 
 	// Notes
 	formContent.WriteString(s.renderField("Notes:", s.notesArea.View(), s.focusIndex == 5))
+	formContent.WriteString("\n\n")
 
-	// Render form box
-	box := styles.BoxStyle.
-		Width(min(70, s.width-4)).
-		Render(formContent.String())
-	b.WriteString(box)
-	b.WriteString("\n\n")
+	// Password rules
+	rulesView := s.rulesInput.View()
+	if s.focusIndex == 6 {
+		hint := "Site password requirements in passwordrules syntax, used by the generator"
+		if site := s.siteRules(); site != "" && strings.TrimSpace(s.rulesInput.Value()) == "" {
+			hint = "Known rules for this site: " + site
+		}
+		rulesView += "\n" + styles.HelpStyle.Render(hint)
+	}
+	formContent.WriteString(s.renderField("Password Rules:", rulesView, s.focusIndex == 6))
+	formContent.WriteString("\n\n")
 
-	// Help text
-	helpText := "[Ctrl+S] Save  •  [Esc] Cancel  •  [Tab] Next Field  •  [Ctrl+F] Toggle Favorite"
-	b.WriteString(styles.HelpStyle.Render(helpText))
+	// Auto-type sequence
+	autoTypeView := s.autoTypeInput.View()
+	if s.focusIndex == 7 {
+		autoTypeView += "\n" + styles.HelpStyle.Render("Keystrokes for Ctrl+A, empty for the default: {USERNAME} {PASSWORD} {TOTP} {S:Field} {TAB} {ENTER} {DELAY 500}")
+	}
+	formContent.WriteString(s.renderField("Auto-Type Sequence:", autoTypeView, s.focusIndex == 7))
+}
 
-	return b.String()
+// renderSSHKeyForm renders the fields of an SSH key
+func (s *EntryEditorScreen) renderSSHKeyForm(formContent *strings.Builder) {
+	formContent.WriteString(s.renderField("Name:", s.nameInput.View(), s.focusIndex == 0))
+	formContent.WriteString("\n\n")
+
+	formContent.WriteString(s.renderField("Comment:", s.commentInput.View(), s.focusIndex == 1))
+	formContent.WriteString("\n\n")
+
+	passView := s.passwordInput.View()
+	if s.focusIndex == 2 {
+		passView += "\n" + styles.HelpStyle.Render("Encrypts the private key, empty for none  [Ctrl+G] Generate  [Ctrl+H] Show/Hide")
+	}
+	formContent.WriteString(s.renderField("Passphrase:", passView, s.focusIndex == 2))
+	formContent.WriteString("\n\n")
+
+	formContent.WriteString(s.renderField("Private Key:", s.keyArea.View(), s.focusIndex == 3))
+	formContent.WriteString("\n\n")
+
+	formContent.WriteString(s.renderField("Notes:", s.notesArea.View(), s.focusIndex == 4))
 }
 
 // renderField renders a form field
@@ -281,6 +447,26 @@ func (s *EntryEditorScreen) updateFocus() {
 	s.uriInput.Blur()
 	s.totpInput.Blur()
 	s.notesArea.Blur()
+	s.rulesInput.Blur()
+	s.autoTypeInput.Blur()
+	s.commentInput.Blur()
+	s.keyArea.Blur()
+
+	if s.isSSHKey() {
+		switch s.focusIndex {
+		case 0:
+			s.nameInput.Focus()
+		case 1:
+			s.commentInput.Focus()
+		case 2:
+			s.passwordInput.Focus()
+		case 3:
+			s.keyArea.Focus()
+		case 4:
+			s.notesArea.Focus()
+		}
+		return
+	}
 
 	switch s.focusIndex {
 	case 0:
@@ -295,27 +481,118 @@ func (s *EntryEditorScreen) updateFocus() {
 		s.totpInput.Focus()
 	case 5:
 		s.notesArea.Focus()
+	case 6:
+		s.rulesInput.Focus()
+	case 7:
+		s.autoTypeInput.Focus()
+	}
+}
+
+// isSSHKey reports whether the editor shows the fields of an SSH key
+func (s *EntryEditorScreen) isSSHKey() bool {
+	return s.entryType == entity.EntryTypeSSHKey
+}
+
+// saveSSHKey creates a command to save an SSH key entry, generating an
+// Ed25519 key when no private key was pasted
+func (s *EntryEditorScreen) saveSSHKey() tea.Cmd {
+	if s.nameInput.Value() == "" {
+		s.errorMessage = "Name is required"
+		return nil
+	}
+
+	var key *entity.SSHKey
+	var err error
+	if strings.TrimSpace(s.keyArea.Value()) == "" {
+		key, err = service.GenerateSSHKey(s.commentInput.Value(), s.passwordInput.Value())
+	} else {
+		key, err = service.ParseSSHKey(s.keyArea.Value(), s.passwordInput.Value(), s.commentInput.Value())
+	}
+	if err != nil {
+		s.errorMessage = "Invalid SSH key: " + err.Error()
+		s.focusIndex = 3
+		s.updateFocus()
+		return nil
+	}
+	s.errorMessage = ""
+	s.keyArea.SetValue(key.PrivateKey)
+
+	entry := s.entry
+	if s.isNew {
+		entry = entity.NewEntry(entity.EntryTypeSSHKey, s.nameInput.Value())
+	}
+	entry.Name = s.nameInput.Value()
+	entry.SSHKey = key
+	entry.Notes = s.notesArea.Value()
+	entry.IsFavorite = s.isFavorite
+	if !s.isNew {
+		entry.Update()
+	}
+
+	isNew := s.isNew
+	return func() tea.Msg {
+		return SaveEntryMsg{Entry: entry, IsNew: isNew}
 	}
 }
 
 // saveEntry creates a command to save the entry
 func (s *EntryEditorScreen) saveEntry() tea.Cmd {
+	if s.isSSHKey() {
+		return s.saveSSHKey()
+	}
+	if !s.importTOTPImage() {
+		return nil
+	}
+
 	// Validate
 	if s.nameInput.Value() == "" {
-		// Show error - name is required
-		return func() tea.Msg {
-			return ShowErrorMsg{Error: "Name is required"}
+		s.errorMessage = "Name is required"
+		return nil
+	}
+
+	var totpConfig *entity.TOTPConfig
+	if strings.TrimSpace(s.totpInput.Value()) != "" {
+		cfg, err := s.totp.ParseInput(s.totpInput.Value())
+		if err != nil {
+			s.errorMessage = "Invalid TOTP: " + err.Error()
+			s.focusIndex = 4
+			s.updateFocus()
+			return nil
 		}
+		totpConfig = cfg
 	}
 
+	rules := strings.TrimSpace(s.rulesInput.Value())
+	if rules != "" {
+		if _, err := passwordrules.Parse(rules); err != nil {
+			s.errorMessage = "Invalid password rules: " + err.Error()
+			s.focusIndex = 6
+			s.updateFocus()
+			return nil
+		}
+	}
+
+	sequence := strings.TrimSpace(s.autoTypeInput.Value())
+	if sequence != "" {
+		if _, err := service.ParseAutoTypeSequence(sequence); err != nil {
+			s.errorMessage = "Invalid auto-type sequence: " + err.Error()
+			s.focusIndex = 7
+			s.updateFocus()
+			return nil
+		}
+	}
+	s.errorMessage = ""
+
 	// Create or update entry
 	if s.isNew {
 		entry := entity.NewEntry(s.entryType, s.nameInput.Value())
 		entry.Username = s.usernameInput.Value()
-		entry.Password = s.passwordInput.Value()
+		entry.SetPassword(s.passwordInput.Value())
 		entry.URI = s.uriInput.Value()
-		entry.TOTPSecret = s.totpInput.Value()
+		entry.TOTP = totpConfig
 		entry.Notes = s.notesArea.Value()
+		entry.PasswordRules = rules
+		entry.AutoTypeSequence = sequence
 		entry.IsFavorite = s.isFavorite
 
 		return func() tea.Msg {
@@ -325,10 +602,13 @@ func (s *EntryEditorScreen) saveEntry() tea.Cmd {
 		// Update existing entry
 		s.entry.Name = s.nameInput.Value()
 		s.entry.Username = s.usernameInput.Value()
-		s.entry.Password = s.passwordInput.Value()
+		s.entry.SetPassword(s.passwordInput.Value())
 		s.entry.URI = s.uriInput.Value()
-		s.entry.TOTPSecret = s.totpInput.Value()
+		s.entry.TOTP = totpConfig
+		s.entry.TOTPSecret = ""
 		s.entry.Notes = s.notesArea.Value()
+		s.entry.PasswordRules = rules
+		s.entry.AutoTypeSequence = sequence
 		s.entry.IsFavorite = s.isFavorite
 		s.entry.Update()
 
@@ -338,6 +618,50 @@ func (s *EntryEditorScreen) saveEntry() tea.Cmd {
 	}
 }
 
+// importTOTPImage replaces a QR image path in the TOTP field with the decoded
+// configuration and fills empty name and username fields from its label
+// Returns false if the image could not be imported
+func (s *EntryEditorScreen) importTOTPImage() bool {
+	path := strings.TrimSpace(s.totpInput.Value())
+	if !qrcode.IsImagePath(path) {
+		return true
+	}
+
+	cfg, err := s.totp.ParseImage(path)
+	if err != nil {
+		s.errorMessage = "QR import failed: " + err.Error()
+		s.focusIndex = 4
+		s.updateFocus()
+		return false
+	}
+
+	entry := &entity.Entry{Name: s.nameInput.Value(), Username: s.usernameInput.Value()}
+	s.totp.ApplyLabel(entry, cfg)
+	s.nameInput.SetValue(entry.Name)
+	s.usernameInput.SetValue(entry.Username)
+	s.totpInput.SetValue(s.totp.FormatInput(cfg))
+	s.errorMessage = ""
+	return true
+}
+
+// passwordRules returns the rules typed into the editor, or the known rules of its website
+func (s *EntryEditorScreen) passwordRules() string {
+	if rules := strings.TrimSpace(s.rulesInput.Value()); rules != "" {
+		return rules
+	}
+	return s.siteRules()
+}
+
+// siteRules returns the bundled rules for the website being edited
+func (s *EntryEditorScreen) siteRules() string {
+	return service.PasswordRulesForEntry(&entity.Entry{URI: s.uriInput.Value()})
+}
+
+// SetUsername sets the username field value
+func (s *EntryEditorScreen) SetUsername(username string) {
+	s.usernameInput.SetValue(username)
+}
+
 // SetPassword sets the password field value
 func (s *EntryEditorScreen) SetPassword(password string) {
 	s.passwordInput.SetValue(password)
@@ -352,10 +676,14 @@ type SaveEntryMsg struct {
 	IsNew bool
 }
 
-// OpenPasswordGeneratorMsg signals to open the password generator
-type OpenPasswordGeneratorMsg struct{}
+// OpenUsernameGeneratorMsg signals to open the username generator
+// Site is the site name used in alias templates
+type OpenUsernameGeneratorMsg struct {
+	Site string
+}
 
-// ShowErrorMsg signals an error to display
-type ShowErrorMsg struct {
-	Error string
+// OpenPasswordGeneratorMsg signals to open the password generator
+// Rules holds the site's password rules the generator should default to
+type OpenPasswordGeneratorMsg struct {
+	Rules string
 }
@@ -0,0 +1,196 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/domain/entity"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+	"github.com/hambosto/passmanager/internal/presentation/tui/util"
+)
+
+// HealthScreen shows the security audit of the vault
+type HealthScreen struct {
+	vault    *entity.Vault
+	security *service.SecurityService
+	width    int
+	height   int
+
+	// Audit results
+	score    float64
+	findings []service.Finding
+
+	// UI state
+	cursor        int
+	showDismissed bool
+}
+
+// NewHealthScreen creates a new vault health screen
+func NewHealthScreen(vault *entity.Vault, security *service.SecurityService) *HealthScreen {
+	s := &HealthScreen{
+		vault:    vault,
+		security: security,
+	}
+	s.refresh()
+	return s
+}
+
+// Init initializes the screen
+func (s *HealthScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (s *HealthScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		return s, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return s, func() tea.Msg { return BackMsg{} }
+
+		case "ctrl+c", "ctrl+q":
+			return s, tea.Quit
+
+		case "up", "k":
+			if s.cursor > 0 {
+				s.cursor--
+			}
+
+		case "down", "j":
+			if s.cursor < len(s.findings)-1 {
+				s.cursor++
+			}
+
+		case "ctrl+r":
+			// Re-run the audit
+			s.refresh()
+
+		case "ctrl+a":
+			// Toggle showing dismissed findings
+			s.showDismissed = !s.showDismissed
+			s.refresh()
+
+		case "ctrl+d":
+			// Dismiss or restore the selected finding
+			if s.cursor < len(s.findings) {
+				finding := s.findings[s.cursor]
+				if finding.Dismissed {
+					s.vault.RestoreFinding(finding.ID())
+				} else {
+					s.vault.DismissFinding(finding.ID())
+				}
+				s.refresh()
+				return s, func() tea.Msg { return VaultChangedMsg{} }
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// View renders the screen
+func (s *HealthScreen) View() string {
+	var b strings.Builder
+
+	// Title
+	b.WriteString(styles.TitleStyle.Render(styles.IconLock + " Vault Health"))
+	b.WriteString("\n\n")
+
+	// Score
+	b.WriteString(s.renderScore())
+	b.WriteString("\n\n")
+
+	// Findings
+	if len(s.findings) == 0 {
+		b.WriteString(styles.SuccessStyle.Render(styles.IconSuccess + " No issues found"))
+	} else {
+		b.WriteString(s.renderFindings())
+	}
+	b.WriteString("\n\n")
+
+	// Help text
+	helpText := "[Esc] Back  •  [↑↓] Navigate  •  [Ctrl+D] Dismiss/Restore  •  [Ctrl+A] Show dismissed  •  [Ctrl+R] Re-run audit"
+	b.WriteString(styles.HelpStyle.Render(helpText))
+
+	return b.String()
+}
+
+// renderScore renders the overall security score
+func (s *HealthScreen) renderScore() string {
+	var color lipgloss.Color
+	switch {
+	case s.score >= 80:
+		color = styles.Success
+	case s.score >= 50:
+		color = styles.Warning
+	default:
+		color = styles.Danger
+	}
+
+	scoreText := lipgloss.NewStyle().Foreground(color).Bold(true).Render(
+		fmt.Sprintf("Security score: %.0f/100", s.score))
+
+	return scoreText + "\n" + styles.RenderProgressBar(s.score/100.0, 40)
+}
+
+// renderFindings renders the list of findings grouped by kind
+func (s *HealthScreen) renderFindings() string {
+	var content strings.Builder
+
+	for i, finding := range s.findings {
+		if i == 0 || s.findings[i-1].Kind != finding.Kind {
+			if i > 0 {
+				content.WriteString("\n")
+			}
+			content.WriteString(lipgloss.NewStyle().Bold(true).Foreground(styles.Warning).Render(
+				styles.IconWarning + " " + finding.Kind.String()))
+			content.WriteString("\n")
+		}
+
+		line := finding.Entry.Name
+		if finding.Dismissed {
+			line += " (dismissed)"
+		}
+		detail := lipgloss.NewStyle().Foreground(styles.Subtle).Render("  " + finding.Detail)
+		if i == s.cursor {
+			content.WriteString(styles.SelectedItemStyle.Render("> " + line))
+		} else {
+			content.WriteString(styles.ItemStyle.Render(line))
+		}
+		content.WriteString("\n")
+		content.WriteString(detail)
+		content.WriteString("\n")
+	}
+
+	return styles.BoxStyle.
+		Width(util.MinInt(80, s.width-4)).
+		Render(strings.TrimRight(content.String(), "\n"))
+}
+
+// refresh re-runs the security audit
+func (s *HealthScreen) refresh() {
+	s.score = s.security.CalculateSecurityScore(s.vault)
+
+	s.findings = s.findings[:0]
+	for _, finding := range s.security.Audit(s.vault) {
+		if finding.Dismissed && !s.showDismissed {
+			continue
+		}
+		s.findings = append(s.findings, finding)
+	}
+
+	if s.cursor >= len(s.findings) {
+		s.cursor = util.MaxInt(0, len(s.findings)-1)
+	}
+}
+
+// VaultChangedMsg signals that the vault was modified in place and should be saved
+type VaultChangedMsg struct{}
@@ -62,6 +62,7 @@ func (s *HelpScreen) View() string {
 				{"Esc", "Go back / Cancel"},
 				{"?", "Show this help"},
 				{"Ctrl+,", "Open settings"},
+				{"Ctrl+W", "Vault health report"},
 			},
 		},
 		{
@@ -91,15 +92,23 @@ func (s *HelpScreen) View() string {
 				{"Ctrl+U", "Copy username"},
 				{"Ctrl+P", "Copy password"},
 				{"Ctrl+T", "Copy TOTP code"},
+				{"Ctrl+N", "Next HOTP code (in entry detail)"},
+				{"Ctrl+T", "Authenticator overview (in vault list)"},
+				{"Ctrl+R", "Show TOTP QR code (in entry detail)"},
 				{"Ctrl+C", "Copy (in password generator)"},
+				{"Ctrl+A", "Auto-type into another window (in entry detail)"},
+				{"Ctrl+K", "Copy SSH public key (in entry detail)"},
 			},
 		},
 		{
 			title: "Password Generator",
 			items: [][2]string{
 				{"Ctrl+G", "Generate password (in editor)"},
+				{"Ctrl+G", "Generate username (in username field)"},
+				{"Ctrl+G", "Password generator (in vault list)"},
+				{"Ctrl+Y", "Generator history (in password generator)"},
 				{"Ctrl+R", "Regenerate"},
-				{"Tab", "Switch mode (password/passphrase)"},
+				{"Tab", "Switch mode (random/pattern/pronounceable/passphrase)"},
 				{"↑↓", "Navigate options"},
 				{"←→", "Adjust values"},
 			},
@@ -110,6 +119,7 @@ func (s *HelpScreen) View() string {
 				{"Ctrl+H", "Show/Hide password"},
 				{"Ctrl+O", "Open URL in browser"},
 				{"Ctrl+F", "Toggle favorite (in editor)"},
+				{"Ctrl+T", "Switch between login and SSH key (in new entry)"},
 			},
 		},
 	}
@@ -22,6 +22,13 @@ type SettingsScreen struct {
 	autoLockInput    textinput.Model
 	clipboardInput   textinput.Model
 	passwordLenInput textinput.Model
+	minUpperInput    textinput.Model
+	minLowerInput    textinput.Model
+	minNumbersInput  textinput.Model
+	minSymbolsInput  textinput.Model
+	symbolsInput     textinput.Model
+	allowedInput     textinput.Model
+	excludedInput    textinput.Model
 
 	// State
 	focusIndex int
@@ -30,6 +37,7 @@ type SettingsScreen struct {
 	// Checkboxes
 	clearOnLock      bool
 	clearOnExit      bool
+	restoreClipboard bool
 	includeUpper     bool
 	includeLower     bool
 	includeNumbers   bool
@@ -55,17 +63,26 @@ func NewSettingsScreen(cfg *config.Config) *SettingsScreen {
 	passwordLenInput.Width = 10
 	passwordLenInput.SetValue(fmt.Sprintf("%d", cfg.PasswordGenerator.Length))
 
+	generator := cfg.PasswordGenerator
 	return &SettingsScreen{
 		config:           cfg,
 		autoLockInput:    autoLockInput,
 		clipboardInput:   clipboardInput,
 		passwordLenInput: passwordLenInput,
+		minUpperInput:    newCountInput(generator.MinUppercase),
+		minLowerInput:    newCountInput(generator.MinLowercase),
+		minNumbersInput:  newCountInput(generator.MinNumbers),
+		minSymbolsInput:  newCountInput(generator.MinSymbols),
+		symbolsInput:     newCharsInput("default", generator.Symbols),
+		allowedInput:     newCharsInput("none", generator.AllowedChars),
+		excludedInput:    newCharsInput("none", generator.ExcludedChars),
 		focusIndex:       0,
 		modified:         false,
 
 		// Initialize checkboxes from config
 		clearOnLock:      cfg.Security.ClearClipboardOnLock,
 		clearOnExit:      cfg.Security.ClearClipboardOnExit,
+		restoreClipboard: cfg.Security.RestoreClipboard,
 		includeUpper:     cfg.PasswordGenerator.IncludeUppercase,
 		includeLower:     cfg.PasswordGenerator.IncludeLowercase,
 		includeNumbers:   cfg.PasswordGenerator.IncludeNumbers,
@@ -74,6 +91,41 @@ func NewSettingsScreen(cfg *config.Config) *SettingsScreen {
 	}
 }
 
+// newCountInput creates a small input for a character count
+func newCountInput(value int) textinput.Model {
+	input := textinput.New()
+	input.Width = 3
+	input.CharLimit = 3
+	input.SetValue(fmt.Sprintf("%d", value))
+	return input
+}
+
+// newCharsInput creates an input for a set of characters
+func newCharsInput(placeholder, value string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.Width = 30
+	input.CharLimit = 95
+	input.SetValue(value)
+	return input
+}
+
+// inputs returns the text inputs by focus index
+func (s *SettingsScreen) inputs() map[int]*textinput.Model {
+	return map[int]*textinput.Model{
+		0:  &s.autoLockInput,
+		1:  &s.clipboardInput,
+		5:  &s.passwordLenInput,
+		11: &s.minUpperInput,
+		12: &s.minLowerInput,
+		13: &s.minNumbersInput,
+		14: &s.minSymbolsInput,
+		15: &s.symbolsInput,
+		16: &s.allowedInput,
+		17: &s.excludedInput,
+	}
+}
+
 // Init initializes the screen
 func (s *SettingsScreen) Init() tea.Cmd {
 	return textinput.Blink
@@ -111,7 +163,7 @@ func (s *SettingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.focusIndex--
 			}
 
-			maxIndex := 9 // Total number of focusable items
+			maxIndex := 17 // Total number of focusable items
 			if s.focusIndex > maxIndex {
 				s.focusIndex = 0
 			} else if s.focusIndex < 0 {
@@ -122,9 +174,16 @@ func (s *SettingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, textinput.Blink
 
 		case " ", "enter":
+			// Character sets and numbers never contain spaces
+			if _, ok := s.inputs()[s.focusIndex]; ok && msg.String() == " " {
+				return s, nil
+			}
+
 			// Toggle checkboxes
 			s.modified = true
 			switch s.focusIndex {
+			case 2:
+				s.restoreClipboard = !s.restoreClipboard
 			case 3:
 				s.clearOnLock = !s.clearOnLock
 			case 4:
@@ -144,28 +203,17 @@ func (s *SettingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Mark as modified when inputs change
-	oldAutoLock := s.autoLockInput.Value()
-	oldClipboard := s.clipboardInput.Value()
-	oldPasswordLen := s.passwordLenInput.Value()
-
-	// Update the focused input
-	switch s.focusIndex {
-	case 0:
-		s.autoLockInput, cmd = s.autoLockInput.Update(msg)
-		cmds = append(cmds, cmd)
-	case 1:
-		s.clipboardInput, cmd = s.clipboardInput.Update(msg)
-		cmds = append(cmds, cmd)
-	case 5:
-		s.passwordLenInput, cmd = s.passwordLenInput.Update(msg)
+	// Update the focused input, marking the settings modified when it changes
+	if input, ok := s.inputs()[s.focusIndex]; ok {
+		if key, isKey := msg.(tea.KeyMsg); isKey && key.Type == tea.KeyRunes {
+			msg = printableKeys(key)
+		}
+		old := input.Value()
+		*input, cmd = input.Update(msg)
 		cmds = append(cmds, cmd)
-	}
-
-	if oldAutoLock != s.autoLockInput.Value() ||
-		oldClipboard != s.clipboardInput.Value() ||
-		oldPasswordLen != s.passwordLenInput.Value() {
-		s.modified = true
+		if old != input.Value() {
+			s.modified = true
+		}
 	}
 
 	return s, tea.Batch(cmds...)
@@ -213,6 +261,8 @@ func (s *SettingsScreen) renderSecuritySection() string {
 
 	// Clipboard timeout
 	content.WriteString(s.renderField(1, "Clipboard timeout:", s.clipboardInput.View()+" seconds"))
+	content.WriteString("\n")
+	content.WriteString(s.renderCheckbox(2, "Restore previous contents after clearing", s.restoreClipboard))
 	content.WriteString("\n\n")
 
 	// Clear clipboard options
@@ -249,6 +299,26 @@ func (s *SettingsScreen) renderPasswordGeneratorSection() string {
 	content.WriteString("\n\n")
 
 	content.WriteString(s.renderCheckbox(10, "Exclude ambiguous (0,O,l,1,I)", s.excludeAmbiguous))
+	content.WriteString("\n\n")
+
+	// Minimum counts
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render("At least:"))
+	content.WriteString("\n")
+	content.WriteString(s.renderField(11, "Upper", s.minUpperInput.View()))
+	content.WriteString("  ")
+	content.WriteString(s.renderField(12, "Lower", s.minLowerInput.View()))
+	content.WriteString("  ")
+	content.WriteString(s.renderField(13, "Numbers", s.minNumbersInput.View()))
+	content.WriteString("  ")
+	content.WriteString(s.renderField(14, "Symbols", s.minSymbolsInput.View()))
+	content.WriteString("\n\n")
+
+	// Character sets
+	content.WriteString(s.renderField(15, "Symbol set:", s.symbolsInput.View()))
+	content.WriteString("\n")
+	content.WriteString(s.renderField(16, "Also allow:", s.allowedInput.View()))
+	content.WriteString("\n")
+	content.WriteString(s.renderField(17, "Never use: ", s.excludedInput.View()))
 
 	return styles.BoxStyle.Width(util.MinInt(70, s.width-4)).Render(content.String())
 }
@@ -280,20 +350,28 @@ func (s *SettingsScreen) renderCheckbox(index int, label string, checked bool) s
 
 // updateFocus updates which input is focused
 func (s *SettingsScreen) updateFocus() {
-	s.autoLockInput.Blur()
-	s.clipboardInput.Blur()
-	s.passwordLenInput.Blur()
-
-	switch s.focusIndex {
-	case 0:
-		s.autoLockInput.Focus()
-	case 1:
-		s.clipboardInput.Focus()
-	case 5:
-		s.passwordLenInput.Focus()
+	for index, input := range s.inputs() {
+		if index == s.focusIndex {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
 	}
 }
 
+// printableKeys drops runes that are not printable ASCII, such as spaces in pasted text,
+// since the generator rejects them in character sets
+func printableKeys(msg tea.KeyMsg) tea.KeyMsg {
+	runes := make([]rune, 0, len(msg.Runes))
+	for _, r := range msg.Runes {
+		if r > ' ' && r <= '~' {
+			runes = append(runes, r)
+		}
+	}
+	msg.Runes = runes
+	return msg
+}
+
 // saveSettings creates a command to save the settings
 func (s *SettingsScreen) saveSettings() tea.Cmd {
 	// Update config from inputs
@@ -307,8 +385,26 @@ func (s *SettingsScreen) saveSettings() tea.Cmd {
 		s.config.PasswordGenerator.Length = val
 	}
 
+	for _, field := range []struct {
+		input *textinput.Model
+		value *int
+	}{
+		{&s.minUpperInput, &s.config.PasswordGenerator.MinUppercase},
+		{&s.minLowerInput, &s.config.PasswordGenerator.MinLowercase},
+		{&s.minNumbersInput, &s.config.PasswordGenerator.MinNumbers},
+		{&s.minSymbolsInput, &s.config.PasswordGenerator.MinSymbols},
+	} {
+		if val, err := parseInt(field.input.Value()); err == nil && val >= 0 {
+			*field.value = val
+		}
+	}
+	s.config.PasswordGenerator.Symbols = s.symbolsInput.Value()
+	s.config.PasswordGenerator.AllowedChars = s.allowedInput.Value()
+	s.config.PasswordGenerator.ExcludedChars = s.excludedInput.Value()
+
 	s.config.Security.ClearClipboardOnLock = s.clearOnLock
 	s.config.Security.ClearClipboardOnExit = s.clearOnExit
+	s.config.Security.RestoreClipboard = s.restoreClipboard
 	s.config.PasswordGenerator.IncludeUppercase = s.includeUpper
 	s.config.PasswordGenerator.IncludeLowercase = s.includeLower
 	s.config.PasswordGenerator.IncludeNumbers = s.includeNumbers
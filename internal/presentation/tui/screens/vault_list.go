@@ -21,7 +21,7 @@ type VaultListScreen struct {
 	width     int
 	height    int
 
-	// TOTP ticker for real-time updates
+	// Ticker refreshing the relative "last used" times
 	ticker *time.Ticker
 }
 
@@ -63,6 +63,8 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		icon = styles.IconCard
 	case entity.EntryTypeIdentity:
 		icon = styles.IconIdentity
+	case entity.EntryTypeSSHKey:
+		icon = styles.IconKey
 	}
 
 	// Title line
@@ -104,8 +106,8 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 
 	// Meta line (TOTP / Last Used)
 	var metaBuilder strings.Builder
-	if i.entry.TOTPSecret != "" {
-		metaBuilder.WriteString("TOTP")
+	if i.entry.HasTOTP() {
+		metaBuilder.WriteString(i.entry.TOTP.Label())
 	}
 	if !i.entry.AccessedAt.IsZero() {
 		if metaBuilder.Len() > 0 {
@@ -173,10 +175,16 @@ func (s *VaultListScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, func() tea.Msg {
 				return NewEntryMsg{}
 			}
+
+		case "ctrl+g":
+			// Password generator and its history
+			return s, func() tea.Msg {
+				return OpenPasswordGeneratorMsg{}
+			}
 		}
 
 	case tickMsg:
-		// Update TOTP codes
+		// The list re-renders on every message, which refreshes access times
 		return s, s.tickCmd()
 	}
 
@@ -212,7 +220,7 @@ func (s *VaultListScreen) tickCmd() tea.Cmd {
 	}
 }
 
-// tickMsg is sent when it's time to update TOTP codes
+// tickMsg is sent every second to refresh time-dependent views such as TOTP codes
 type tickMsg struct{}
 
 // formatDuration formats a duration in a human-readable way
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hambosto/passmanager/internal/application/service"
+	"github.com/hambosto/passmanager/internal/infrastructure/agent"
+	"github.com/hambosto/passmanager/internal/presentation/tui/styles"
+)
+
+// sshConfirmMsg asks the user to allow a signature with an SSH key
+type sshConfirmMsg struct {
+	request  service.SSHSignRequest
+	deadline time.Time
+	reply    chan bool
+}
+
+// startSSHAgent serves the SSH keys of the vault while the interface runs
+// A failure is shown in the status bar and leaves the vault usable
+func (a *App) startSSHAgent() tea.Cmd {
+	if a.config == nil || !a.config.SSHAgent.Enabled || a.sshServer != nil {
+		return nil
+	}
+
+	path := a.config.SSHAgent.SocketPath
+	if path == "" {
+		path = agent.SSHSocketPath()
+	}
+	timeout := time.Duration(a.config.SSHAgent.ConfirmTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	requests := make(chan sshConfirmMsg)
+	sshAgent := service.NewSSHAgentService(confirmSSHSignature(requests, timeout))
+	server, err := agent.ListenSSH(path, sshAgent.ForPeer)
+	if err != nil {
+		a.sshStatus = "SSH agent not started: " + err.Error()
+		return nil
+	}
+	go func() { _ = server.Serve() }()
+
+	a.sshAgent = sshAgent
+	a.sshServer = server
+	a.sshRequests = requests
+	a.sshStatus = ""
+	a.refreshSSHKeys()
+	return waitForSSHConfirm(requests)
+}
+
+// stopSSHAgent stops serving SSH keys
+func (a *App) stopSSHAgent() {
+	if a.sshServer == nil {
+		return
+	}
+	a.sshAgent.Clear()
+	_ = a.sshServer.Close()
+	a.sshServer = nil
+	a.sshAgent = nil
+	if a.sshConfirm != nil {
+		a.sshConfirm.reply <- false
+		a.sshConfirm = nil
+	}
+}
+
+// refreshSSHKeys hands the current SSH key entries to the agent
+func (a *App) refreshSSHKeys() {
+	if a.sshAgent != nil && a.vault != nil {
+		a.sshAgent.SetKeys(a.vault.Entries)
+	}
+}
+
+// confirmSSHSignature returns the agent's confirmation callback, which asks the
+// interface and refuses when nobody answers within timeout
+func confirmSSHSignature(requests chan<- sshConfirmMsg, timeout time.Duration) func(service.SSHSignRequest) bool {
+	return func(request service.SSHSignRequest) bool {
+		msg := sshConfirmMsg{
+			request:  request,
+			deadline: time.Now().Add(timeout),
+			reply:    make(chan bool, 1),
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case requests <- msg:
+		case <-timer.C:
+			return false
+		}
+		select {
+		case ok := <-msg.reply:
+			return ok
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// waitForSSHConfirm delivers the next signature to confirm
+func waitForSSHConfirm(requests <-chan sshConfirmMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-requests
+	}
+}
+
+// answerSSHConfirm replies to the pending confirmation and waits for the next one
+func (a *App) answerSSHConfirm(allow bool) tea.Cmd {
+	a.sshConfirm.reply <- allow
+	a.sshConfirm = nil
+	return waitForSSHConfirm(a.sshRequests)
+}
+
+// handleSSHConfirmKey answers the pending confirmation from a key press
+func (a *App) handleSSHConfirmKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "Y":
+		return a.answerSSHConfirm(true)
+	case "n", "N", "esc":
+		return a.answerSSHConfirm(false)
+	case "ctrl+c", "ctrl+q":
+		a.answerSSHConfirm(false)
+		a.clipboard.Clear()
+		return tea.Quit
+	}
+	return nil
+}
+
+// renderSSHConfirm renders the confirmation prompt of a signature
+func (a *App) renderSSHConfirm() string {
+	request := a.sshConfirm.request
+	var content strings.Builder
+	content.WriteString(styles.TitleStyle.Render(styles.IconKey + " SSH Key Use"))
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render(request.Peer.String()))
+	content.WriteString(" wants to sign with\n\n")
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render(request.EntryName))
+	if request.Comment != "" && request.Comment != request.EntryName {
+		content.WriteString(" (" + request.Comment + ")")
+	}
+	content.WriteString("\n")
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Subtle).Render(request.Fingerprint))
+	content.WriteString("\n\n")
+
+	seconds := max(0, int(time.Until(a.sshConfirm.deadline).Round(time.Second).Seconds()))
+	content.WriteString(lipgloss.NewStyle().Foreground(styles.Warning).Render(
+		fmt.Sprintf("%s Refused in %ds", styles.IconClock, seconds)))
+	content.WriteString("\n\n")
+	content.WriteString(styles.HelpStyle.Render("[Y] Allow  •  [N/Esc] Refuse"))
+
+	box := styles.BoxStyle.Width(min(60, max(40, a.width-4))).Render(content.String())
+	if a.width > 0 && a.height > 0 {
+		return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, box)
+	}
+	return box
+}
@@ -0,0 +1,98 @@
+// Package dockercredential implements the protocol docker uses to talk to
+// credential helpers, see https://github.com/docker/docker-credential-helpers
+package dockercredential
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned by stores without credentials for a server
+// docker recognizes missing credentials by this exact message
+var ErrNotFound = errors.New("credentials not found in native keychain")
+
+// maxInputSize bounds what is read from docker, credentials are small
+const maxInputSize = 1 << 20
+
+// Credentials are the login of a registry
+type Credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Store keeps the credentials of registries by server URL
+type Store interface {
+	Add(creds *Credentials) error
+	Delete(serverURL string) error
+	Get(serverURL string) (username, secret string, err error)
+	List() (map[string]string, error)
+}
+
+// Actions lists the actions a helper is run with
+var Actions = []string{"store", "get", "erase", "list"}
+
+// Serve runs one action, reading its input from in and writing its answer to out
+// Helpers print the returned error on stdout and exit with status 1
+func Serve(store Store, action string, in io.Reader, out io.Writer) error {
+	switch action {
+	case "store":
+		var creds Credentials
+		if err := json.NewDecoder(io.LimitReader(in, maxInputSize)).Decode(&creds); err != nil {
+			return fmt.Errorf("invalid credentials: %w", err)
+		}
+		if strings.TrimSpace(creds.ServerURL) == "" {
+			return errors.New("no server URL")
+		}
+		if creds.Username == "" {
+			return errors.New("no username")
+		}
+		return store.Add(&creds)
+
+	case "get":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		username, secret, err := store.Get(serverURL)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(out).Encode(Credentials{ServerURL: serverURL, Username: username, Secret: secret})
+
+	case "erase":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		return store.Delete(serverURL)
+
+	case "list":
+		servers, err := store.List()
+		if err != nil {
+			return err
+		}
+		if servers == nil {
+			servers = map[string]string{}
+		}
+		return json.NewEncoder(out).Encode(servers)
+	}
+	return fmt.Errorf("unknown action %q (supported: %s)", action, strings.Join(Actions, ", "))
+}
+
+// readServerURL reads the server URL docker sends for get and erase
+func readServerURL(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(io.LimitReader(in, maxInputSize)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read server URL: %w", err)
+	}
+	serverURL := strings.TrimSpace(line)
+	if serverURL == "" {
+		return "", errors.New("no server URL")
+	}
+	return serverURL, nil
+}
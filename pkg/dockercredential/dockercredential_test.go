@@ -0,0 +1,122 @@
+package dockercredential
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// memoryStore keeps credentials in a map
+type memoryStore map[string]Credentials
+
+func (s memoryStore) Add(creds *Credentials) error {
+	s[creds.ServerURL] = *creds
+	return nil
+}
+
+func (s memoryStore) Delete(serverURL string) error {
+	if _, ok := s[serverURL]; !ok {
+		return ErrNotFound
+	}
+	delete(s, serverURL)
+	return nil
+}
+
+func (s memoryStore) Get(serverURL string) (string, string, error) {
+	creds, ok := s[serverURL]
+	if !ok {
+		return "", "", ErrNotFound
+	}
+	return creds.Username, creds.Secret, nil
+}
+
+func (s memoryStore) List() (map[string]string, error) {
+	servers := make(map[string]string)
+	for url, creds := range s {
+		servers[url] = creds.Username
+	}
+	return servers, nil
+}
+
+// serveOverPipes runs an action with input and output connected through pipes,
+// as docker runs helpers
+func serveOverPipes(t *testing.T, store Store, action, input string) (string, error) {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	go func() {
+		_, _ = io.WriteString(inWriter, input)
+		inWriter.Close()
+	}()
+	done := make(chan error, 1)
+	go func() {
+		err := Serve(store, action, inReader, outWriter)
+		inReader.Close()
+		outWriter.Close()
+		done <- err
+	}()
+
+	output, err := io.ReadAll(outReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output), <-done
+}
+
+func TestServe(t *testing.T) {
+	store := memoryStore{}
+
+	input := `{"ServerURL":"https://index.docker.io/v1/","Username":"alice","Secret":"s3cret"}`
+	if _, err := serveOverPipes(t, store, "store", input); err != nil {
+		t.Fatalf("store error = %v", err)
+	}
+
+	output, err := serveOverPipes(t, store, "get", "https://index.docker.io/v1/\n")
+	if err != nil {
+		t.Fatalf("get error = %v", err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal([]byte(output), &creds); err != nil {
+		t.Fatalf("get output %q: %v", output, err)
+	}
+	if creds != (Credentials{ServerURL: "https://index.docker.io/v1/", Username: "alice", Secret: "s3cret"}) {
+		t.Errorf("get = %+v", creds)
+	}
+
+	output, err = serveOverPipes(t, store, "list", "")
+	if err != nil || strings.TrimSpace(output) != `{"https://index.docker.io/v1/":"alice"}` {
+		t.Errorf("list = %q, %v", output, err)
+	}
+
+	if _, err := serveOverPipes(t, store, "erase", "https://index.docker.io/v1/"); err != nil {
+		t.Fatalf("erase error = %v", err)
+	}
+	if _, err := serveOverPipes(t, store, "get", "https://index.docker.io/v1/\n"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after erase error = %v, want ErrNotFound", err)
+	}
+	if output, _ := serveOverPipes(t, store, "list", ""); strings.TrimSpace(output) != "{}" {
+		t.Errorf("list of an empty store = %q", output)
+	}
+}
+
+func TestServeInvalidInput(t *testing.T) {
+	tests := []struct {
+		action string
+		input  string
+	}{
+		{"store", "not json"},
+		{"store", `{"Username":"alice","Secret":"x"}`},
+		{"store", `{"ServerURL":"ghcr.io","Secret":"x"}`},
+		{"get", "\n"},
+		{"erase", ""},
+		{"version", ""},
+	}
+	for _, tt := range tests {
+		if _, err := serveOverPipes(t, memoryStore{}, tt.action, tt.input); err == nil {
+			t.Errorf("%s %q succeeded", tt.action, tt.input)
+		}
+	}
+}
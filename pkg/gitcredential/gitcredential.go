@@ -0,0 +1,114 @@
+// Package gitcredential reads and writes the attributes exchanged between git and
+// credential helpers, see gitcredentials(7) and git-credential(1)
+package gitcredential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// maxLineSize bounds a single attribute line, git itself refuses longer ones
+const maxLineSize = 64 * 1024
+
+// Credential holds the attributes git knows about a credential
+// Attributes git sends that are not listed here are ignored, as the protocol requires
+type Credential struct {
+	Protocol string // e.g. https
+	Host     string // host name, with the port if it is not the default one
+	Path     string // repository path, only sent with credential.useHttpPath
+	Username string
+	Password string
+}
+
+// Read parses attributes up to a blank line or the end of input
+// A url attribute is split into protocol, host, path and username
+func Read(r io.Reader) (*Credential, error) {
+	cred := &Credential{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid credential line %q", line)
+		}
+		switch key {
+		case "protocol":
+			cred.Protocol = value
+		case "host":
+			cred.Host = value
+		case "path":
+			cred.Path = value
+		case "username":
+			cred.Username = value
+		case "password":
+			cred.Password = value
+		case "url":
+			if err := cred.setURL(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credential: %w", err)
+	}
+	return cred, nil
+}
+
+// setURL replaces the attributes with those of a URL, as git does
+func (c *Credential) setURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return fmt.Errorf("invalid credential url %q", raw)
+	}
+	*c = Credential{
+		Protocol: u.Scheme,
+		Host:     u.Host,
+		Path:     strings.TrimPrefix(u.Path, "/"),
+	}
+	if u.User != nil {
+		c.Username = u.User.Username()
+		c.Password, _ = u.User.Password()
+	}
+	return nil
+}
+
+// Write writes the non-empty attributes followed by the end of the list
+// Values containing a newline or NUL cannot be represented and are refused
+func (c *Credential) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, attr := range []struct{ key, value string }{
+		{"protocol", c.Protocol},
+		{"host", c.Host},
+		{"path", c.Path},
+		{"username", c.Username},
+		{"password", c.Password},
+	} {
+		if attr.value == "" {
+			continue
+		}
+		if strings.ContainsAny(attr.value, "\n\x00") {
+			return fmt.Errorf("credential %s contains a newline or NUL", attr.key)
+		}
+		b.WriteString(attr.key + "=" + attr.value + "\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// URL returns the credential as protocol://host/path without user information
+func (c *Credential) URL() string {
+	u := url.URL{Scheme: c.Protocol, Host: c.Host}
+	if c.Path != "" {
+		u.Path = "/" + strings.TrimPrefix(c.Path, "/")
+	}
+	return u.String()
+}
@@ -0,0 +1,54 @@
+package gitcredential
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	input := "protocol=https\nhost=git.example.com:8443\npath=team/repo.git\nusername=alice\n" +
+		"password=a=b\r\nwwwauth[]=Basic realm=\"git\"\ncapability[]=authtype\n\nprotocol=ignored\n"
+	cred, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := Credential{Protocol: "https", Host: "git.example.com:8443", Path: "team/repo.git", Username: "alice", Password: "a=b"}
+	if *cred != want {
+		t.Errorf("Read() = %+v, want %+v", *cred, want)
+	}
+	if got := cred.URL(); got != "https://git.example.com:8443/team/repo.git" {
+		t.Errorf("URL() = %q", got)
+	}
+
+	// A url attribute sets the other attributes
+	cred, err = Read(strings.NewReader("url=https://bob@example.com/repo\n"))
+	if err != nil {
+		t.Fatalf("Read(url) error = %v", err)
+	}
+	if want := (Credential{Protocol: "https", Host: "example.com", Path: "repo", Username: "bob"}); *cred != want {
+		t.Errorf("Read(url) = %+v, want %+v", *cred, want)
+	}
+
+	for _, input := range []string{"no separator\n", "=value\n", "url=not a url\n"} {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("Read(%q) succeeded", input)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var out bytes.Buffer
+	cred := &Credential{Username: "alice", Password: "s3cret"}
+	if err := cred.Write(&out); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if out.String() != "username=alice\npassword=s3cret\n\n" {
+		t.Errorf("Write() = %q", out.String())
+	}
+
+	cred.Password = "line\nbreak"
+	if err := cred.Write(&out); err == nil {
+		t.Error("Write() of a password with a newline succeeded")
+	}
+}
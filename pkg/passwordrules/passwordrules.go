@@ -0,0 +1,416 @@
+// Package passwordrules parses site password requirements written in Apple's
+// passwordrules syntax, such as "required: upper; allowed: [-!]; maxlength: 20;"
+package passwordrules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Property names of the passwordrules syntax
+const (
+	PropertyRequired       = "required"
+	PropertyAllowed        = "allowed"
+	PropertyMaxConsecutive = "max-consecutive"
+	PropertyMinLength      = "minlength"
+	PropertyMaxLength      = "maxlength"
+)
+
+// Named character classes
+const (
+	ClassUpper          = "upper"
+	ClassLower          = "lower"
+	ClassDigit          = "digit"
+	ClassSpecial        = "special"
+	ClassASCIIPrintable = "ascii-printable"
+	ClassUnicode        = "unicode"
+)
+
+// classCharacters maps named classes to their characters
+// Unicode is treated as ASCII printable since only those characters are generated
+var classCharacters = map[string]string{
+	ClassUpper:          "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	ClassLower:          "abcdefghijklmnopqrstuvwxyz",
+	ClassDigit:          "0123456789",
+	ClassSpecial:        "-~!@#$%^&*_+=`|(){}[:;\"'<>,.? ]",
+	ClassASCIIPrintable: asciiPrintable(),
+	ClassUnicode:        asciiPrintable(),
+}
+
+// Class is a named or custom character class
+type Class struct {
+	Name  string // named class, empty for custom classes
+	Chars string // characters of a custom class
+}
+
+// Characters returns the characters that belong to the class
+func (c Class) Characters() string {
+	if c.Name != "" {
+		return classCharacters[c.Name]
+	}
+	return c.Chars
+}
+
+// String returns the class in passwordrules syntax
+// Custom classes list "]" first and "-" last so they parse back unchanged
+func (c Class) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	chars := c.Chars
+	var prefix, suffix string
+	if strings.Contains(chars, "]") {
+		prefix = "]"
+		chars = strings.ReplaceAll(chars, "]", "")
+	}
+	if strings.Contains(chars, "-") {
+		suffix = "-"
+		chars = strings.ReplaceAll(chars, "-", "")
+	}
+	return "[" + prefix + chars + suffix + "]"
+}
+
+// Rules holds the requirements of one site
+type Rules struct {
+	// Required lists requirements that each need one character from any of their classes
+	Required [][]Class
+	// Allowed lists the further classes the password may use
+	Allowed []Class
+	// MinLength, MaxLength and MaxConsecutive are 0 when unrestricted
+	MinLength      int
+	MaxLength      int
+	MaxConsecutive int
+}
+
+// Parse parses a passwordrules string
+// Unknown properties are ignored; when a length or consecutive limit is given
+// several times the strictest value wins
+func Parse(text string) (*Rules, error) {
+	p := &parser{input: text}
+	rules := &Rules{}
+
+	for {
+		p.skipSpace()
+		if p.done() {
+			break
+		}
+
+		name := strings.ToLower(p.identifier())
+		if name == "" {
+			return nil, p.errorf("expected property name")
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			return nil, p.errorf("expected ':' after %q", name)
+		}
+
+		switch name {
+		case PropertyRequired, PropertyAllowed:
+			classes, err := p.classes()
+			if err != nil {
+				return nil, err
+			}
+			if len(classes) == 0 {
+				break
+			}
+			if name == PropertyRequired {
+				rules.Required = append(rules.Required, classes)
+			} else {
+				rules.Allowed = append(rules.Allowed, classes...)
+			}
+
+		case PropertyMinLength, PropertyMaxLength, PropertyMaxConsecutive:
+			value, err := p.integer()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			switch name {
+			case PropertyMinLength:
+				rules.MinLength = max(rules.MinLength, value)
+			case PropertyMaxLength:
+				rules.MaxLength = strictest(rules.MaxLength, value)
+			case PropertyMaxConsecutive:
+				rules.MaxConsecutive = strictest(rules.MaxConsecutive, value)
+			}
+
+		default:
+			p.skipValue()
+		}
+
+		p.skipSpace()
+		if !p.consume(';') && !p.done() {
+			return nil, p.errorf("expected ';' after %s", name)
+		}
+	}
+
+	if rules.MaxLength > 0 && rules.MinLength > rules.MaxLength {
+		return nil, fmt.Errorf("minlength %d exceeds maxlength %d", rules.MinLength, rules.MaxLength)
+	}
+	return rules, nil
+}
+
+// strictest returns the smaller positive limit, treating 0 as unrestricted
+func strictest(current, value int) int {
+	if current == 0 || (value > 0 && value < current) {
+		return value
+	}
+	return current
+}
+
+// String returns the rules in canonical passwordrules syntax
+func (r *Rules) String() string {
+	var parts []string
+	for _, classes := range r.Required {
+		parts = append(parts, PropertyRequired+": "+joinClasses(classes))
+	}
+	if len(r.Allowed) > 0 {
+		parts = append(parts, PropertyAllowed+": "+joinClasses(r.Allowed))
+	}
+	if r.MaxConsecutive > 0 {
+		parts = append(parts, fmt.Sprintf("%s: %d", PropertyMaxConsecutive, r.MaxConsecutive))
+	}
+	if r.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("%s: %d", PropertyMinLength, r.MinLength))
+	}
+	if r.MaxLength > 0 {
+		parts = append(parts, fmt.Sprintf("%s: %d", PropertyMaxLength, r.MaxLength))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "; ") + ";"
+}
+
+// joinClasses renders a comma separated class list
+func joinClasses(classes []Class) string {
+	names := make([]string, len(classes))
+	for i, class := range classes {
+		names[i] = class.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// RequiredSets returns the characters of each requirement
+func (r *Rules) RequiredSets() []string {
+	sets := make([]string, len(r.Required))
+	for i, classes := range r.Required {
+		sets[i] = union(classes)
+	}
+	return sets
+}
+
+// Pool returns every character a password may contain
+// Rules without required or allowed classes permit all ASCII printable characters
+func (r *Rules) Pool() string {
+	classes := append([]Class(nil), r.Allowed...)
+	for _, required := range r.Required {
+		classes = append(classes, required...)
+	}
+	if len(classes) == 0 {
+		return classCharacters[ClassASCIIPrintable]
+	}
+	return union(classes)
+}
+
+// Check reports the first rule a password violates
+func (r *Rules) Check(password string) error {
+	length := len([]rune(password))
+	if r.MinLength > 0 && length < r.MinLength {
+		return fmt.Errorf("password is shorter than %d characters", r.MinLength)
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return fmt.Errorf("password is longer than %d characters", r.MaxLength)
+	}
+
+	pool := r.Pool()
+	for _, c := range password {
+		if !strings.ContainsRune(pool, c) {
+			return fmt.Errorf("character %q is not allowed", c)
+		}
+	}
+
+	for i, set := range r.RequiredSets() {
+		if !strings.ContainsAny(password, set) {
+			return fmt.Errorf("password needs a character from %s", joinClasses(r.Required[i]))
+		}
+	}
+
+	if r.MaxConsecutive > 0 {
+		run := 0
+		var previous rune
+		for i, c := range password {
+			if i > 0 && c == previous {
+				run++
+			} else {
+				run = 1
+			}
+			if run > r.MaxConsecutive {
+				return fmt.Errorf("password repeats %q more than %d times in a row", c, r.MaxConsecutive)
+			}
+			previous = c
+		}
+	}
+	return nil
+}
+
+// union returns the sorted distinct characters of all classes
+func union(classes []Class) string {
+	seen := make(map[rune]bool)
+	for _, class := range classes {
+		for _, c := range class.Characters() {
+			seen[c] = true
+		}
+	}
+
+	chars := make([]rune, 0, len(seen))
+	for c := range seen {
+		chars = append(chars, c)
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	return string(chars)
+}
+
+// asciiPrintable returns the characters from space to tilde
+func asciiPrintable() string {
+	var b strings.Builder
+	for c := byte(' '); c <= '~'; c++ {
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// parser scans a passwordrules string
+type parser struct {
+	input string
+	pos   int
+}
+
+// done reports whether the whole input was read
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+// peek returns the next byte without consuming it
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// consume skips the next byte if it equals c
+func (p *parser) consume(c byte) bool {
+	if p.peek() == c && !p.done() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace
+func (p *parser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+// identifier reads a property or class name
+func (p *parser) identifier() string {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c != '-' {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// integer reads a non-negative number
+func (p *parser) integer() (int, error) {
+	p.skipSpace()
+	start := p.pos
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.errorf("expected a number")
+	}
+	return strconv.Atoi(p.input[start:p.pos])
+}
+
+// classes reads a comma separated list of named and custom classes
+// Unknown class names are ignored
+func (p *parser) classes() ([]Class, error) {
+	var classes []Class
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ';' {
+			return classes, nil
+		}
+
+		if p.consume('[') {
+			chars, err := p.customClass()
+			if err != nil {
+				return nil, err
+			}
+			if chars != "" {
+				classes = append(classes, Class{Chars: chars})
+			}
+		} else {
+			name := strings.ToLower(p.identifier())
+			if name == "" {
+				return nil, p.errorf("expected character class")
+			}
+			if _, ok := classCharacters[name]; ok {
+				classes = append(classes, Class{Name: name})
+			}
+		}
+
+		p.skipSpace()
+		if !p.consume(',') {
+			return classes, nil
+		}
+	}
+}
+
+// customClass reads the characters of a [...] class after the opening bracket
+// A "]" directly after the bracket is a literal; non-printable characters are dropped
+func (p *parser) customClass() (string, error) {
+	start := p.pos
+	var chars []byte
+	for {
+		if p.done() {
+			return "", fmt.Errorf("unterminated character class at offset %d", start-1)
+		}
+		c := p.input[p.pos]
+		p.pos++
+		if c == ']' && p.pos-1 > start {
+			break
+		}
+		if c >= ' ' && c <= '~' && !strings.ContainsRune(string(chars), rune(c)) {
+			chars = append(chars, c)
+		}
+	}
+	return string(chars), nil
+}
+
+// skipValue skips the value of an unknown property
+func (p *parser) skipValue() {
+	for !p.done() && p.peek() != ';' {
+		if p.consume('[') {
+			_, _ = p.customClass()
+			continue
+		}
+		p.pos++
+	}
+}
+
+// errorf returns a syntax error at the current position
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid password rules at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,125 @@
+package passwordrules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse("required: upper; required: digit, [!@#]; allowed: lower, [-_]; max-consecutive: 2; minlength: 8; maxlength: 20;")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(rules.Required) != 2 || len(rules.Required[1]) != 2 {
+		t.Fatalf("Required = %+v", rules.Required)
+	}
+	if rules.MinLength != 8 || rules.MaxLength != 20 || rules.MaxConsecutive != 2 {
+		t.Errorf("limits = %d/%d/%d, want 8/20/2", rules.MinLength, rules.MaxLength, rules.MaxConsecutive)
+	}
+
+	sets := rules.RequiredSets()
+	if sets[0] != classCharacters[ClassUpper] || sets[1] != "!#0123456789@" {
+		t.Errorf("RequiredSets() = %q", sets)
+	}
+
+	pool := rules.Pool()
+	for _, c := range "Aa0!-_" {
+		if !strings.ContainsRune(pool, c) {
+			t.Errorf("Pool() is missing %q", c)
+		}
+	}
+	if strings.ContainsAny(pool, "$ ") {
+		t.Errorf("Pool() = %q contains characters that are not allowed", pool)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"no trailing semicolon", "minlength: 6", "minlength: 6;"},
+		{"case and spacing", "  REQUIRED :UPPER ,Lower;MaxLength:  16 ;", "required: upper, lower; maxlength: 16;"},
+		{"strictest limits", "maxlength: 30; maxlength: 20; minlength: 4; minlength: 6;", "minlength: 6; maxlength: 20;"},
+		{"unknown property", "required: digit; color: [;]; allowed: upper;", "required: digit; allowed: upper;"},
+		{"unknown class", "required: emoji, digit;", "required: digit;"},
+		{"custom class specials", "allowed: []-a;];", "allowed: []a;-];"},
+		{"duplicate characters", "allowed: [aab];", "allowed: [ab];"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := rules.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			// Canonical output parses back to itself
+			again, err := Parse(rules.String())
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", rules.String(), err)
+			}
+			if again.String() != tt.want {
+				t.Errorf("round trip = %q, want %q", again.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"required upper;",
+		"minlength: ten;",
+		"allowed: [abc;",
+		"required: upper lower;",
+		"minlength: 20; maxlength: 10;",
+		"; ;",
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestDefaultPool(t *testing.T) {
+	rules, err := Parse("minlength: 8;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool := rules.Pool(); len(pool) != 95 {
+		t.Errorf("Pool() has %d characters, want the 95 ASCII printable ones", len(pool))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	rules, err := Parse("required: upper; required: digit; allowed: lower; max-consecutive: 2; minlength: 6; maxlength: 10;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{"Abcde1", true},
+		{"Abc1", false},        // too short
+		{"Abcdefghij1", false}, // too long
+		{"abcdef1", false},     // no upper
+		{"Abcdefg", false},     // no digit
+		{"Abc-de1", false},     // symbol not allowed
+		{"Abbbcd1", false},     // three in a row
+		{"AbbcdE1", true},
+	}
+
+	for _, tt := range tests {
+		if err := rules.Check(tt.password); (err == nil) != tt.ok {
+			t.Errorf("Check(%q) error = %v, want ok = %v", tt.password, err, tt.ok)
+		}
+	}
+}
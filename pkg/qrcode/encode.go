@@ -0,0 +1,123 @@
+package qrcode
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	zxqrcode "github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// terminalQuietZone is the blank border, in modules, drawn around terminal codes
+const terminalQuietZone = 2
+
+// ANSI sequences for black modules on a white background, independent of the terminal theme
+const (
+	ansiBlackOnWhite = "\x1b[30;107m"
+	ansiReset        = "\x1b[0m"
+)
+
+// Matrix returns the modules of the QR code for text, true meaning dark,
+// surrounded by quietZone light modules on each side
+func Matrix(text string, quietZone int) ([][]bool, error) {
+	bits, err := encode(text, 0, quietZone)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := make([][]bool, bits.GetHeight())
+	for y := range matrix {
+		matrix[y] = make([]bool, bits.GetWidth())
+		for x := range matrix[y] {
+			matrix[y][x] = bits.Get(x, y)
+		}
+	}
+	return matrix, nil
+}
+
+// Terminal renders the QR code for text with Unicode half blocks, two module
+// rows per line. Modules are scaled up as far as the code still fits within
+// maxCols columns and maxRows lines; maxRows <= 0 leaves the height unbounded
+// and maxCols <= 0 renders one column per module without size checks
+func Terminal(text string, maxCols, maxRows int) (string, error) {
+	matrix, err := Matrix(text, terminalQuietZone)
+	if err != nil {
+		return "", err
+	}
+
+	size := len(matrix)
+	scale := 1
+	for maxCols > 0 && fits(size*(scale+1), maxCols, maxRows) {
+		scale++
+	}
+	if maxCols > 0 && !fits(size*scale, maxCols, maxRows) {
+		return "", fmt.Errorf("terminal too small for QR code: need %dx%d, have %dx%d",
+			size, (size+1)/2, maxCols, maxRows)
+	}
+
+	dark := func(x, y int) bool {
+		if y >= size*scale {
+			return false
+		}
+		return matrix[y/scale][x/scale]
+	}
+
+	var b strings.Builder
+	for y := 0; y < size*scale; y += 2 {
+		b.WriteString(ansiBlackOnWhite)
+		for x := 0; x < size*scale; x++ {
+			top, bottom := dark(x, y), dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(ansiReset)
+		if y+2 < size*scale {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// fits reports whether a square of the given module size fits in the terminal area
+func fits(modules, maxCols, maxRows int) bool {
+	if modules > maxCols {
+		return false
+	}
+	return maxRows <= 0 || (modules+1)/2 <= maxRows
+}
+
+// WritePNG writes the QR code for text as a PNG image about size pixels wide
+func WritePNG(w io.Writer, text string, size int) error {
+	bits, err := encode(text, size, 4)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(w, bits); err != nil {
+		return fmt.Errorf("failed to write PNG: %w", err)
+	}
+	return nil
+}
+
+// encode builds the QR code bitmap for text with medium error correction,
+// which scans more reliably from screens than the minimum level
+func encode(text string, size, quietZone int) (*gozxing.BitMatrix, error) {
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_ERROR_CORRECTION: "M",
+		gozxing.EncodeHintType_MARGIN:           quietZone,
+	}
+	bits, err := zxqrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, size, size, hints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return bits, nil
+}
@@ -0,0 +1,63 @@
+// Package qrcode reads QR codes from images
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	zxqrcode "github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// imageExtensions lists the file extensions DecodeFile understands
+var imageExtensions = []string{".png", ".jpg", ".jpeg"}
+
+// IsImagePath reports whether path names a supported image file
+func IsImagePath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSpace(path)))
+	for _, supported := range imageExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeFile reads a PNG or JPEG file and returns the text of the QR code it contains
+func DecodeFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return Decode(img)
+}
+
+// Decode returns the text of the QR code contained in img
+func Decode(img image.Image) (string, error) {
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	result, err := zxqrcode.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		return "", fmt.Errorf("no QR code found in image: %w", err)
+	}
+
+	return result.GetText(), nil
+}
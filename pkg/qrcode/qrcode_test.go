@@ -0,0 +1,141 @@
+package qrcode
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testURI = "otpauth://totp/ACME:bob@acme.io?issuer=ACME&secret=JBSWY3DPEHPK3PXP"
+
+func encodeTestImage(t *testing.T, text string) image.Image {
+	t.Helper()
+	matrix, err := encode(text, 256, 4)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return matrix
+}
+
+func TestDecodeFile(t *testing.T) {
+	img := encodeTestImage(t, testURI)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		encode func(f *os.File) error
+	}{
+		{"code.png", func(f *os.File) error { return png.Encode(f, img) }},
+		{"code.JPG", func(f *os.File) error { return jpeg.Encode(f, img, &jpeg.Options{Quality: 90}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.encode(f); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			if !IsImagePath(path) {
+				t.Errorf("IsImagePath(%q) = false, want true", path)
+			}
+
+			text, err := DecodeFile(path)
+			if err != nil {
+				t.Fatalf("DecodeFile() error = %v", err)
+			}
+			if text != testURI {
+				t.Errorf("DecodeFile() = %q, want %q", text, testURI)
+			}
+		})
+	}
+}
+
+func TestDecodeNoCode(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	if _, err := Decode(img); err == nil {
+		t.Error("Decode() of blank image succeeded, want error")
+	}
+}
+
+func TestIsImagePath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"shot.png":       true,
+		"shot.jpeg":      true,
+		"JBSWY3DPEHPK3P": false,
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP": false,
+	} {
+		if got := IsImagePath(path); got != want {
+			t.Errorf("IsImagePath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestWritePNGRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qr.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePNG(f, testURI, 512); err != nil {
+		t.Fatalf("WritePNG() error = %v", err)
+	}
+	f.Close()
+
+	text, err := DecodeFile(path)
+	if err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if text != testURI {
+		t.Errorf("DecodeFile() = %q, want %q", text, testURI)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	matrix, err := Matrix(testURI, terminalQuietZone)
+	if err != nil {
+		t.Fatalf("Matrix() error = %v", err)
+	}
+	size := len(matrix)
+
+	// Room for exactly twice the module size
+	out, err := Terminal(testURI, size*2+1, size+1)
+	if err != nil {
+		t.Fatalf("Terminal() error = %v", err)
+	}
+
+	lines := strings.Split(out, "\n")
+	if len(lines) != size {
+		t.Fatalf("lines = %d, want %d", len(lines), size)
+	}
+	for y, line := range lines {
+		line = strings.TrimSuffix(strings.TrimPrefix(line, ansiBlackOnWhite), ansiReset)
+		cells := []rune(line)
+		if len(cells) != size*2 {
+			t.Fatalf("line %d has %d cells, want %d", y, len(cells), size*2)
+		}
+		for x, cell := range cells {
+			// Each line covers one module row at scale 2
+			want := ' '
+			if matrix[y][x/2] {
+				want = '█'
+			}
+			if cell != want {
+				t.Fatalf("cell (%d,%d) = %q, want %q", x, y, cell, want)
+			}
+		}
+	}
+
+	if _, err := Terminal(testURI, size-1, 100); err == nil {
+		t.Error("Terminal() in a too narrow terminal succeeded, want error")
+	}
+}
@@ -0,0 +1,131 @@
+package totp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder names as used in the encoder parameter of otpauth:// URIs
+const (
+	EncoderRFC   = ""      // RFC 4226 decimal digits
+	EncoderSteam = "steam" // Steam Guard 5 character codes
+	EncoderMOTP  = "motp"  // Mobile-OTP (MD5 of time, secret and PIN)
+)
+
+// steamAlphabet is the character set used by Steam Guard codes
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// steamDigits is the fixed length of Steam Guard codes
+const steamDigits = 5
+
+// Encoder turns a counter value into a one-time code
+type Encoder interface {
+	// Encode returns the code for the given counter
+	Encode(c *Config, counter uint64) (string, error)
+	// Validate checks that the configuration can be used with this encoder
+	Validate(c *Config) error
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		EncoderRFC:   rfcEncoder{},
+		EncoderSteam: steamEncoder{},
+		EncoderMOTP:  motpEncoder{},
+	}
+)
+
+// RegisterEncoder makes an encoder available under name
+// Registering an existing name replaces the previous encoder
+func RegisterEncoder(name string, encoder Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[strings.ToLower(name)] = encoder
+}
+
+// LookupEncoder returns the encoder registered under name
+func LookupEncoder(name string) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	encoder, ok := encoders[strings.ToLower(name)]
+	return encoder, ok
+}
+
+// rfcEncoder produces standard HOTP/TOTP decimal codes
+type rfcEncoder struct{}
+
+func (rfcEncoder) Encode(c *Config, counter uint64) (string, error) {
+	secret, err := decodeSecret(c.Secret)
+	if err != nil {
+		return "", err
+	}
+	return c.generateHOTP(secret, counter)
+}
+
+func (rfcEncoder) Validate(c *Config) error {
+	if _, err := decodeSecret(c.Secret); err != nil {
+		return err
+	}
+
+	switch c.Algorithm {
+	case "SHA1", "SHA256", "SHA512":
+	default:
+		return fmt.Errorf("unsupported algorithm: %s", c.Algorithm)
+	}
+
+	if c.Digits < 6 || c.Digits > 10 {
+		return fmt.Errorf("invalid digits: %d (must be between 6 and 10)", c.Digits)
+	}
+	return nil
+}
+
+// steamEncoder produces Steam Guard codes: the truncated HMAC-SHA1 value
+// is written in base 26 using a reduced alphabet
+type steamEncoder struct{}
+
+func (steamEncoder) Encode(c *Config, counter uint64) (string, error) {
+	secret, err := decodeSecret(c.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := truncatedHMAC("SHA1", secret, counter)
+	if err != nil {
+		return "", err
+	}
+
+	code := make([]byte, steamDigits)
+	for i := range code {
+		code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+		value /= uint32(len(steamAlphabet))
+	}
+	return string(code), nil
+}
+
+func (steamEncoder) Validate(c *Config) error {
+	if c.IsHOTP() {
+		return fmt.Errorf("steam encoder only supports time-based codes")
+	}
+	_, err := decodeSecret(c.Secret)
+	return err
+}
+
+// motpEncoder produces Mobile-OTP codes: the first six hex characters of
+// MD5(counter + secret + PIN), where the counter is the time in 10 second steps
+type motpEncoder struct{}
+
+func (motpEncoder) Encode(c *Config, counter uint64) (string, error) {
+	sum := md5.Sum([]byte(strconv.FormatUint(counter, 10) + c.Secret + c.PIN))
+	return hex.EncodeToString(sum[:])[:6], nil
+}
+
+func (motpEncoder) Validate(c *Config) error {
+	if c.IsHOTP() {
+		return fmt.Errorf("motp encoder only supports time-based codes")
+	}
+	return nil
+}
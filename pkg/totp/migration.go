@@ -0,0 +1,257 @@
+package totp
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MigrationScheme is the URI scheme of Google Authenticator exports
+const MigrationScheme = "otpauth-migration"
+
+// Field numbers of the Google Authenticator MigrationPayload message
+const (
+	migrationOTPParameters = 1
+	migrationVersion       = 2
+	migrationBatchSize     = 3
+	migrationBatchIndex    = 4
+	migrationBatchID       = 5
+
+	otpSecret    = 1
+	otpName      = 2
+	otpIssuer    = 3
+	otpAlgorithm = 4
+	otpDigits    = 5
+	otpType      = 6
+	otpCounter   = 7
+)
+
+// Enum values used by the MigrationPayload message, 0 meaning unspecified
+var (
+	migrationAlgorithms = map[uint64]string{0: "SHA1", 1: "SHA1", 2: "SHA256", 3: "SHA512"}
+	migrationDigits     = map[uint64]int{0: 6, 1: 6, 2: 8}
+	migrationTypes      = map[uint64]string{0: TypeTOTP, 1: TypeHOTP, 2: TypeTOTP}
+)
+
+// MigrationBatch is one QR code of a Google Authenticator export
+// Large exports are split into BatchSize codes sharing the same BatchID
+type MigrationBatch struct {
+	Configs    []*Config
+	Version    int
+	BatchSize  int
+	BatchIndex int
+	BatchID    int
+}
+
+// IsMigrationURI reports whether input is an otpauth-migration:// URI
+func IsMigrationURI(input string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(input)), MigrationScheme+"://")
+}
+
+// ParseMigrationURI decodes an otpauth-migration://offline?data=... URI
+func ParseMigrationURI(uri string) (*MigrationBatch, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI: %w", err)
+	}
+
+	if u.Scheme != MigrationScheme {
+		return nil, fmt.Errorf("invalid scheme: expected %s, got %s", MigrationScheme, u.Scheme)
+	}
+
+	data := u.Query().Get("data")
+	if data == "" {
+		return nil, fmt.Errorf("missing data parameter")
+	}
+
+	// The payload is standard Base64, but some scanners drop the padding
+	payload, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		payload, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data parameter: %w", err)
+		}
+	}
+
+	return DecodeMigrationPayload(payload)
+}
+
+// DecodeMigrationPayload decodes the protobuf MigrationPayload of an export
+func DecodeMigrationPayload(payload []byte) (*MigrationBatch, error) {
+	batch := &MigrationBatch{BatchSize: 1}
+
+	err := walkFields(payload, func(num protowire.Number, value uint64, bytes []byte) error {
+		switch num {
+		case migrationOTPParameters:
+			config, err := decodeOTPParameters(bytes)
+			if err != nil {
+				return err
+			}
+			batch.Configs = append(batch.Configs, config)
+		case migrationVersion:
+			batch.Version = int(value)
+		case migrationBatchSize:
+			batch.BatchSize = int(value)
+		case migrationBatchIndex:
+			batch.BatchIndex = int(value)
+		case migrationBatchID:
+			batch.BatchID = int(int32(value))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid migration payload: %w", err)
+	}
+
+	if batch.BatchSize < 1 || batch.BatchIndex < 0 || batch.BatchIndex >= batch.BatchSize {
+		return nil, fmt.Errorf("invalid migration payload: batch %d of %d", batch.BatchIndex+1, batch.BatchSize)
+	}
+
+	return batch, nil
+}
+
+// decodeOTPParameters decodes one account of a MigrationPayload
+func decodeOTPParameters(data []byte) (*Config, error) {
+	var (
+		secret []byte
+		name   string
+		config = DefaultConfig("")
+	)
+
+	err := walkFields(data, func(num protowire.Number, value uint64, bytes []byte) error {
+		switch num {
+		case otpSecret:
+			secret = bytes
+		case otpName:
+			name = string(bytes)
+		case otpIssuer:
+			config.Issuer = string(bytes)
+		case otpAlgorithm:
+			algorithm, ok := migrationAlgorithms[value]
+			if !ok {
+				return fmt.Errorf("unsupported algorithm: %d", value)
+			}
+			config.Algorithm = algorithm
+		case otpDigits:
+			digits, ok := migrationDigits[value]
+			if !ok {
+				return fmt.Errorf("unsupported digit count: %d", value)
+			}
+			config.Digits = digits
+		case otpType:
+			kind, ok := migrationTypes[value]
+			if !ok {
+				return fmt.Errorf("unsupported OTP type: %d", value)
+			}
+			config.Type = kind
+		case otpCounter:
+			config.Counter = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("account %q has no secret", name)
+	}
+	config.Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+
+	// Names are stored as "Issuer:account" or just "account"
+	if issuer, account, ok := strings.Cut(name, ":"); ok {
+		if config.Issuer == "" {
+			config.Issuer = issuer
+		}
+		name = account
+	}
+	config.Account = strings.TrimSpace(name)
+
+	if err := config.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("account %q: %w", name, err)
+	}
+	return config, nil
+}
+
+// walkFields calls fn for every varint and length-delimited field of a protobuf message
+// Fields of other wire types are skipped
+func walkFields(data []byte, fn func(num protowire.Number, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var (
+			value uint64
+			bytes []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(num, value, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeMigrationBatches combines the parts of one or more exports into a single list
+// Every part of each export must be present; accounts keep their export order
+func MergeMigrationBatches(batches []*MigrationBatch) ([]*Config, error) {
+	type export struct {
+		size  int
+		parts map[int]*MigrationBatch
+	}
+
+	exports := make(map[int]*export)
+	var order []int
+	for _, batch := range batches {
+		e, ok := exports[batch.BatchID]
+		if !ok {
+			e = &export{size: batch.BatchSize, parts: make(map[int]*MigrationBatch)}
+			exports[batch.BatchID] = e
+			order = append(order, batch.BatchID)
+		}
+		if batch.BatchSize != e.size {
+			return nil, fmt.Errorf("export %d: inconsistent batch size %d and %d", batch.BatchID, e.size, batch.BatchSize)
+		}
+		e.parts[batch.BatchIndex] = batch
+	}
+
+	var configs []*Config
+	for _, id := range order {
+		e := exports[id]
+		var missing []string
+		for i := 0; i < e.size; i++ {
+			if _, ok := e.parts[i]; !ok {
+				missing = append(missing, fmt.Sprint(i+1))
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("export is missing QR code %s of %d", strings.Join(missing, ", "), e.size)
+		}
+		for i := 0; i < e.size; i++ {
+			configs = append(configs, e.parts[i].Configs...)
+		}
+	}
+	return configs, nil
+}
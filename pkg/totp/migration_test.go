@@ -0,0 +1,199 @@
+package totp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// exampleMigrationURI is the widely published sample export holding one
+// account: Example:alice@google.com with secret JBSWY3DPEHPK3PXP
+const exampleMigrationURI = "otpauth-migration://offline?data=CjMKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZSABKAEwAhABGAEgACjr4JKK%2Bf%2F%2F%2F%2F8B"
+
+func TestParseMigrationURI(t *testing.T) {
+	batch, err := ParseMigrationURI(exampleMigrationURI)
+	if err != nil {
+		t.Fatalf("ParseMigrationURI() error = %v", err)
+	}
+
+	if batch.Version != 1 || batch.BatchSize != 1 || batch.BatchIndex != 0 {
+		t.Errorf("batch = version %d, %d/%d, want version 1, 0/1", batch.Version, batch.BatchIndex, batch.BatchSize)
+	}
+	if len(batch.Configs) != 1 {
+		t.Fatalf("accounts = %d, want 1", len(batch.Configs))
+	}
+
+	config := batch.Configs[0]
+	if config.Secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Secret = %v, want JBSWY3DPEHPK3PXP", config.Secret)
+	}
+	if config.Issuer != "Example" || config.Account != "alice@google.com" {
+		t.Errorf("label = %s:%s, want Example:alice@google.com", config.Issuer, config.Account)
+	}
+	if config.Type != TypeTOTP || config.Algorithm != "SHA1" || config.Digits != 6 {
+		t.Errorf("config = %s %s %d digits, want totp SHA1 6 digits", config.Type, config.Algorithm, config.Digits)
+	}
+}
+
+func TestMigrationRoundTrip(t *testing.T) {
+	hotp := DefaultConfig("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	hotp.Type = TypeHOTP
+	hotp.Counter = 42
+	hotp.Account = "bob"
+
+	sha256 := DefaultConfig("JBSWY3DPEHPK3PXP")
+	sha256.Algorithm = "SHA256"
+	sha256.Digits = 8
+	sha256.Issuer = "ACME"
+	sha256.Account = "bob@acme.io"
+
+	uri, err := encodeMigrationURI(&MigrationBatch{Configs: []*Config{hotp, sha256}, Version: 1, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("encodeMigrationURI() error = %v", err)
+	}
+
+	batch, err := ParseMigrationURI(uri)
+	if err != nil {
+		t.Fatalf("ParseMigrationURI() error = %v", err)
+	}
+	if len(batch.Configs) != 2 {
+		t.Fatalf("accounts = %d, want 2", len(batch.Configs))
+	}
+
+	for i, want := range []*Config{hotp, sha256} {
+		got := batch.Configs[i]
+		if got.Secret != want.Secret || got.Type != want.Type || got.Counter != want.Counter ||
+			got.Algorithm != want.Algorithm || got.Digits != want.Digits ||
+			got.Issuer != want.Issuer || got.Account != want.Account {
+			t.Errorf("account %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestMergeMigrationBatches(t *testing.T) {
+	part := func(index int, account string) *MigrationBatch {
+		config := DefaultConfig("JBSWY3DPEHPK3PXP")
+		config.Account = account
+		return &MigrationBatch{Configs: []*Config{config}, BatchSize: 3, BatchIndex: index, BatchID: 7}
+	}
+
+	// Parts may be scanned in any order
+	configs, err := MergeMigrationBatches([]*MigrationBatch{part(2, "c"), part(0, "a"), part(1, "b")})
+	if err != nil {
+		t.Fatalf("MergeMigrationBatches() error = %v", err)
+	}
+	var accounts []string
+	for _, config := range configs {
+		accounts = append(accounts, config.Account)
+	}
+	if strings.Join(accounts, ",") != "a,b,c" {
+		t.Errorf("accounts = %v, want [a b c]", accounts)
+	}
+
+	_, err = MergeMigrationBatches([]*MigrationBatch{part(0, "a"), part(2, "c")})
+	if err == nil || !strings.Contains(err.Error(), "missing QR code 2 of 3") {
+		t.Errorf("MergeMigrationBatches() error = %v, want missing part 2", err)
+	}
+}
+
+func TestParseMigrationURIInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://offline",
+		"otpauth-migration://offline?data=%%%",
+		"otpauth-migration://offline?data=CgA=", // account without secret
+	} {
+		if _, err := ParseMigrationURI(uri); err == nil {
+			t.Errorf("ParseMigrationURI(%q) succeeded, want error", uri)
+		}
+	}
+}
+
+// Enum values Google Authenticator expects when encoding a MigrationPayload
+var (
+	exportAlgorithms = map[string]uint64{"SHA1": 1, "SHA256": 2, "SHA512": 3}
+	exportDigits     = map[int]uint64{6: 1, 8: 2}
+	exportTypes      = map[string]uint64{TypeHOTP: 1, TypeTOTP: 2}
+)
+
+// encodeMigrationURI encodes a batch as an otpauth-migration:// URI so tests can build exports
+func encodeMigrationURI(b *MigrationBatch) (string, error) {
+	var payload []byte
+
+	for _, config := range b.Configs {
+		params, err := encodeOTPParameters(config)
+		if err != nil {
+			return "", err
+		}
+		payload = protowire.AppendTag(payload, migrationOTPParameters, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, params)
+	}
+
+	for _, field := range []struct {
+		num   protowire.Number
+		value int
+	}{
+		{migrationVersion, b.Version},
+		{migrationBatchSize, b.BatchSize},
+		{migrationBatchIndex, b.BatchIndex},
+		{migrationBatchID, b.BatchID},
+	} {
+		payload = protowire.AppendTag(payload, field.num, protowire.VarintType)
+		payload = protowire.AppendVarint(payload, uint64(field.value))
+	}
+
+	values := url.Values{}
+	values.Set("data", base64.StdEncoding.EncodeToString(payload))
+	return MigrationScheme + "://offline?" + values.Encode(), nil
+}
+
+// encodeOTPParameters encodes one account for a MigrationPayload
+func encodeOTPParameters(config *Config) ([]byte, error) {
+	if config.Encoder != EncoderRFC {
+		return nil, fmt.Errorf("cannot export %s codes", config.Encoder)
+	}
+
+	secret, err := decodeSecret(config.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	name := config.Account
+	if config.Issuer != "" {
+		name = config.Issuer + ":" + config.Account
+	}
+
+	algorithm, ok := exportAlgorithms[config.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("cannot export algorithm %s", config.Algorithm)
+	}
+	digits, ok := exportDigits[config.Digits]
+	if !ok {
+		return nil, fmt.Errorf("cannot export %d digit codes", config.Digits)
+	}
+	kind := exportTypes[TypeTOTP]
+	if config.IsHOTP() {
+		kind = exportTypes[TypeHOTP]
+	}
+
+	var data []byte
+	data = protowire.AppendTag(data, otpSecret, protowire.BytesType)
+	data = protowire.AppendBytes(data, secret)
+	data = protowire.AppendTag(data, otpName, protowire.BytesType)
+	data = protowire.AppendString(data, name)
+	data = protowire.AppendTag(data, otpIssuer, protowire.BytesType)
+	data = protowire.AppendString(data, config.Issuer)
+	data = protowire.AppendTag(data, otpAlgorithm, protowire.VarintType)
+	data = protowire.AppendVarint(data, algorithm)
+	data = protowire.AppendTag(data, otpDigits, protowire.VarintType)
+	data = protowire.AppendVarint(data, digits)
+	data = protowire.AppendTag(data, otpType, protowire.VarintType)
+	data = protowire.AppendVarint(data, kind)
+	data = protowire.AppendTag(data, otpCounter, protowire.VarintType)
+	data = protowire.AppendVarint(data, config.Counter)
+	return data, nil
+}
@@ -16,19 +16,33 @@ import (
 	"time"
 )
 
+// OTP types as used in the host part of otpauth:// URIs
+const (
+	TypeTOTP = "totp"
+	TypeHOTP = "hotp"
+)
+
+// DefaultLookAhead is the number of counter values checked ahead when validating HOTP codes
+const DefaultLookAhead = 10
+
 // Config represents TOTP configuration parameters
 type Config struct {
+	Type      string        // OTP type: "totp" (default) or "hotp"
 	Secret    string        // Base32 encoded secret
-	Period    time.Duration // Time period (default 30s)
+	Period    time.Duration // Time period (default 30s), TOTP only
+	Counter   uint64        // Next counter value, HOTP only
 	Digits    int           // Number of digits (default 6)
 	Algorithm string        // Hash algorithm: "SHA1", "SHA256", "SHA512"
 	Issuer    string        // Optional issuer name
 	Account   string        // Optional account name
+	Encoder   string        // Code encoder: "" (RFC digits), "steam" or "motp"
+	PIN       string        // PIN mixed into mOTP codes
 }
 
 // DefaultConfig returns a standard TOTP configuration
 func DefaultConfig(secret string) *Config {
 	return &Config{
+		Type:      TypeTOTP,
 		Secret:    secret,
 		Period:    30 * time.Second,
 		Digits:    6,
@@ -36,6 +50,23 @@ func DefaultConfig(secret string) *Config {
 	}
 }
 
+// IsHOTP returns true for counter-based configurations
+func (c *Config) IsHOTP() bool {
+	return c.Type == TypeHOTP
+}
+
+// CodeLength returns the number of characters in generated codes
+func (c *Config) CodeLength() int {
+	switch c.Encoder {
+	case EncoderSteam:
+		return steamDigits
+	case EncoderMOTP:
+		return 6
+	default:
+		return c.Digits
+	}
+}
+
 // GenerateCode generates the current TOTP code
 // Returns: code, time until expiry, error
 func (c *Config) GenerateCode() (string, time.Duration, error) {
@@ -43,18 +74,22 @@ func (c *Config) GenerateCode() (string, time.Duration, error) {
 }
 
 // GenerateCodeAt generates a TOTP code for a specific time
+// HOTP configurations return the code for the current counter without expiry
 func (c *Config) GenerateCodeAt(t time.Time) (string, time.Duration, error) {
-	// Decode secret
-	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(c.Secret))
-	if err != nil {
-		return "", 0, fmt.Errorf("invalid secret: %w", err)
+	if c.IsHOTP() {
+		code, err := c.encode(c.Counter)
+		return code, 0, err
+	}
+
+	if c.Period < time.Second {
+		return "", 0, fmt.Errorf("invalid period: %s", c.Period)
 	}
 
 	// Calculate time counter
 	counter := uint64(t.Unix()) / uint64(c.Period.Seconds())
 
-	// Generate HOTP
-	code, err := c.generateHOTP(secret, counter)
+	// Generate code
+	code, err := c.encode(counter)
 	if err != nil {
 		return "", 0, err
 	}
@@ -66,11 +101,68 @@ func (c *Config) GenerateCodeAt(t time.Time) (string, time.Duration, error) {
 	return code, expiresIn, nil
 }
 
+// GenerateCodeAtCounter generates the HOTP code for a specific counter value
+func (c *Config) GenerateCodeAtCounter(counter uint64) (string, error) {
+	return c.encode(counter)
+}
+
+// NextCode generates the HOTP code for the current counter and advances the counter
+func (c *Config) NextCode() (string, error) {
+	code, err := c.GenerateCodeAtCounter(c.Counter)
+	if err != nil {
+		return "", err
+	}
+	c.Counter++
+	return code, nil
+}
+
+// ValidateCounter checks an HOTP code against the current counter and the
+// following lookAhead values. On success it returns the counter to use next,
+// which resynchronizes a token that was advanced without being used
+func (c *Config) ValidateCounter(code string, lookAhead int) (uint64, bool) {
+	for i := 0; i <= lookAhead; i++ {
+		counter := c.Counter + uint64(i)
+		expected, err := c.encode(counter)
+		if err != nil {
+			return c.Counter, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return counter + 1, true
+		}
+	}
+
+	return c.Counter, false
+}
+
+// encode generates the code for a counter value with the configured encoder
+func (c *Config) encode(counter uint64) (string, error) {
+	encoder, ok := LookupEncoder(c.Encoder)
+	if !ok {
+		return "", fmt.Errorf("unsupported encoder: %s", c.Encoder)
+	}
+	return encoder.Encode(c, counter)
+}
+
 // generateHOTP generates an HOTP code
 func (c *Config) generateHOTP(secret []byte, counter uint64) (string, error) {
+	code, err := truncatedHMAC(c.Algorithm, secret, counter)
+	if err != nil {
+		return "", err
+	}
+
+	// Generate digits, 10^10 does not fit in 32 bits
+	modulo := uint64(math.Pow10(c.Digits))
+
+	// Format with leading zeros
+	format := fmt.Sprintf("%%0%dd", c.Digits)
+	return fmt.Sprintf(format, uint64(code)%modulo), nil
+}
+
+// truncatedHMAC computes the HMAC of counter and applies RFC 4226 dynamic truncation
+func truncatedHMAC(algorithm string, secret []byte, counter uint64) (uint32, error) {
 	// Create HMAC hash function
 	var h func() hash.Hash
-	switch c.Algorithm {
+	switch algorithm {
 	case "SHA1":
 		h = sha1.New
 	case "SHA256":
@@ -78,7 +170,7 @@ func (c *Config) generateHOTP(secret []byte, counter uint64) (string, error) {
 	case "SHA512":
 		h = sha512.New
 	default:
-		return "", fmt.Errorf("unsupported algorithm: %s", c.Algorithm)
+		return 0, fmt.Errorf("unsupported algorithm: %s", algorithm)
 	}
 
 	// Create HMAC
@@ -94,15 +186,7 @@ func (c *Config) generateHOTP(secret []byte, counter uint64) (string, error) {
 
 	// Dynamic truncation
 	offset := hmacResult[len(hmacResult)-1] & 0xf
-	code := binary.BigEndian.Uint32(hmacResult[offset:offset+4]) & 0x7fffffff
-
-	// Generate digits
-	modulo := uint32(math.Pow10(c.Digits))
-	code = code % modulo
-
-	// Format with leading zeros
-	format := fmt.Sprintf("%%0%dd", c.Digits)
-	return fmt.Sprintf(format, code), nil
+	return binary.BigEndian.Uint32(hmacResult[offset:offset+4]) & 0x7fffffff, nil
 }
 
 // Validate checks if a code is valid for the current time window
@@ -112,7 +196,13 @@ func (c *Config) Validate(code string) bool {
 }
 
 // ValidateAt checks if a code is valid for a specific time
+// HOTP configurations ignore the time and use the look-ahead window instead
 func (c *Config) ValidateAt(code string, t time.Time) bool {
+	if c.IsHOTP() {
+		_, ok := c.ValidateCounter(code, DefaultLookAhead)
+		return ok
+	}
+
 	// Check current time
 	currentCode, _, err := c.GenerateCodeAt(t)
 	if err == nil && currentCode == code {
@@ -135,8 +225,93 @@ func (c *Config) ValidateAt(code string, t time.Time) bool {
 	return false
 }
 
+// ValidateConfig checks that the configuration can generate codes
+func (c *Config) ValidateConfig() error {
+	if c.Type != "" && c.Type != TypeTOTP && c.Type != TypeHOTP {
+		return fmt.Errorf("unsupported OTP type: %s", c.Type)
+	}
+
+	if c.Secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	if !c.IsHOTP() && c.Period < time.Second {
+		return fmt.Errorf("invalid period: %s", c.Period)
+	}
+
+	encoder, ok := LookupEncoder(c.Encoder)
+	if !ok {
+		return fmt.Errorf("unsupported encoder: %s", c.Encoder)
+	}
+	return encoder.Validate(c)
+}
+
+// Parse parses an otpauth:// URI, a steam:// secret or a bare Base32 secret
+func Parse(input string) (*Config, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("missing secret")
+	}
+
+	lower := strings.ToLower(input)
+	if strings.HasPrefix(lower, "otpauth://") {
+		return ParseURI(input)
+	}
+
+	if strings.HasPrefix(lower, "steam://") {
+		config := SteamConfig(NormalizeSecret(input[len("steam://"):]))
+		if err := config.ValidateConfig(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	config := DefaultConfig(NormalizeSecret(input))
+	if err := config.ValidateConfig(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SteamConfig returns a Steam Guard configuration for a Base32 secret
+func SteamConfig(secret string) *Config {
+	config := DefaultConfig(secret)
+	config.Encoder = EncoderSteam
+	config.Digits = steamDigits
+	config.Issuer = "Steam"
+	return config
+}
+
+// MOTPConfig returns a Mobile-OTP configuration for a secret and PIN
+func MOTPConfig(secret, pin string) *Config {
+	config := DefaultConfig(secret)
+	config.Encoder = EncoderMOTP
+	config.PIN = pin
+	config.Period = 10 * time.Second
+	config.Algorithm = "MD5"
+	return config
+}
+
+// NormalizeSecret upper-cases a Base32 secret and strips spaces, dashes and padding
+func NormalizeSecret(secret string) string {
+	secret = strings.ToUpper(secret)
+	secret = strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret)
+	return secret
+}
+
+// decodeSecret decodes a Base32 secret with or without padding
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(NormalizeSecret(secret))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+	return key, nil
+}
+
 // ParseURI parses an otpauth:// URI
 // Format: otpauth://totp/{issuer}:{account}?secret={secret}&issuer={issuer}&period={period}&digits={digits}&algorithm={algorithm}
+// HOTP URIs use otpauth://hotp/... and require a counter parameter instead of period
+// The encoder parameter selects Steam Guard ("steam") or Mobile-OTP ("motp", with pin) codes
 func ParseURI(uri string) (*Config, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -147,8 +322,9 @@ func ParseURI(uri string) (*Config, error) {
 		return nil, fmt.Errorf("invalid scheme: expected otpauth, got %s", u.Scheme)
 	}
 
-	if u.Host != "totp" {
-		return nil, fmt.Errorf("invalid type: expected totp, got %s", u.Host)
+	otpType := strings.ToLower(u.Host)
+	if otpType != TypeTOTP && otpType != TypeHOTP {
+		return nil, fmt.Errorf("invalid type: expected totp or hotp, got %s", u.Host)
 	}
 
 	// Parse query parameters
@@ -158,7 +334,31 @@ func ParseURI(uri string) (*Config, error) {
 		return nil, fmt.Errorf("missing secret parameter")
 	}
 
-	config := DefaultConfig(secret)
+	var config *Config
+	switch encoder := strings.ToLower(query.Get("encoder")); encoder {
+	case EncoderSteam:
+		config = SteamConfig(NormalizeSecret(secret))
+		config.Issuer = ""
+	case EncoderMOTP:
+		// mOTP secrets are used verbatim, usually as hex strings
+		config = MOTPConfig(secret, query.Get("pin"))
+	default:
+		config = DefaultConfig(NormalizeSecret(secret))
+		config.Encoder = encoder
+	}
+	config.Type = otpType
+
+	if config.IsHOTP() {
+		counter := query.Get("counter")
+		if counter == "" {
+			return nil, fmt.Errorf("missing counter parameter")
+		}
+		c, err := strconv.ParseUint(counter, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter: %w", err)
+		}
+		config.Counter = c
+	}
 
 	// Parse optional parameters
 	if issuer := query.Get("issuer"); issuer != "" {
@@ -181,7 +381,7 @@ func ParseURI(uri string) (*Config, error) {
 		config.Digits = d
 	}
 
-	if algorithm := query.Get("algorithm"); algorithm != "" {
+	if algorithm := query.Get("algorithm"); algorithm != "" && config.Encoder == EncoderRFC {
 		config.Algorithm = strings.ToUpper(algorithm)
 	}
 
@@ -199,11 +399,27 @@ func ParseURI(uri string) (*Config, error) {
 		}
 	}
 
+	if err := config.ValidateConfig(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
 // ToURI converts the config to an otpauth:// URI
+// The mOTP PIN is a second factor and is left out; use ToURIWithPIN to include it
 func (c *Config) ToURI() string {
+	return c.toURI(false)
+}
+
+// ToURIWithPIN converts the config to an otpauth:// URI that also carries the mOTP PIN
+// The result must not be shown as a QR code or leave the vault
+func (c *Config) ToURIWithPIN() string {
+	return c.toURI(true)
+}
+
+// toURI builds the otpauth:// URI, optionally including the mOTP PIN
+func (c *Config) toURI(includePIN bool) string {
 	values := url.Values{}
 	values.Set("secret", c.Secret)
 
@@ -211,16 +427,27 @@ func (c *Config) ToURI() string {
 		values.Set("issuer", c.Issuer)
 	}
 
-	if c.Period != 30*time.Second {
+	if c.IsHOTP() {
+		values.Set("counter", strconv.FormatUint(c.Counter, 10))
+	} else if c.Period != 30*time.Second {
 		values.Set("period", strconv.Itoa(int(c.Period.Seconds())))
 	}
 
-	if c.Digits != 6 {
-		values.Set("digits", strconv.Itoa(c.Digits))
-	}
-
-	if c.Algorithm != "SHA1" {
-		values.Set("algorithm", c.Algorithm)
+	switch c.Encoder {
+	case EncoderRFC:
+		if c.Digits != 6 {
+			values.Set("digits", strconv.Itoa(c.Digits))
+		}
+		if c.Algorithm != "SHA1" {
+			values.Set("algorithm", c.Algorithm)
+		}
+	case EncoderMOTP:
+		values.Set("encoder", c.Encoder)
+		if includePIN && c.PIN != "" {
+			values.Set("pin", c.PIN)
+		}
+	default:
+		values.Set("encoder", c.Encoder)
 	}
 
 	// Build label
@@ -231,5 +458,10 @@ func (c *Config) ToURI() string {
 		label = c.Issuer
 	}
 
-	return fmt.Sprintf("otpauth://totp/%s?%s", url.PathEscape(label), values.Encode())
+	otpType := TypeTOTP
+	if c.IsHOTP() {
+		otpType = TypeHOTP
+	}
+
+	return fmt.Sprintf("otpauth://%s/%s?%s", otpType, url.PathEscape(label), values.Encode())
 }
@@ -1,6 +1,7 @@
 package totp
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -157,10 +158,30 @@ func TestParseURI(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "Invalid type (not totp)",
+			name: "HOTP URI",
+			uri:  "otpauth://hotp/Bank:alice?secret=JBSWY3DPEHPK3PXP&counter=42",
+			want: &Config{
+				Type:      TypeHOTP,
+				Secret:    "JBSWY3DPEHPK3PXP",
+				Counter:   42,
+				Period:    30 * time.Second,
+				Digits:    6,
+				Algorithm: "SHA1",
+				Issuer:    "Bank",
+				Account:   "alice",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "HOTP URI without counter",
 			uri:     "otpauth://hotp/Test?secret=JBSWY3DPEHPK3PXP",
 			wantErr: true,
 		},
+		{
+			name:    "Invalid type",
+			uri:     "otpauth://motp/Test?secret=JBSWY3DPEHPK3PXP",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,6 +214,12 @@ func TestParseURI(t *testing.T) {
 			if got.Account != tt.want.Account {
 				t.Errorf("Account = %v, want %v", got.Account, tt.want.Account)
 			}
+			if tt.want.Type != "" && got.Type != tt.want.Type {
+				t.Errorf("Type = %v, want %v", got.Type, tt.want.Type)
+			}
+			if got.Counter != tt.want.Counter {
+				t.Errorf("Counter = %v, want %v", got.Counter, tt.want.Counter)
+			}
 		})
 	}
 }
@@ -226,6 +253,340 @@ func TestToURI(t *testing.T) {
 	}
 }
 
+func TestToURIOmitsPIN(t *testing.T) {
+	config := MOTPConfig("e3152afee62599c8", "1234")
+
+	if uri := config.ToURI(); strings.Contains(uri, "pin=") || strings.Contains(uri, "1234") {
+		t.Errorf("ToURI() = %q, want the PIN left out", uri)
+	}
+
+	parsed, err := ParseURI(config.ToURIWithPIN())
+	if err != nil {
+		t.Fatalf("ParseURI(ToURIWithPIN()) error = %v", err)
+	}
+	if parsed.PIN != "1234" {
+		t.Errorf("PIN = %q, want 1234", parsed.PIN)
+	}
+}
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226 Appendix D test values
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	config := DefaultConfig("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	config.Type = TypeHOTP
+
+	for counter, expected := range want {
+		code, err := config.NextCode()
+		if err != nil {
+			t.Fatalf("NextCode() error = %v", err)
+		}
+		if code != expected {
+			t.Errorf("counter %d: code = %v, want %v", counter, code, expected)
+		}
+	}
+
+	if config.Counter != uint64(len(want)) {
+		t.Errorf("Counter = %d, want %d", config.Counter, len(want))
+	}
+}
+
+func TestHOTPTenDigits(t *testing.T) {
+	// RFC 4226 Appendix D truncated values, all below 10^10
+	want := []string{"1284755224", "1094287082", "0137359152", "1726969429"}
+
+	config := DefaultConfig("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	config.Type = TypeHOTP
+	config.Digits = 10
+
+	for counter, expected := range want {
+		code, err := config.NextCode()
+		if err != nil {
+			t.Fatalf("NextCode() error = %v", err)
+		}
+		if code != expected {
+			t.Errorf("counter %d: code = %v, want %v", counter, code, expected)
+		}
+	}
+}
+
+func TestValidateCounterResync(t *testing.T) {
+	config := DefaultConfig("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	config.Type = TypeHOTP
+	config.Counter = 2
+
+	// Code for counter 5 lies inside the look-ahead window
+	next, ok := config.ValidateCounter("254676", 3)
+	if !ok {
+		t.Fatal("ValidateCounter() should accept code within the look-ahead window")
+	}
+	if next != 6 {
+		t.Errorf("next counter = %d, want 6", next)
+	}
+
+	// Code for counter 8 lies outside the window
+	if _, ok := config.ValidateCounter("399871", 3); ok {
+		t.Error("ValidateCounter() should reject code outside the look-ahead window")
+	}
+
+	// Codes for counters already used are rejected
+	if _, ok := config.ValidateCounter("755224", 3); ok {
+		t.Error("ValidateCounter() should reject code for a past counter")
+	}
+}
+
+func TestHOTPToURIRoundTrip(t *testing.T) {
+	config := DefaultConfig("JBSWY3DPEHPK3PXP")
+	config.Type = TypeHOTP
+	config.Counter = 7
+	config.Issuer = "VPN"
+	config.Account = "bob"
+
+	parsed, err := ParseURI(config.ToURI())
+	if err != nil {
+		t.Fatalf("ParseURI() error = %v", err)
+	}
+
+	if !parsed.IsHOTP() {
+		t.Errorf("Type = %v, want %v", parsed.Type, TypeHOTP)
+	}
+	if parsed.Counter != config.Counter {
+		t.Errorf("Counter = %d, want %d", parsed.Counter, config.Counter)
+	}
+	if parsed.Issuer != config.Issuer || parsed.Account != config.Account {
+		t.Errorf("label = %s:%s, want %s:%s", parsed.Issuer, parsed.Account, config.Issuer, config.Account)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantSecret string
+		wantDigits int
+		wantErr    bool
+	}{
+		{
+			name:       "Bare secret",
+			input:      "JBSWY3DPEHPK3PXP",
+			wantSecret: "JBSWY3DPEHPK3PXP",
+			wantDigits: 6,
+		},
+		{
+			name:       "Grouped lower-case secret with padding",
+			input:      "jbsw y3dp ehpk 3pxp====",
+			wantSecret: "JBSWY3DPEHPK3PXP",
+			wantDigits: 6,
+		},
+		{
+			name:       "URI with parameters",
+			input:      "otpauth://totp/Test?secret=JBSWY3DPEHPK3PXP&digits=8",
+			wantSecret: "JBSWY3DPEHPK3PXP",
+			wantDigits: 8,
+		},
+		{
+			name:    "Invalid Base32",
+			input:   "not-a-secret!",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported algorithm",
+			input:   "otpauth://totp/Test?secret=JBSWY3DPEHPK3PXP&algorithm=MD4",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid digits",
+			input:   "otpauth://totp/Test?secret=JBSWY3DPEHPK3PXP&digits=3",
+			wantErr: true,
+		},
+		{
+			name:    "Empty input",
+			input:   "  ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.Secret != tt.wantSecret {
+				t.Errorf("Secret = %v, want %v", got.Secret, tt.wantSecret)
+			}
+			if got.Digits != tt.wantDigits {
+				t.Errorf("Digits = %v, want %v", got.Digits, tt.wantDigits)
+			}
+		})
+	}
+}
+
+func TestSteamGuardVectors(t *testing.T) {
+	// Vectors from the ValvePython steam library (shared secret "superdupersecret")
+	config := SteamConfig("ON2XAZLSMR2XAZLSONSWG4TFOQ")
+
+	tests := map[int64]string{
+		3000029: "94R9D",
+		3000030: "YRGQJ",
+	}
+	for unix, expected := range tests {
+		code, _, err := config.GenerateCodeAt(time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateCodeAt() error = %v", err)
+		}
+		if code != expected {
+			t.Errorf("time %d: code = %v, want %v", unix, code, expected)
+		}
+	}
+}
+
+func TestMOTPVectors(t *testing.T) {
+	// mOTP codes are MD5(time/10 + secret + PIN) truncated to six hex characters,
+	// e.g. MD5("116593920" + "e3152afee62599c8" + "1234") starts with 4c0931
+	config := MOTPConfig("e3152afee62599c8", "1234")
+
+	tests := map[int64]string{
+		1165939200: "4c0931",
+		1700000000: "ac896a",
+	}
+	for unix, expected := range tests {
+		code, expiresIn, err := config.GenerateCodeAt(time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateCodeAt() error = %v", err)
+		}
+		if code != expected {
+			t.Errorf("time %d: code = %v, want %v", unix, code, expected)
+		}
+		if expiresIn != 10*time.Second {
+			t.Errorf("time %d: expiresIn = %v, want 10s", unix, expiresIn)
+		}
+	}
+}
+
+func TestParseEncoders(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantEncoder string
+		wantSecret  string
+		wantPIN     string
+		wantPeriod  time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "steam URI",
+			input:       "otpauth://totp/Steam:gaben?secret=ON2XAZLSMR2XAZLSONSWG4TFOQ&issuer=Steam&encoder=steam",
+			wantEncoder: EncoderSteam,
+			wantSecret:  "ON2XAZLSMR2XAZLSONSWG4TFOQ",
+			wantPeriod:  30 * time.Second,
+		},
+		{
+			name:        "steam prefix",
+			input:       "steam://on2xazlsmr2xazlsonswg4tfoq",
+			wantEncoder: EncoderSteam,
+			wantSecret:  "ON2XAZLSMR2XAZLSONSWG4TFOQ",
+			wantPeriod:  30 * time.Second,
+		},
+		{
+			name:        "motp URI keeps secret verbatim",
+			input:       "otpauth://totp/alice?secret=e3152afee62599c8&encoder=motp&pin=1234",
+			wantEncoder: EncoderMOTP,
+			wantSecret:  "e3152afee62599c8",
+			wantPIN:     "1234",
+			wantPeriod:  10 * time.Second,
+		},
+		{
+			name:    "steam HOTP",
+			input:   "otpauth://hotp/Steam?secret=ON2XAZLSMR2XAZLSONSWG4TFOQ&counter=1&encoder=steam",
+			wantErr: true,
+		},
+		{
+			name:    "unknown encoder",
+			input:   "otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&encoder=yubi",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Encoder != tt.wantEncoder {
+				t.Errorf("Encoder = %v, want %v", got.Encoder, tt.wantEncoder)
+			}
+			if got.Secret != tt.wantSecret {
+				t.Errorf("Secret = %v, want %v", got.Secret, tt.wantSecret)
+			}
+			if got.PIN != tt.wantPIN {
+				t.Errorf("PIN = %v, want %v", got.PIN, tt.wantPIN)
+			}
+			if got.Period != tt.wantPeriod {
+				t.Errorf("Period = %v, want %v", got.Period, tt.wantPeriod)
+			}
+
+			// Encoder settings survive a URI round trip
+			parsed, err := ParseURI(got.ToURIWithPIN())
+			if err != nil {
+				t.Fatalf("ParseURI(ToURIWithPIN()) error = %v", err)
+			}
+			if parsed.Encoder != got.Encoder || parsed.PIN != got.PIN || parsed.Secret != got.Secret {
+				t.Errorf("round trip = %+v, want %+v", parsed, got)
+			}
+		})
+	}
+}
+
+type reverseEncoder struct{}
+
+func (reverseEncoder) Encode(c *Config, counter uint64) (string, error) {
+	code, err := rfcEncoder{}.Encode(c, counter)
+	if err != nil {
+		return "", err
+	}
+	runes := []rune(code)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func (reverseEncoder) Validate(c *Config) error {
+	return rfcEncoder{}.Validate(c)
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	RegisterEncoder("reverse", reverseEncoder{})
+
+	config := DefaultConfig("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	config.Type = TypeHOTP
+	config.Encoder = "reverse"
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+
+	code, err := config.GenerateCodeAtCounter(0)
+	if err != nil {
+		t.Fatalf("GenerateCodeAtCounter() error = %v", err)
+	}
+	if code != "422557" {
+		t.Errorf("code = %v, want 422557", code)
+	}
+}
+
 func BenchmarkGenerateCode(b *testing.B) {
 	config := DefaultConfig("JBSWY3DPEHPK3PXP")
 